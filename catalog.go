@@ -0,0 +1,162 @@
+package codelint
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// RuleDescription describes a rule of the built-in catalog with its
+// default configuration, for tools and editor integrations
+type RuleDescription struct {
+	// ID is the rule's key in the rules configuration and, for most rules,
+	// the Rule of its results
+	ID string `json:"id"`
+
+	// Category is the check (--checks) the rule belongs to
+	Category string `json:"category"`
+
+	// Description says what the rule flags
+	Description string `json:"description"`
+
+	// Enabled and Severity are the rule's defaults
+	Enabled  bool   `json:"enabled"`
+	Severity string `json:"severity"`
+
+	// Parameters lists the rule's parameters, sorted by name
+	Parameters []ParameterDescription `json:"parameters"`
+}
+
+// ParameterDescription describes a rule parameter
+type ParameterDescription struct {
+	Name string `json:"name"`
+
+	// Type is "integer", "number", "boolean", "string", "string list" or
+	// "object"
+	Type string `json:"type"`
+
+	Default interface{} `json:"default"`
+}
+
+// ruleInfo is the part of a RuleDescription not found in the default
+// rules configuration
+type ruleInfo struct {
+	category    string
+	description string
+}
+
+// ruleCatalog describes every rule of the default rules configuration
+var ruleCatalog = map[string]ruleInfo{
+	"license-headers":        {"license-headers", "Files must start with a license or copyright header"},
+	"header-guards":          {"header-guards", "Headers need an #ifndef/#define/#endif guard or #pragma once, with the guard defined right after the #ifndef"},
+	"header-guard-collision": {"header-guards", "Headers must not share a guard macro"},
+	"naming-conventions":     {"naming-conventions", "Functions in C files use snake_case"},
+	"formatting":             {"formatting", "Lines stay within max_line_length (reported as line-length), and files use spaces rather than tabs"},
+	"trailing-whitespace":    {"formatting", "Lines must not end in whitespace; whitespace-only lines may be reported as blank-line-whitespace"},
+	"non-ascii":              {"formatting", "Non-ASCII characters outside string literals and comments"},
+	"encoding":               {"formatting", "Files must be valid in one of the allowed encodings"},
+	"empty-file":             {"formatting", "Files without any content"},
+	"leading-blank-lines":    {"formatting", "Blank lines at the start of a file"},
+	"function-spacing":       {"formatting", "Blank lines between top-level function definitions must be within a range"},
+	"include-style":          {"formatting", "Project headers use quotes and system headers angle brackets"},
+	"max-includes":           {"readability", "Files with too many #include directives"},
+	"include-depth":          {"readability", "Headers whose chain of nested includes is too deep"},
+	"banned-include":         {"readability", "Includes of headers banned for the including file"},
+	"own-header-first":       {"readability", "Source files whose first include isn't their own header"},
+	"indent-width":           {"formatting", "Space indentation must be a multiple of the indent width"},
+	"max-indent":             {"formatting", "Lines indented past a column once tabs are expanded"},
+	"redundant-else":         {"readability", "else branches after an if body that always returns, breaks, continues or throws"},
+	"todo-density":           {"readability", "Files with too many TODO/FIXME markers"},
+	"todo-format":            {"readability", "TODO/FIXME markers must reference a ticket and/or a date that isn't overdue"},
+	"if-zero":                {"readability", "Code disabled with #if 0"},
+	"comment-alignment":      {"formatting", "Trailing comments drifting from the alignment of their block"},
+	"section-comments":       {"readability", "Long files without section divider comments"},
+	"namespace-indentation":  {"formatting", "Indentation of namespace contents"},
+	"tab-alignment":          {"formatting", "Indentation mixing tabs and spaces"},
+	"mid-line-tab":           {"formatting", "Tabs after the first non-whitespace character of a line"},
+	"keyword-space":          {"formatting", "Control keywords must be followed by a space before the parenthesis"},
+	"empty-statement":        {"bugprone", "Stray semicolons after control headers and empty statements"},
+	"macro-hygiene":          {"bugprone", "Function-like macros with unparenthesized parameters or bodies"},
+	"mutable-global":         {"bugprone", "Mutable variables at namespace or file scope"},
+	"using-namespace":        {"bugprone", "using namespace directives in any file: at file or namespace scope with the rule's severity, inside functions only with function_scope_severity (none by default)"},
+	"implicit-int":           {"bugprone", "C function definitions without a return type"},
+	"unused-static":          {"bugprone", "Static functions never used in their file"},
+	"void-return":            {"bugprone", "Return statements with a value in constructors, destructors and void functions"},
+	"library-io":             {"bugprone", "Writes to the standard streams in library code"},
+	"missing-override":       {"bugprone", "Virtual methods of derived classes not marked override or final"},
+	"constant-condition":     {"bugprone", "if and while conditions that are a literal constant"},
+	"header-definition":      {"bugprone", "Non-inline function definitions in headers"},
+	"deprecated-api":         {"bugprone", "Calls to functions marked DEPRECATED in a header"},
+	"class-key":              {"bugprone", "Types declared with both struct and class"},
+	"catch-handler":          {"bugprone", "catch (...) handlers and empty catch blocks"},
+	"macro-constant":         {"readability", "Object-like macros defining constants in C++"},
+	"long-parameter-list":    {"readability", "Functions with too many parameters"},
+	"parameter-wrapping":     {"readability", "Inconsistent wrapping of function parameter lists"},
+	"nesting-depth":          {"readability", "Blocks nested too deeply inside a function body"},
+	"header-docs":            {"readability", "Public declarations in headers without a documentation comment"},
+	"closing-comment":        {"readability", "Closing braces of long blocks without a comment naming the block"},
+	"identifier-length":      {"readability", "Identifiers longer than a maximum length"},
+	"predicate-name":         {"readability", "Functions returning bool must be named as predicates"},
+	"boolean-complexity":     {"readability", "Conditions with too many && and || operators"},
+}
+
+// DescribeRules returns the catalog of built-in rules, sorted by ID, with
+// their default configuration
+func DescribeRules() []RuleDescription {
+	defaults := defaultRulesConfig()
+
+	descriptions := make([]RuleDescription, 0, len(defaults.Rules))
+	for id, rule := range defaults.Rules {
+		info := ruleCatalog[id]
+		description := RuleDescription{
+			ID:          id,
+			Category:    info.category,
+			Description: info.description,
+			Enabled:     rule.Enabled,
+			Severity:    rule.Severity,
+			Parameters:  []ParameterDescription{},
+		}
+		for name, value := range rule.Parameters {
+			description.Parameters = append(description.Parameters, ParameterDescription{
+				Name:    name,
+				Type:    parameterType(value),
+				Default: value,
+			})
+		}
+		sort.Slice(description.Parameters, func(i, j int) bool {
+			return description.Parameters[i].Name < description.Parameters[j].Name
+		})
+		descriptions = append(descriptions, description)
+	}
+
+	sort.Slice(descriptions, func(i, j int) bool {
+		return descriptions[i].ID < descriptions[j].ID
+	})
+	return descriptions
+}
+
+// WriteRulesCatalog writes DescribeRules as an indented JSON array
+func WriteRulesCatalog(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(DescribeRules())
+}
+
+// parameterType names the JSON type of a default parameter value
+func parameterType(value interface{}) string {
+	switch value.(type) {
+	case int:
+		return "integer"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []string:
+		return "string list"
+	default:
+		return "object"
+	}
+}
@@ -0,0 +1,104 @@
+package codelint
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestWriteRulesCatalog(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteRulesCatalog(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var catalog []RuleDescription
+	if err := json.Unmarshal(buf.Bytes(), &catalog); err != nil {
+		t.Fatalf("invalid catalog JSON: %v", err)
+	}
+
+	defaults := defaultRulesConfig()
+	var ids, wantIDs []string
+	for _, rule := range catalog {
+		ids = append(ids, rule.ID)
+	}
+	for id := range defaults.Rules {
+		wantIDs = append(wantIDs, id)
+	}
+	sort.Strings(wantIDs)
+	if !reflect.DeepEqual(ids, wantIDs) {
+		t.Fatalf("catalog rules %v, want %v", ids, wantIDs)
+	}
+
+	for _, rule := range catalog {
+		t.Run(rule.ID, func(t *testing.T) {
+			def := defaults.Rules[rule.ID]
+			if rule.Category == "" || rule.Description == "" {
+				t.Errorf("category %q, description %q", rule.Category, rule.Description)
+			}
+			if rule.Enabled != def.Enabled || rule.Severity != def.Severity {
+				t.Errorf("enabled %v with severity %s, want %v with %s", rule.Enabled, rule.Severity, def.Enabled, def.Severity)
+			}
+
+			if len(rule.Parameters) != len(def.Parameters) {
+				t.Errorf("%d parameters, want %d", len(rule.Parameters), len(def.Parameters))
+			}
+			for i, param := range rule.Parameters {
+				if i > 0 && rule.Parameters[i-1].Name >= param.Name {
+					t.Errorf("parameter %s listed after %s", param.Name, rule.Parameters[i-1].Name)
+				}
+				value, ok := def.Parameters[param.Name]
+				if !ok {
+					t.Errorf("unknown parameter %s", param.Name)
+					continue
+				}
+				if want := parameterType(value); param.Type != want {
+					t.Errorf("parameter %s has type %q, want %q", param.Name, param.Type, want)
+				}
+				// Compare defaults in their JSON form
+				got, _ := json.Marshal(param.Default)
+				want, _ := json.Marshal(value)
+				if !bytes.Equal(got, want) {
+					t.Errorf("parameter %s defaults to %s, want %s", param.Name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRuleCatalogCategories(t *testing.T) {
+	// Every registered rule's check has catalog entries, and every
+	// catalog category is a check some registered rule belongs to
+	registered := make(map[string]bool)
+	for _, rule := range newRulesWithConfig(DefaultConfig(), defaultRulesConfig()).rules {
+		registered[rule.Name()] = true
+	}
+	categories := make(map[string]bool)
+	for _, rule := range DescribeRules() {
+		categories[rule.Category] = true
+	}
+	if !reflect.DeepEqual(categories, registered) {
+		t.Errorf("catalog categories %v, want the checks of the registered rules %v", categories, registered)
+	}
+}
+
+func TestParameterType(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{40, "integer"},
+		{0.5, "number"},
+		{true, "boolean"},
+		{"^//", "string"},
+		{[]string{"lib"}, "string list"},
+		{map[string]interface{}{}, "object"},
+		{nil, "object"},
+	}
+	for _, tc := range tests {
+		if got := parameterType(tc.value); got != tc.want {
+			t.Errorf("parameterType(%#v) = %q, want %q", tc.value, got, tc.want)
+		}
+	}
+}
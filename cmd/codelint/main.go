@@ -1,10 +1,13 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	codelint "github.com/nirohfeld/code_linter"
 )
@@ -12,30 +15,78 @@ import (
 func main() {
 	// Define command-line flags
 	var (
-		rootDir     = flag.String("root", ".", "Root directory to scan")
+		rootDir     = flag.String("root", ".", "Comma-separated list of root directories to scan")
 		includeDirs = flag.String("include", "", "Comma-separated list of directories to include")
 		excludeDirs = flag.String("exclude", ".git,build,third_party,vendor", "Comma-separated list of directories to exclude")
+		skipHidden  = flag.Bool("skip-hidden", false, "Skip hidden files and directories (names starting with '.')")
 		fileTypes   = flag.String("types", ".c,.cc,.cpp,.h,.hpp", "Comma-separated list of file extensions")
-		checks      = flag.String("checks", "formatting,naming-conventions,header-guards,license-headers", "Comma-separated list of checks")
+		lang        = flag.String("lang", "", "Apply C or C++ rules to every file regardless of extension: c or cpp")
+		since       = flag.String("changed-since", "", "Only lint files modified within a duration (e.g. 2h) or since a timestamp (RFC 3339 or YYYY-MM-DD)")
+		checks      = flag.String("checks", "formatting,naming-conventions,header-guards,license-headers", "Comma-separated list of checks (bugprone and readability are opt-in)")
+		forceChecks = flag.Bool("force-checks", false, "Run the checks named in --checks even if the rules configuration disables them")
+		relative    = flag.Bool("relative-paths", true, "Report file paths relative to the root directory (false = absolute paths)")
+		relativeTo  = flag.String("paths-relative-to", codelint.PathsRelativeToRoot, "Base of relative file paths: root or cwd (the working directory)")
+		slashes     = flag.Bool("forward-slashes", false, "Report file paths with forward slashes on every platform")
+		canonical   = flag.Bool("canonical", false, "Make output identical across platforms: forward slashes in every path, including Windows-style ones")
 		verbose     = flag.Bool("verbose", false, "Enable verbose output")
+		quiet       = flag.Bool("quiet", false, "Print only findings: no summary, no clean-run message, no fix count")
+		errorsOnly  = flag.Bool("errors-only", false, "Print only error results; the exit code still reflects every result")
+		format      = flag.String("format", "text", "Output format: text, concise, json, ndjson or sarif")
+		groupBy     = flag.String("group-by", "", "With text or concise output, print results in sections per file, rule or severity")
+		templ       = flag.String("template", "", "Print each result with a Go template, e.g. '{{.File}}:{{.Line}}: {{.Message}}' (replaces --format)")
+		profile     = flag.Int("profile-files", 10, "With --verbose, list this many of the slowest files to check (0 = none)")
+		summaryFmt  = flag.String("summary-format", "", "Also write a one-line summary to stderr: text or json")
+		fix         = flag.Bool("fix", false, "Apply automatic fixes where available")
 		maxErrors   = flag.Int("max-errors", 0, "Maximum number of errors before stopping (0 = no limit)")
+		maxErrMode  = flag.String("max-errors-mode", codelint.MaxErrorsStop, "What reaching --max-errors does: stop (end the run) or mark (check everything, still exit 3)")
+		collapse    = flag.Bool("collapse-ranges", false, "Merge results of the same rule on consecutive lines into one ranged result")
+		noSort      = flag.Bool("no-sort", false, "Don't sort results across files; with concise or ndjson output, print each file's results as soon as it is checked")
+		maxPerFile  = flag.Int("max-results-per-file", 0, "Maximum number of results reported per file (0 = no limit)")
+		fileTimeout = flag.Duration("file-timeout", 0, "Abandon a file's checks after this long, e.g. 5s (0 = no limit)")
+		required    = flag.String("require-rules", "", "Comma-separated list of rules that must be enabled by the rules config")
+		offline     = flag.Bool("offline", false, "Never access the network (rules out --webhook)")
+		preCommit   = flag.Bool("pre-commit", false, "Preset for pre-commit hooks: fast checks only, offline, relative paths, concise output, fail on errors")
+		rulesFile   = flag.String("config", "", "Rules configuration file (default: .codelint.json in the root directory)")
+		ownersFile  = flag.String("owners", "", "File mapping path globs to owners; annotates each result with its owner")
+		failOn      = flag.String("fail-on", codelint.SeverityError, "Lowest severity that fails the run: error, warning or info")
+		reportFile  = flag.String("report", "", "Write a JSON run report (version, timestamp, configuration, results) for audit trails")
+		statusFile  = flag.String("status-file", "", "Write a JSON status file (passed, exit code, counts by severity)")
+		webhookURL  = flag.String("webhook", "", "POST the JSON results to this URL at the end of the run (not with --offline)")
+		webhookWait = flag.Duration("webhook-timeout", codelint.DefaultWebhookTimeout, "Timeout of each --webhook attempt")
+		errorExit   = flag.Int("error-exit-code", codelint.ExitLintErrors, "Exit code used when errors are found")
+		initConfig  = flag.Bool("init", false, "Write a .codelint.json with the default rules configuration to the root directory and exit")
+		force       = flag.Bool("force", false, "With --init, overwrite an existing .codelint.json")
+		dumpRules   = flag.Bool("dump-rules-json", false, "Print every rule with its ID, description, default severity and parameters as JSON and exit")
 		help        = flag.Bool("help", false, "Show help message")
 	)
 
+	var errorRules stringList
+	flag.Var(&errorRules, "error-rule", "Promote a rule's results to error severity (repeatable or comma-separated)")
+
 	flag.Parse()
 
 	if *help {
 		fmt.Println("Code Linter - A fast C/C++ code quality checker")
-		fmt.Println("\nUsage:")
+		fmt.Println("\nUsage: codelint [flags] [file...]")
 		flag.PrintDefaults()
 		fmt.Println("\nAvailable checks:")
 		fmt.Println("  - license-headers: Check for license headers")
 		fmt.Println("  - header-guards: Verify header include guards")
 		fmt.Println("  - naming-conventions: Check naming standards")
 		fmt.Println("  - formatting: Check code formatting")
+		fmt.Println("  - bugprone: Detect patterns that are likely bugs (opt-in)")
+		fmt.Println("  - readability: Flag hard-to-read code structure (opt-in)")
 		os.Exit(0)
 	}
 
+	if *dumpRules {
+		if err := codelint.WriteRulesCatalog(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(codelint.ExitInternalError)
+		}
+		os.Exit(codelint.ExitSuccess)
+	}
+
 	// Parse comma-separated values
 	parseCSV := func(s string) []string {
 		if s == "" {
@@ -51,15 +102,166 @@ func main() {
 		return result
 	}
 
+	// Flags given explicitly still win over the pre-commit preset
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if *quiet && *verbose {
+		fmt.Fprintf(os.Stderr, "Error: --quiet and --verbose cannot be used together\n")
+		os.Exit(codelint.ExitInternalError)
+	}
+	if (*preCommit && !set["format"]) || (*quiet && *format == "text") {
+		*format = "concise"
+	}
+
+	var reporter codelint.Reporter
+	var err error
+	if *templ != "" {
+		if set["format"] {
+			fmt.Fprintf(os.Stderr, "Error: --template and --format cannot be used together\n")
+			os.Exit(codelint.ExitInternalError)
+		}
+		*format = "template"
+		reporter, err = codelint.NewTemplateReporter(*templ)
+	} else {
+		reporter, err = codelint.NewReporter(*format)
+	}
+	if err == nil && *groupBy != "" {
+		if *format != "text" && *format != "concise" {
+			fmt.Fprintf(os.Stderr, "Error: --group-by only applies to text and concise output\n")
+			os.Exit(codelint.ExitInternalError)
+		}
+		reporter, err = codelint.NewGroupedReporter(*groupBy, *format == "text")
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(codelint.ExitInternalError)
+	}
+
+	if *summaryFmt != "" && *summaryFmt != "text" && *summaryFmt != "json" {
+		fmt.Fprintf(os.Stderr, "Error: --summary-format must be text or json\n")
+		os.Exit(codelint.ExitInternalError)
+	}
+
+	if *failOn != codelint.SeverityError && *failOn != codelint.SeverityWarning && *failOn != codelint.SeverityInfo {
+		fmt.Fprintf(os.Stderr, "Error: --fail-on must be error, warning or info\n")
+		os.Exit(codelint.ExitInternalError)
+	}
+
+	if *relativeTo != codelint.PathsRelativeToRoot && *relativeTo != codelint.PathsRelativeToCWD {
+		fmt.Fprintf(os.Stderr, "Error: --paths-relative-to must be root or cwd\n")
+		os.Exit(codelint.ExitInternalError)
+	}
+
+	if *lang != "" && *lang != codelint.LanguageC && *lang != codelint.LanguageCPP {
+		fmt.Fprintf(os.Stderr, "Error: --lang must be c or cpp\n")
+		os.Exit(codelint.ExitInternalError)
+	}
+
+	if *maxErrMode != codelint.MaxErrorsStop && *maxErrMode != codelint.MaxErrorsMark {
+		fmt.Fprintf(os.Stderr, "Error: --max-errors-mode must be stop or mark\n")
+		os.Exit(codelint.ExitInternalError)
+	}
+
+	if *errorExit == codelint.ExitSuccess || *errorExit == codelint.ExitInternalError || *errorExit == codelint.ExitMaxErrors {
+		fmt.Fprintf(os.Stderr, "Error: --error-exit-code must differ from %d, %d and %d\n",
+			codelint.ExitSuccess, codelint.ExitInternalError, codelint.ExitMaxErrors)
+		os.Exit(codelint.ExitInternalError)
+	}
+
 	// Build configuration
+	defaults := codelint.DefaultConfig()
 	config := codelint.Config{
-		RootDir:     *rootDir,
-		IncludeDirs: parseCSV(*includeDirs),
-		ExcludeDirs: parseCSV(*excludeDirs),
-		FileTypes:   parseCSV(*fileTypes),
-		Checks:      parseCSV(*checks),
-		Verbose:     *verbose,
-		MaxErrors:   *maxErrors,
+		RootDir:              *rootDir,
+		IncludeDirs:          parseCSV(*includeDirs),
+		ExcludeDirs:          parseCSV(*excludeDirs),
+		SkipHidden:           *skipHidden,
+		FileTypes:            parseCSV(*fileTypes),
+		AbsolutePaths:        !*relative,
+		PathsRelativeTo:      *relativeTo,
+		SlashPaths:           *slashes,
+		Canonical:            *canonical,
+		Checks:               parseCSV(*checks),
+		ForceChecks:          *forceChecks,
+		Verbose:              *verbose,
+		ProfileFiles:         *profile,
+		MaxErrors:            *maxErrors,
+		MaxErrorsMode:        *maxErrMode,
+		MaxResultsPerFile:    *maxPerFile,
+		FileTimeout:          *fileTimeout,
+		CollapseRanges:       *collapse,
+		Language:             *lang,
+		NoSort:               *noSort,
+		GeneratedMarkers:     defaults.GeneratedMarkers,
+		GeneratedMarkerLines: defaults.GeneratedMarkerLines,
+		Offline:              *offline,
+		RulesFile:            *rulesFile,
+		RequiredRules:        parseCSV(*required),
+		ErrorRules:           parseCSV(strings.Join(errorRules, ",")),
+		Files:                flag.Args(),
+		OwnersFile:           *ownersFile,
+		FailOn:               *failOn,
+		ErrorExitCode:        *errorExit,
+	}
+
+	if *preCommit {
+		preset := codelint.PreCommitConfig(config)
+		if !set["checks"] {
+			config.Checks = preset.Checks
+		}
+		if !set["relative-paths"] {
+			config.AbsolutePaths = preset.AbsolutePaths
+		}
+		if !set["fail-on"] {
+			config.FailOn = preset.FailOn
+		}
+		if !set["verbose"] {
+			config.Verbose = preset.Verbose
+		}
+		config.Offline = preset.Offline
+	}
+
+	// The webhook is the only other network access; --offline rules it out
+	var webhook *codelint.Webhook
+	if *webhookURL != "" {
+		if config.Offline {
+			fmt.Fprintf(os.Stderr, "Error: --webhook cannot be used with --offline\n")
+			os.Exit(codelint.ExitInternalError)
+		}
+		webhook, err = codelint.NewWebhook(*webhookURL)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(codelint.ExitInternalError)
+		}
+		webhook.Client = &http.Client{Timeout: *webhookWait}
+	}
+
+	if *since != "" {
+		changedSince, err := parseChangedSince(*since, time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(codelint.ExitInternalError)
+		}
+		config.ChangedSince = changedSince
+	}
+
+	// Several roots may be given; the first one also anchors the rules config
+	if roots := parseCSV(*rootDir); len(roots) > 1 {
+		config.RootDir = roots[0]
+		config.RootDirs = roots
+	}
+
+	if *initConfig {
+		path, err := codelint.InitRulesConfig(config.RootDir, *force)
+		if errors.Is(err, os.ErrExist) {
+			fmt.Fprintf(os.Stderr, "Error: %v (use --force to overwrite)\n", err)
+			os.Exit(codelint.ExitInternalError)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(codelint.ExitInternalError)
+		}
+		fmt.Printf("Wrote %s\n", path)
+		os.Exit(codelint.ExitSuccess)
 	}
 
 	// If no include dirs specified, use current directory
@@ -67,19 +269,124 @@ func main() {
 		config.IncludeDirs = []string{"."}
 	}
 
+	// exit records the outcome in the status file, if requested, and exits
+	exit := func(results []codelint.Result, code int) {
+		if *statusFile != "" {
+			if err := codelint.WriteStatusFile(*statusFile, results, code); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(codelint.ExitInternalError)
+			}
+		}
+		os.Exit(code)
+	}
+
+	// --errors-only hides lower severities from the output but not from
+	// the exit code
+	shown := func(results []codelint.Result) []codelint.Result {
+		if !*errorsOnly {
+			return results
+		}
+		var errors []codelint.Result
+		for _, r := range results {
+			if r.Severity == codelint.SeverityError {
+				errors = append(errors, r)
+			}
+		}
+		return errors
+	}
+
+	// Line-based formats can print results as files finish when the
+	// overall order doesn't matter
+	var opts []codelint.Option
+	var streamErr error
+	stream := *noSort && *groupBy == "" && (*format == "concise" || *format == "ndjson" || *format == "template")
+	if stream {
+		opts = append(opts, codelint.WithResultHandler(func(results []codelint.Result) {
+			if streamErr == nil {
+				streamErr = reporter.Report(os.Stdout, shown(results), codelint.Summary{})
+			}
+		}))
+	}
+
 	// Create and run linter
-	linter := codelint.New(config)
-	results, err := linter.Run()
+	linter := codelint.New(config, opts...)
+	results, summary, err := linter.RunWithSummary()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(2)
+		exit(nil, codelint.ExitCode(nil, err, config))
+	}
+
+	if *reportFile != "" {
+		if err := codelint.WriteRunReport(*reportFile, linter.NewRunReport(results)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(results, codelint.ExitInternalError)
+		}
+	}
+
+	if webhook != nil {
+		if err := webhook.Post(results, summary); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(results, codelint.ExitInternalError)
+		}
 	}
 
 	// Print results
-	codelint.PrintResults(results)
+	if !stream {
+		streamErr = reporter.Report(os.Stdout, shown(results), summary)
+	}
+	if streamErr != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", streamErr)
+		exit(results, codelint.ExitInternalError)
+	}
+
+	if *summaryFmt != "" {
+		if err := codelint.WriteMachineSummary(os.Stderr, *summaryFmt, results, linter.FilesScanned()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(results, codelint.ExitInternalError)
+		}
+	}
+
+	if *fix {
+		applied, err := linter.ApplyFixes(results)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(results, codelint.ExitCode(nil, err, config))
+		}
+		// Keep structured output on stdout parseable
+		if *format == "text" {
+			fmt.Printf("Applied %d fixes\n", applied)
+		} else if !*quiet {
+			fmt.Fprintf(os.Stderr, "Applied %d fixes\n", applied)
+		}
+	}
 
 	// Exit with appropriate code
-	if codelint.HasErrors(results) {
-		os.Exit(1)
+	exit(results, codelint.ExitCode(results, nil, config))
+}
+
+// stringList is a repeatable string flag
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// parseChangedSince accepts a duration relative to now, an RFC 3339
+// timestamp or a plain date
+func parseChangedSince(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
 	}
-}
\ No newline at end of file
+	return time.Time{}, fmt.Errorf("invalid --changed-since value %q: want a duration, RFC 3339 timestamp or YYYY-MM-DD date", value)
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	codelint "github.com/nirohfeld/code_linter"
+)
+
+// TestMain runs main instead of the tests when re-executed by runMain
+func TestMain(m *testing.M) {
+	if encoded := os.Getenv("CODELINT_TEST_ARGS"); encoded != "" {
+		var args []string
+		if err := json.Unmarshal([]byte(encoded), &args); err != nil {
+			panic(err)
+		}
+		os.Args = append([]string{"codelint"}, args...)
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runMain runs the command with args in a child process and returns its
+// output and exit code
+func runMain(t *testing.T, args ...string) (stdout, stderr string, code int) {
+	t.Helper()
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), "CODELINT_TEST_ARGS="+string(encoded))
+	var out, errOut bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &errOut
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		code = exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return out.String(), errOut.String(), code
+}
+
+// writeTree creates files, given by slash-separated path, below a
+// temporary directory and returns it
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestParseChangedSince(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "2h", want: now.Add(-2 * time.Hour)},
+		{value: "90m", want: now.Add(-90 * time.Minute)},
+		{value: "2024-03-01T08:30:00Z", want: time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)},
+		{value: "2024-03-01", want: time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)},
+		{value: "yesterday", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		got, err := parseChangedSince(tc.value, now)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("parseChangedSince(%q) = %v, want an error", tc.value, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseChangedSince(%q): %v", tc.value, err)
+		} else if !got.Equal(tc.want) {
+			t.Errorf("parseChangedSince(%q) = %v, want %v", tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestQuiet(t *testing.T) {
+	clean := writeTree(t, map[string]string{"a.c": "/* Copyright */\nint x;\n"})
+	dirty := writeTree(t, map[string]string{"a.c": "/* Copyright */\nint x; \n"})
+	const finding = "WARNING: a.c:2:7: Line has trailing whitespace [trailing-whitespace]\n"
+
+	tests := []struct {
+		name   string
+		args   []string
+		stdout string
+		code   int
+	}{
+		{
+			name:   "clean run",
+			args:   []string{"--root", clean},
+			stdout: "No issues found!\n",
+		},
+		{
+			name: "quiet clean run",
+			args: []string{"--root", clean, "--quiet"},
+		},
+		{
+			name:   "quiet findings",
+			args:   []string{"--root", dirty, "--quiet"},
+			stdout: finding,
+		},
+		{
+			name:   "quiet keeps the exit code",
+			args:   []string{"--root", dirty, "--quiet", "--fail-on", "warning"},
+			stdout: finding,
+			code:   codelint.ExitLintErrors,
+		},
+		{
+			name: "quiet errors only",
+			args: []string{"--root", dirty, "--quiet", "--errors-only"},
+		},
+		{
+			name: "quiet and verbose",
+			args: []string{"--root", clean, "--quiet", "--verbose"},
+			code: codelint.ExitInternalError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			stdout, stderr, code := runMain(t, tc.args...)
+			if stdout != tc.stdout || code != tc.code {
+				t.Errorf("stdout %q with exit code %d, want %q with %d (stderr %q)", stdout, code, tc.stdout, tc.code, stderr)
+			}
+			if tc.code != codelint.ExitInternalError && stderr != "" {
+				t.Errorf("stderr %q, want nothing", stderr)
+			}
+		})
+	}
+}
+
+func TestWebhookFlags(t *testing.T) {
+	root := writeTree(t, map[string]string{"a.c": "/* Copyright */\nint x;\n"})
+	tests := []struct {
+		name   string
+		args   []string
+		stderr string
+	}{
+		{
+			name:   "offline",
+			args:   []string{"--root", root, "--webhook", "http://127.0.0.1:1/hook", "--offline"},
+			stderr: "Error: --webhook cannot be used with --offline\n",
+		},
+		{
+			name:   "pre-commit preset is offline",
+			args:   []string{"--root", root, "--webhook", "http://127.0.0.1:1/hook", "--pre-commit"},
+			stderr: "Error: --webhook cannot be used with --offline\n",
+		},
+		{
+			name:   "invalid URL",
+			args:   []string{"--root", root, "--webhook", "file:///etc/passwd"},
+			stderr: "Error: invalid webhook URL \"file:///etc/passwd\": want an http or https URL\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, stderr, code := runMain(t, tc.args...)
+			if stderr != tc.stderr || code != codelint.ExitInternalError {
+				t.Errorf("stderr %q with exit code %d, want %q with %d", stderr, code, tc.stderr, codelint.ExitInternalError)
+			}
+		})
+	}
+}
+
+func TestDumpRulesJSON(t *testing.T) {
+	// The catalog is printed before any other flag is looked at
+	for _, args := range [][]string{
+		{"--dump-rules-json"},
+		{"--dump-rules-json", "--root", filepath.Join(t.TempDir(), "missing"), "--checks", "bogus"},
+	} {
+		stdout, stderr, code := runMain(t, args...)
+		if stderr != "" || code != codelint.ExitSuccess {
+			t.Fatalf("%v: stderr %q with exit code %d", args, stderr, code)
+		}
+
+		var catalog []codelint.RuleDescription
+		if err := json.Unmarshal([]byte(stdout), &catalog); err != nil {
+			t.Fatalf("%v: invalid JSON: %v", args, err)
+		}
+		want := codelint.DescribeRules()
+		if len(catalog) != len(want) {
+			t.Fatalf("%v: %d rules, want %d", args, len(catalog), len(want))
+		}
+		for i, rule := range catalog {
+			if rule.ID != want[i].ID || rule.Severity != want[i].Severity || len(rule.Parameters) != len(want[i].Parameters) {
+				t.Errorf("%v: rule %d is %+v, want %+v", args, i, rule, want[i])
+			}
+		}
+	}
+}
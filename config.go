@@ -1,28 +1,136 @@
 // Package codelint provides a code linting framework for C/C++ projects
 package codelint
 
+import "time"
+
 // Config defines the configuration for the linter
 type Config struct {
 	// RootDir is the root directory to scan
 	RootDir string
 
+	// RootDirs scans several root directories in one run; when set it
+	// takes precedence over RootDir
+	RootDirs []string
+
+	// Files lists the exact files to lint; when set, the directories are
+	// not walked (only FileTypes filtering applies)
+	Files []string
+
 	// IncludeDirs are directories to include in the scan (relative to RootDir)
 	IncludeDirs []string
 
 	// ExcludeDirs are directories to exclude from the scan
 	ExcludeDirs []string
 
+	// SkipHidden skips every file and directory whose name starts with '.'
+	// (by default only ExcludeDirs such as .git are skipped)
+	SkipHidden bool
+
 	// FileTypes are the file extensions to check (e.g., .c, .cc, .h)
 	FileTypes []string
 
+	// ChangedSince limits linting to files modified at or after this time
+	// (zero = all files)
+	ChangedSince time.Time
+
+	// AbsolutePaths reports absolute file paths instead of paths relative
+	// to the root
+	AbsolutePaths bool
+
+	// PathsRelativeTo is the base of relative file paths: PathsRelativeToRoot
+	// (the default) or PathsRelativeToCWD, which keeps paths clickable in
+	// terminals started outside the root
+	PathsRelativeTo string
+
+	// SlashPaths reports file paths with forward slashes on every platform,
+	// for output that is reproducible across machines
+	SlashPaths bool
+
+	// Canonical makes results identical across platforms: it implies
+	// SlashPaths and also turns backslashes into forward slashes where
+	// filepath.ToSlash leaves them alone, so paths written Windows-style
+	// report the same on Unix. Lines are always counted the same for LF,
+	// CRLF and CR files.
+	Canonical bool
+
 	// Checks are the types of checks to perform
 	Checks []string
 
+	// ForceChecks runs the checks named in Checks even when the rules
+	// configuration disables them; their severity and parameters still
+	// come from the configuration
+	ForceChecks bool
+
 	// Verbose enables verbose output
 	Verbose bool
 
+	// ProfileFiles is how many of the slowest files to list at the end of a
+	// verbose run (0 = none)
+	ProfileFiles int
+
 	// MaxErrors stops after this many errors (0 = no limit)
 	MaxErrors int
+
+	// MaxErrorsMode is what reaching MaxErrors does: MaxErrorsStop (the
+	// default) ends the run early, MaxErrorsMark checks every file and
+	// only records that the limit was reached
+	MaxErrorsMode string
+
+	// RequiredRules lists rules that must be enabled in the resolved rules
+	// configuration; Run fails if any of them is disabled
+	RequiredRules []string
+
+	// ErrorRules lists rule IDs whose results are promoted to error
+	// severity
+	ErrorRules []string
+
+	// CollapseRanges merges results of the same rule on consecutive lines
+	// of a file into a single result spanning the range
+	CollapseRanges bool
+
+	// Language forces C (LanguageC) or C++ (LanguageCPP) rule behavior
+	// for every file regardless of its extension ("" = by extension)
+	Language string
+
+	// NoSort skips ordering results across files; each file's results stay
+	// sorted and are returned in the order files finish
+	NoSort bool
+
+	// MaxResultsPerFile caps the results reported for a single file
+	// (0 = no limit)
+	MaxResultsPerFile int
+
+	// FileTimeout is how long the rules may spend on a single file before
+	// its checks are abandoned with an info result (0 = no limit)
+	FileTimeout time.Duration
+
+	// GeneratedMarkers are regular expressions identifying generated files,
+	// which are skipped entirely
+	GeneratedMarkers []string
+
+	// GeneratedMarkerLines is how many leading lines are searched for a
+	// generated-file marker
+	GeneratedMarkerLines int
+
+	// Offline rules out all network access, i.e. posting results to a
+	// webhook
+	Offline bool
+
+	// RulesFile is a local rules configuration file; when empty,
+	// .codelint.json in RootDir is used if present
+	RulesFile string
+
+	// OwnersFile maps paths to owning teams (glob and owner per line);
+	// when set, each result's Owner is filled in
+	OwnersFile string
+
+	// FailOn is the lowest severity that fails a run: "error", "warning"
+	// or "info" (empty = "error")
+	FailOn string
+
+	// ErrorExitCode is the exit code used when errors are found
+	// (0 = ExitLintErrors)
+	ErrorExitCode int
 }
 
 // DefaultConfig returns a default configuration
@@ -38,32 +146,85 @@ func DefaultConfig() Config {
 			"header-guards",
 			"license-headers",
 		},
-		Verbose:   false,
-		MaxErrors: 0,
+		Verbose:      false,
+		ProfileFiles: 10,
+		MaxErrors:    0,
+		GeneratedMarkers: []string{
+			`// Code generated .* DO NOT EDIT\.`,
+			`@generated`,
+		},
+		GeneratedMarkerLines: 5,
+	}
+}
+
+// PreCommitConfig tunes a configuration for pre-commit hooks: only the
+// fast line-based checks, no network access, relative paths and failing on
+// errors only
+func PreCommitConfig(config Config) Config {
+	config.Checks = []string{
+		"formatting",
+		"naming-conventions",
+		"header-guards",
+		"license-headers",
 	}
+	config.Offline = true
+	config.AbsolutePaths = false
+	config.FailOn = SeverityError
+	config.Verbose = false
+	return config
 }
 
 // Result represents a single linting issue
 type Result struct {
 	// File is the path to the file containing the issue
-	File string
+	File string `json:"file"`
 
 	// Line number where the issue occurs (1-based)
-	Line int
+	Line int `json:"line"`
 
 	// Column number where the issue occurs (1-based)
-	Column int
+	Column int `json:"column"`
 
 	// Severity of the issue: "error", "warning", "info"
-	Severity string
+	Severity string `json:"severity"`
 
 	// Rule that was violated
-	Rule string
+	Rule string `json:"rule"`
 
 	// Message describing the issue
-	Message string
+	Message string `json:"message"`
+
+	// EndLine is the last line of a result covering a range of lines
+	// (0 = just Line)
+	EndLine int `json:"end_line,omitempty"`
+
+	// Owner is the team owning the file, from Config.OwnersFile
+	Owner string `json:"owner,omitempty"`
+
+	// Fix is an optional automatic fix for the issue
+	Fix *Fix `json:"fix,omitempty"`
 }
 
+// Exit codes returned by ExitCode
+const (
+	ExitSuccess       = 0
+	ExitLintErrors    = 1
+	ExitInternalError = 2
+	ExitMaxErrors     = 3
+)
+
+// PathsRelativeTo values
+const (
+	PathsRelativeToRoot = "root"
+	PathsRelativeToCWD  = "cwd"
+)
+
+// MaxErrorsMode values
+const (
+	MaxErrorsStop = "stop"
+	MaxErrorsMark = "mark"
+)
+
 // Severity constants
 const (
 	SeverityError   = "error"
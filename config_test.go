@@ -0,0 +1,69 @@
+package codelint
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDefaultConfigChecks(t *testing.T) {
+	// The heuristic categories are opt-in
+	want := map[string]bool{
+		"formatting":         true,
+		"naming-conventions": true,
+		"header-guards":      true,
+		"license-headers":    true,
+	}
+	checks := DefaultConfig().Checks
+	if len(checks) != len(want) {
+		t.Errorf("DefaultConfig().Checks = %v, want %d checks", checks, len(want))
+	}
+	for _, check := range checks {
+		if !want[check] {
+			t.Errorf("DefaultConfig().Checks includes %s", check)
+		}
+	}
+}
+
+func TestPreCommitConfig(t *testing.T) {
+	config := DefaultConfig()
+	config.Checks = append(config.Checks, "bugprone", "readability")
+	config.AbsolutePaths = true
+	config.FailOn = SeverityInfo
+	config.Verbose = true
+	config.RootDir = "src"
+	config.Files = []string{"a.c"}
+
+	got := PreCommitConfig(config)
+	if !reflect.DeepEqual(got.Checks, DefaultConfig().Checks) {
+		t.Errorf("Checks = %v, want the fast checks %v", got.Checks, DefaultConfig().Checks)
+	}
+	if !got.Offline || got.AbsolutePaths || got.Verbose || got.FailOn != SeverityError {
+		t.Errorf("Offline %v, AbsolutePaths %v, Verbose %v, FailOn %q; want true, false, false, error",
+			got.Offline, got.AbsolutePaths, got.Verbose, got.FailOn)
+	}
+	// Everything else is kept
+	if got.RootDir != "src" || !reflect.DeepEqual(got.Files, []string{"a.c"}) {
+		t.Errorf("RootDir %q, Files %v; want the original settings", got.RootDir, got.Files)
+	}
+	// The input is not modified
+	if len(config.Checks) != 6 || config.Offline {
+		t.Errorf("PreCommitConfig modified its argument: %+v", config)
+	}
+}
+
+func TestRulesConfigNeverFetched(t *testing.T) {
+	// Without a local file the built-in defaults are used, online or not
+	for _, offline := range []bool{false, true} {
+		config := DefaultConfig()
+		config.RootDir = t.TempDir()
+		config.Offline = offline
+		if source := NewRules(config).rulesConfig.Source; source != "default" {
+			t.Errorf("offline %v: rules config source %q, want default", offline, source)
+		}
+	}
+
+	rulesConfig, err := LoadRulesConfig()
+	if err != nil || rulesConfig.Source != "default" {
+		t.Errorf("LoadRulesConfig() = %q, %v; want the defaults", rulesConfig.Source, err)
+	}
+}
@@ -0,0 +1,223 @@
+package codelint
+
+import (
+	"bufio"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// editorConfigName is the file name of EditorConfig files
+const editorConfigName = ".editorconfig"
+
+// editorConfig is a parsed .editorconfig file
+type editorConfig struct {
+	// root stops the search for .editorconfig files in parent directories
+	root     bool
+	sections []editorSection
+}
+
+// editorSection is a [glob] section of an .editorconfig file
+type editorSection struct {
+	pattern *regexp.Regexp
+	props   map[string]string
+}
+
+// parseEditorConfig parses the content of an .editorconfig file. Sections
+// whose glob can't be translated are dropped; property names and values
+// are lower-cased as the format is case-insensitive.
+func parseEditorConfig(content string) *editorConfig {
+	config := &editorConfig{}
+	var props map[string]string
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			props = nil
+			if re, err := regexp.Compile(editorGlobExpr(line[1 : len(line)-1])); err == nil {
+				props = make(map[string]string)
+				config.sections = append(config.sections, editorSection{pattern: re, props: props})
+			}
+			continue
+		}
+
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:eq]))
+		value := strings.ToLower(strings.TrimSpace(line[eq+1:]))
+		if props != nil {
+			props[key] = value
+		} else if len(config.sections) == 0 && key == "root" {
+			config.root = value == "true"
+		}
+	}
+	return config
+}
+
+// editorGlobExpr translates an EditorConfig section glob into a regular
+// expression matched against slash-separated paths relative to the
+// .editorconfig file. A glob without a slash matches a file name in any
+// directory; numeric ranges ({1..3}) accept any integer.
+func editorGlobExpr(glob string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(glob, "/") {
+		b.WriteString("(?:.*/)?")
+	}
+	glob = strings.TrimPrefix(glob, "/")
+
+	braces := 0
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end
+		case '{':
+			end := strings.IndexByte(glob[i:], '}')
+			if end > 0 && isNumericRange(glob[i+1:i+end]) {
+				b.WriteString(`[+-]?[0-9]+`)
+				i += end
+				continue
+			}
+			braces++
+			b.WriteString("(?:")
+		case '}':
+			if braces == 0 {
+				b.WriteString(`\}`)
+				continue
+			}
+			braces--
+			b.WriteString(")")
+		case ',':
+			if braces == 0 {
+				b.WriteString(",")
+				continue
+			}
+			b.WriteString("|")
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// isNumericRange reports whether s has the form num1..num2
+func isNumericRange(s string) bool {
+	dots := strings.Index(s, "..")
+	if dots < 0 {
+		return false
+	}
+	_, errFrom := strconv.Atoi(s[:dots])
+	_, errTo := strconv.Atoi(s[dots+2:])
+	return errFrom == nil && errTo == nil
+}
+
+// properties adds the properties of the sections matching relPath, a
+// slash-separated path relative to the .editorconfig file, to props. Later
+// sections take precedence, and "unset" removes a property.
+func (e *editorConfig) properties(relPath string, props map[string]string) {
+	for _, section := range e.sections {
+		if !section.pattern.MatchString(relPath) {
+			continue
+		}
+		for key, value := range section.props {
+			if value == "unset" {
+				delete(props, key)
+			} else {
+				props[key] = value
+			}
+		}
+	}
+}
+
+// editorConfigOverride translates EditorConfig properties into a rules
+// configuration override in the JSON form read by mergeRulesConfig, or
+// nil when none of the properties concern the rules:
+//
+//   - indent_style: tab turns off the formatting rule's tab check
+//   - indent_size (or tab_width when indent_size is "tab" or missing) sets
+//     indent-width's indent_width
+//   - tab_width sets max-indent's tab_width
+//   - trim_trailing_whitespace enables or disables trailing-whitespace
+func editorConfigOverride(props map[string]string) map[string]interface{} {
+	rules := make(map[string]interface{})
+
+	switch props["indent_style"] {
+	case "tab":
+		rules["formatting"] = map[string]interface{}{
+			"parameters": map[string]interface{}{"check_tabs": false},
+		}
+	case "space":
+		rules["formatting"] = map[string]interface{}{
+			"parameters": map[string]interface{}{"check_tabs": true},
+		}
+	}
+
+	size := props["indent_size"]
+	if size == "" || size == "tab" {
+		size = props["tab_width"]
+	}
+	if width, err := strconv.Atoi(size); err == nil && width > 0 {
+		rules["indent-width"] = map[string]interface{}{
+			"parameters": map[string]interface{}{"indent_width": width},
+		}
+	}
+
+	if width, err := strconv.Atoi(props["tab_width"]); err == nil && width > 0 {
+		rules["max-indent"] = map[string]interface{}{
+			"parameters": map[string]interface{}{"tab_width": width},
+		}
+	}
+
+	switch props["trim_trailing_whitespace"] {
+	case "true":
+		rules["trailing-whitespace"] = map[string]interface{}{"enabled": true}
+	case "false":
+		rules["trailing-whitespace"] = map[string]interface{}{"enabled": false}
+	}
+
+	if len(rules) == 0 {
+		return nil
+	}
+	return map[string]interface{}{"rules": rules}
+}
+
+// editorConfigRel returns path relative to dir with forward slashes
+func editorConfigRel(dir, path string) string {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,169 @@
+package codelint
+
+import (
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestEditorGlobExpr(t *testing.T) {
+	tests := []struct {
+		glob  string
+		path  string
+		match bool
+	}{
+		{"*", "a.c", true},
+		{"*", "src/a.c", true},
+		{"*.c", "src/deep/a.c", true},
+		{"*.c", "a.h", false},
+		{"src/*.c", "src/a.c", true},
+		{"src/*.c", "src/deep/a.c", false},
+		{"src/**.c", "src/deep/a.c", true},
+		{"/src/*.c", "src/a.c", true},
+		{"*.{c,h}", "a.h", true},
+		{"*.{c,h}", "a.cc", false},
+		{"file?.c", "file1.c", true},
+		{"file?.c", "file10.c", false},
+		{"file[0-9].c", "file7.c", true},
+		{"file[!0-9].c", "file7.c", false},
+		{"v{1..3}.c", "v12.c", true},
+		{`a\*.c`, "a*.c", true},
+		{`a\*.c`, "ab.c", false},
+	}
+
+	for _, tc := range tests {
+		re, err := regexp.Compile(editorGlobExpr(tc.glob))
+		if err != nil {
+			t.Errorf("editorGlobExpr(%q): %v", tc.glob, err)
+			continue
+		}
+		if got := re.MatchString(tc.path); got != tc.match {
+			t.Errorf("glob %q matches %q: %v, want %v", tc.glob, tc.path, got, tc.match)
+		}
+	}
+}
+
+func TestEditorConfigProperties(t *testing.T) {
+	editor := parseEditorConfig(`
+# comment
+root = true
+
+[*]
+Indent_Style = Space
+indent_size = 4
+
+[*.h]
+indent_size = 2
+
+[lib/**]
+indent_style = tab
+indent_size = unset
+`)
+	if !editor.root {
+		t.Error("root = true not recorded")
+	}
+
+	tests := []struct {
+		path string
+		want map[string]string
+	}{
+		{"a.c", map[string]string{"indent_style": "space", "indent_size": "4"}},
+		{"a.h", map[string]string{"indent_style": "space", "indent_size": "2"}},
+		{"lib/b.h", map[string]string{"indent_style": "tab"}},
+	}
+	for _, tc := range tests {
+		props := make(map[string]string)
+		editor.properties(tc.path, props)
+		if !reflect.DeepEqual(props, tc.want) {
+			t.Errorf("properties of %s = %v, want %v", tc.path, props, tc.want)
+		}
+	}
+}
+
+func TestEditorConfigOverride(t *testing.T) {
+	params := func(values map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"parameters": values}
+	}
+	tests := []struct {
+		name  string
+		props map[string]string
+		want  map[string]interface{}
+	}{
+		{name: "nothing relevant", props: map[string]string{"charset": "utf-8"}},
+		{
+			name:  "tabs",
+			props: map[string]string{"indent_style": "tab", "tab_width": "8"},
+			want: map[string]interface{}{
+				"formatting":   params(map[string]interface{}{"check_tabs": false}),
+				"indent-width": params(map[string]interface{}{"indent_width": 8}),
+				"max-indent":   params(map[string]interface{}{"tab_width": 8}),
+			},
+		},
+		{
+			name:  "spaces",
+			props: map[string]string{"indent_style": "space", "indent_size": "2", "tab_width": "4"},
+			want: map[string]interface{}{
+				"formatting":   params(map[string]interface{}{"check_tabs": true}),
+				"indent-width": params(map[string]interface{}{"indent_width": 2}),
+				"max-indent":   params(map[string]interface{}{"tab_width": 4}),
+			},
+		},
+		{
+			name:  "indent size tab",
+			props: map[string]string{"indent_size": "tab", "tab_width": "3"},
+			want: map[string]interface{}{
+				"indent-width": params(map[string]interface{}{"indent_width": 3}),
+				"max-indent":   params(map[string]interface{}{"tab_width": 3}),
+			},
+		},
+		{name: "invalid size", props: map[string]string{"indent_size": "wide"}},
+		{
+			name:  "keep trailing whitespace",
+			props: map[string]string{"trim_trailing_whitespace": "false"},
+			want:  map[string]interface{}{"trailing-whitespace": map[string]interface{}{"enabled": false}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := editorConfigOverride(tc.props)
+			var want map[string]interface{}
+			if tc.want != nil {
+				want = map[string]interface{}{"rules": tc.want}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("override %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestEditorConfigRun(t *testing.T) {
+	const content = "/* Copyright */\nint f(void) {\n\treturn 0; \n}\n"
+	root := writeTree(t, map[string]string{
+		".editorconfig":     "root = true\n\n[*]\ntrim_trailing_whitespace = false\n\n[src/**.c]\nindent_style = tab\n",
+		"a.c":               content,
+		"src/b.c":           content,
+		"src/.editorconfig": "[vendor.c]\ntrim_trailing_whitespace = true\n",
+		"src/vendor.c":      content,
+	})
+	results, err := New(testConfig(root)).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string][]string)
+	for _, result := range results {
+		if result.Rule == "formatting" || result.Rule == "trailing-whitespace" {
+			got[filepath.ToSlash(result.File)] = append(got[filepath.ToSlash(result.File)], result.Rule)
+		}
+	}
+	want := map[string][]string{
+		"a.c":          {"formatting"},
+		"src/vendor.c": {"trailing-whitespace"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rules reported per file %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,91 @@
+package codelint
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Fix is a suggested edit resolving a result: lines StartLine through
+// EndLine (1-based, inclusive) are replaced with Replacement
+type Fix struct {
+	StartLine   int      `json:"start_line"`
+	EndLine     int      `json:"end_line"`
+	Replacement []string `json:"replacement"`
+}
+
+// lineFix builds a fix replacing a single 0-based line
+func lineFix(line int, replacement string) *Fix {
+	return &Fix{StartLine: line + 1, EndLine: line + 1, Replacement: []string{replacement}}
+}
+
+// applyFixes applies fixes to lines. Fixes overlapping an already-applied
+// fix are skipped (a later run picks them up); it returns the new lines and
+// the number of fixes applied.
+func applyFixes(lines []string, fixes []Fix) ([]string, int) {
+	sorted := append([]Fix(nil), fixes...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].StartLine < sorted[j].StartLine
+	})
+
+	var out []string
+	next := 1 // next original line (1-based) to copy
+	applied := 0
+	for _, fix := range sorted {
+		if fix.StartLine < next || fix.StartLine < 1 || fix.EndLine > len(lines) || fix.EndLine < fix.StartLine-1 {
+			continue
+		}
+		out = append(out, lines[next-1:fix.StartLine-1]...)
+		out = append(out, fix.Replacement...)
+		next = fix.EndLine + 1
+		applied++
+	}
+	out = append(out, lines[next-1:]...)
+	return out, applied
+}
+
+// ApplyFixes writes the fixes attached to results back to the files they
+// came from and returns the number of fixes applied
+func (l *Linter) ApplyFixes(results []Result) (int, error) {
+	byFile := make(map[string][]Fix)
+	var files []string
+	for _, r := range results {
+		if r.Fix == nil {
+			continue
+		}
+		if _, seen := byFile[r.File]; !seen {
+			files = append(files, r.File)
+		}
+		byFile[r.File] = append(byFile[r.File], *r.Fix)
+	}
+
+	total := 0
+	for _, file := range files {
+		path, ok := l.paths[file]
+		if !ok {
+			path = file
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return total, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		lines, ending := splitLines(string(content))
+		lines, applied := applyFixes(lines, byFile[file])
+		if applied == 0 {
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return total, err
+		}
+		if err := os.WriteFile(path, []byte(strings.Join(lines, lineTerminator(ending))), info.Mode().Perm()); err != nil {
+			return total, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		total += applied
+	}
+
+	return total, nil
+}
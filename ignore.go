@@ -0,0 +1,146 @@
+package codelint
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// ignoreFileName is the lint-specific ignore file looked up in each root
+const ignoreFileName = ".codelintignore"
+
+// ignorePattern is a single compiled line of an ignore file
+type ignorePattern struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreList holds patterns in gitignore syntax. Later patterns take
+// precedence, and a leading '!' re-includes a previously ignored path.
+type ignoreList struct {
+	patterns []ignorePattern
+}
+
+// loadIgnoreFile reads an ignore file. A missing file yields an empty list.
+func loadIgnoreFile(path string) (*ignoreList, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return &ignoreList{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return parseIgnoreLines(lines), nil
+}
+
+// parseIgnoreLines compiles gitignore-style lines. Blank lines and lines
+// starting with '#' are skipped.
+func parseIgnoreLines(lines []string) *ignoreList {
+	list := &ignoreList{}
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var p ignorePattern
+		if strings.HasPrefix(line, "!") {
+			p.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			p.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+
+		re, err := regexp.Compile(globPrefixExpr(line) + "$")
+		if err != nil {
+			// Skip malformed patterns such as invalid character ranges
+			continue
+		}
+		p.re = re
+		list.patterns = append(list.patterns, p)
+	}
+	return list
+}
+
+// Match reports whether a slash-separated path relative to the root is
+// ignored
+func (l *ignoreList) Match(relPath string, isDir bool) bool {
+	ignored := false
+	for _, p := range l.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		if p.re.MatchString(relPath) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+// globPrefixExpr translates a gitignore-style pattern into an unterminated
+// regular expression. Patterns containing a slash are anchored to the root;
+// others match at any directory level.
+func globPrefixExpr(pattern string) string {
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	if anchored {
+		return "^" + globToRegexp(pattern)
+	}
+	return "(?:^|/)" + globToRegexp(pattern)
+}
+
+// globToRegexp translates a gitignore glob into a regular expression
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			if end := strings.IndexByte(glob[i+1:], ']'); end >= 0 {
+				class := glob[i+1 : i+1+end]
+				if strings.HasPrefix(class, "!") {
+					class = "^" + class[1:]
+				}
+				b.WriteString("[" + class + "]")
+				i += end + 1
+			} else {
+				b.WriteString(`\[`)
+			}
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,64 @@
+package codelint
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIgnoreListMatch(t *testing.T) {
+	ignore := parseIgnoreLines([]string{
+		"# generated sources",
+		"gen/",
+		"*.pb.c",
+		"!keep.pb.c",
+		"/top.c",
+		"docs/**/*.h",
+		`\#literal.c`,
+		"",
+	})
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"gen", true, true},
+		{"src/gen", true, true},
+		{"gen", false, false},
+		{"a.pb.c", false, true},
+		{"src/b.pb.c", false, true},
+		{"src/keep.pb.c", false, false},
+		{"top.c", false, true},
+		{"src/top.c", false, false},
+		{"docs/a/b/c.h", false, true},
+		{"docs/c.h", false, true},
+		{"src/docs/c.h", false, false},
+		{"#literal.c", false, true},
+		{"# generated sources", false, false},
+		{"main.c", false, false},
+	}
+
+	for _, tc := range tests {
+		if got := ignore.Match(tc.path, tc.isDir); got != tc.want {
+			t.Errorf("Match(%q, %v) = %v, want %v", tc.path, tc.isDir, got, tc.want)
+		}
+	}
+}
+
+func TestWalkHonorsIgnoreFile(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		".codelintignore": "# lint-only exclusions\ntests/\n*.h\n!public.h\n",
+		"main.c":          "int main;\n",
+		"private.h":       "int p;\n",
+		"public.h":        "int q;\n",
+		"tests/t.c":       "int t;\n",
+		"src/tests.c":     "int s;\n",
+	})
+	config := testConfig(root)
+	config.SlashPaths = true
+
+	want := []string{"main.c", "public.h", "src/tests.c"}
+	if got := walkPaths(t, config); !reflect.DeepEqual(got, want) {
+		t.Errorf("Walk() found %v, want %v", got, want)
+	}
+}
@@ -2,8 +2,13 @@ package codelint
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
+	"time"
 )
 
 // Linter is the main linting engine
@@ -11,32 +16,126 @@ type Linter struct {
 	config Config
 	walker *Walker
 	rules  *Rules
+
+	// paths maps reported (relative) paths back to the files on disk
+	paths map[string]string
+
+	// filesScanned counts the files checked by the last Run
+	filesScanned int
+
+	// fileTimings records how long each file took to check in the last Run
+	fileTimings []FileTiming
+
+	// resolvers find per-directory rules configuration overrides, one per
+	// root directory, and rulesByConfig holds the rule set built for each
+	// resolved configuration; both are nil when the rules were given with
+	// WithRules
+	resolvers     []*ConfigResolver
+	rulesByConfig map[*RulesConfig]*Rules
+
+	// onResults receives each file's results as soon as it is checked
+	onResults func([]Result)
+}
+
+// FileTiming is the time spent checking a single file
+type FileTiming struct {
+	File     string
+	Duration time.Duration
+}
+
+// Option customizes a Linter created by New
+type Option func(*Linter)
+
+// WithRules replaces the built-in rule set with the given rules, which all
+// run regardless of Config.Checks. The rules configuration is not loaded.
+func WithRules(rules ...Rule) Option {
+	return func(l *Linter) {
+		l.rules = newRuleSet(rules)
+	}
+}
+
+// WithResultHandler registers a function called with each file's results
+// as soon as the file has been checked, and once more with the results of
+// project-wide checks. Run still returns every result.
+func WithResultHandler(handler func(results []Result)) Option {
+	return func(l *Linter) {
+		l.onResults = handler
+	}
 }
 
 // New creates a new linter with the given configuration
-func New(config Config) *Linter {
-	return &Linter{
+func New(config Config, opts ...Option) *Linter {
+	l := &Linter{
 		config: config,
 		walker: NewWalker(config),
-		rules:  NewRules(config),
+		paths:  make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(l)
 	}
+	if l.rules == nil {
+		l.rules = NewRules(config)
+		l.resolvers = []*ConfigResolver{NewConfigResolver(config.RootDir, l.rules.rulesConfig)}
+		l.rulesByConfig = map[*RulesConfig]*Rules{l.rules.rulesConfig: l.rules}
+
+		// Further roots start from their own .codelint.json (or RulesFile)
+		for _, root := range config.RootDirs {
+			if sameDir(root, config.RootDir) {
+				continue
+			}
+			rootConfig := config
+			rootConfig.RootDir = root
+			l.resolvers = append(l.resolvers, NewConfigResolver(root, loadRulesConfigFor(rootConfig)))
+		}
+	}
+	return l
+}
+
+// sameDir reports whether two directory paths name the same directory
+func sameDir(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
 }
 
 // Run executes the linter and returns all found issues
 func (l *Linter) Run() ([]Result, error) {
 	// Print initial message
 	if l.config.Verbose {
-		fmt.Printf("Starting code lint in %s\n", l.config.RootDir)
+		if len(l.config.RootDirs) > 0 {
+			fmt.Printf("Starting code lint in %s\n", strings.Join(l.config.RootDirs, ", "))
+		} else {
+			fmt.Printf("Starting code lint in %s\n", l.config.RootDir)
+		}
 		fmt.Printf("Include dirs: %v\n", l.config.IncludeDirs)
 		fmt.Printf("Exclude dirs: %v\n", l.config.ExcludeDirs)
 		fmt.Printf("File types: %v\n", l.config.FileTypes)
 		fmt.Printf("Checks: %v\n", l.config.Checks)
+		fmt.Printf("Rules config: %s\n", l.rules.rulesConfig.Source)
+	}
+
+	// Guard against config drift disabling rules the caller relies on
+	if err := l.rules.CheckRequired(l.config.RequiredRules); err != nil {
+		return nil, err
 	}
 
-	// Walk the file system to find files to lint
-	files, err := l.walker.Walk()
+	checks, err := l.prepareFileChecks()
 	if err != nil {
-		return nil, fmt.Errorf("failed to walk directory: %w", err)
+		return nil, err
+	}
+
+	// Lint the explicitly listed files, or walk the file system to find them
+	var files []FileInfo
+	if len(l.config.Files) > 0 {
+		files, err = l.walker.ReadFiles(l.config.Files)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		files, err = l.walker.Walk()
+		if err != nil {
+			return nil, fmt.Errorf("failed to walk directory: %w", err)
+		}
 	}
 
 	if l.config.Verbose {
@@ -45,24 +144,46 @@ func (l *Linter) Run() ([]Result, error) {
 
 	// Collect all results
 	var allResults []Result
+	var checkedFiles []FileInfo
 	errorCount := 0
+	l.filesScanned = 0
+	l.fileTimings = nil
 
 	for _, file := range files {
 		// Make file path relative for cleaner output
-		file.Path = l.walker.GetRelativePath(file.Path)
-		
-		// Check the file
-		results := l.rules.CheckFile(file)
-		
+		relPath := l.walker.ReportPath(file.Path)
+		l.paths[relPath] = file.Path
+		file.RelPath = l.walker.rootRelativePath(file.Path)
+		file.Path = relPath
+		if file.Language == "" {
+			file.Language = l.config.Language
+		}
+
+		// Skip generated files entirely
+		start := time.Now()
+		results, checked := l.checkFile(file, checks)
+		if !checked {
+			if l.config.Verbose {
+				fmt.Printf("  %s: skipped (generated file)\n", file.Path)
+			}
+			continue
+		}
+		l.filesScanned++
+		l.fileTimings = append(l.fileTimings, FileTiming{File: file.Path, Duration: time.Since(start)})
+		checkedFiles = append(checkedFiles, file)
+		results = l.finishResults(results)
+
 		// Add results
+		fileStart := len(allResults)
 		for _, result := range results {
 			allResults = append(allResults, result)
 			
 			if result.Severity == SeverityError {
 				errorCount++
 				
-				// Check if we've hit the max error limit
-				if l.config.MaxErrors > 0 && errorCount >= l.config.MaxErrors {
+				// Check if we've hit the max error limit; in mark mode the
+				// run continues and the limit is checked at the end
+				if l.config.MaxErrors > 0 && errorCount >= l.config.MaxErrors && l.config.MaxErrorsMode != MaxErrorsMark {
 					allResults = append(allResults, Result{
 						File:     "",
 						Line:     0,
@@ -71,34 +192,380 @@ func (l *Linter) Run() ([]Result, error) {
 						Rule:     "max-errors",
 						Message:  fmt.Sprintf("Maximum error count (%d) reached, stopping", l.config.MaxErrors),
 					})
+					l.emit(allResults[fileStart:])
 					return allResults, nil
 				}
 			}
 		}
+		l.emit(results)
 		
 		if l.config.Verbose && len(results) > 0 {
 			fmt.Printf("  %s: %d issues\n", file.Path, len(results))
 		}
 	}
 
-	// Sort results by file, then line, then column
-	sort.Slice(allResults, func(i, j int) bool {
-		if allResults[i].File != allResults[j].File {
-			return allResults[i].File < allResults[j].File
+	// Checks spanning several files run once everything has been seen
+	projectResults := l.rules.CheckProject(checkedFiles)
+	promoteSeverities(projectResults, l.config.ErrorRules)
+	if checks.owners != nil {
+		matchPaths := make(map[string]string, len(checkedFiles))
+		for _, file := range checkedFiles {
+			matchPaths[file.Path] = file.matchPath()
 		}
-		if allResults[i].Line != allResults[j].Line {
-			return allResults[i].Line < allResults[j].Line
+		for i := range projectResults {
+			path, ok := matchPaths[projectResults[i].File]
+			if !ok {
+				path = filepath.ToSlash(projectResults[i].File)
+			}
+			projectResults[i].Owner = checks.owners.Match(path)
 		}
-		return allResults[i].Column < allResults[j].Column
-	})
+	}
+	projectResults = l.finishResults(projectResults)
+	l.emit(projectResults)
+	allResults = append(allResults, projectResults...)
+
+	if l.config.MaxErrors > 0 && l.config.MaxErrorsMode == MaxErrorsMark {
+		total := 0
+		for _, result := range allResults {
+			if result.Severity == SeverityError {
+				total++
+			}
+		}
+		if total >= l.config.MaxErrors {
+			notice := Result{
+				Severity: SeverityInfo,
+				Rule:     "max-errors",
+				Message:  fmt.Sprintf("Maximum error count (%d) reached (%d errors in total)", l.config.MaxErrors, total),
+			}
+			l.emit([]Result{notice})
+			allResults = append(allResults, notice)
+		}
+	}
+
+	// Sort results by file, then line, then column
+	if !l.config.NoSort {
+		sortResults(allResults)
+	}
 
 	if l.config.Verbose {
 		fmt.Printf("\nLinting complete. Found %d issues\n", len(allResults))
+		if l.config.ProfileFiles > 0 {
+			fmt.Printf("Slowest files:\n")
+			for _, timing := range l.SlowestFiles(l.config.ProfileFiles) {
+				fmt.Printf("  %10s  %s\n", timing.Duration.Round(time.Microsecond), timing.File)
+			}
+		}
 	}
 
 	return allResults, nil
 }
 
+// fileChecks holds the run-wide state needed to check a single file
+type fileChecks struct {
+	generatedMarkers []*regexp.Regexp
+	owners           *Owners
+}
+
+// prepareFileChecks compiles the generated-file markers and loads the
+// owners file
+func (l *Linter) prepareFileChecks() (*fileChecks, error) {
+	checks := &fileChecks{}
+
+	for _, marker := range l.config.GeneratedMarkers {
+		re, err := regexp.Compile(marker)
+		if err != nil {
+			return nil, fmt.Errorf("invalid generated-file marker %q: %w", marker, err)
+		}
+		checks.generatedMarkers = append(checks.generatedMarkers, re)
+	}
+
+	if l.config.OwnersFile != "" {
+		owners, err := LoadOwnersFile(l.config.OwnersFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load owners file: %w", err)
+		}
+		checks.owners = owners
+	}
+
+	return checks, nil
+}
+
+// checkFile runs the rules on a file whose Path is already relative, then
+// promotes severities, caps the results and annotates owners. It returns
+// false if the file was skipped as generated.
+func (l *Linter) checkFile(file FileInfo, checks *fileChecks) ([]Result, bool) {
+	if isGenerated(file, checks.generatedMarkers, l.config.GeneratedMarkerLines) {
+		return nil, false
+	}
+	if file.Language == "" {
+		file.Language = l.config.Language
+	}
+
+	results := l.runRules(l.rulesFor(file.Path), file)
+	promoteSeverities(results, l.config.ErrorRules)
+
+	// Cap the number of results reported for a single file
+	if limit := l.config.MaxResultsPerFile; limit > 0 && len(results) > limit {
+		sortResults(results)
+		suppressed := len(results) - limit
+		results = append(results[:limit], Result{
+			File:     file.Path,
+			Line:     results[limit-1].Line,
+			Column:   results[limit-1].Column,
+			Severity: SeverityInfo,
+			Rule:     "max-results-per-file",
+			Message:  fmt.Sprintf("(+%d more suppressed)", suppressed),
+		})
+	}
+
+	if checks.owners != nil {
+		owner := checks.owners.Match(file.matchPath())
+		for i := range results {
+			results[i].Owner = owner
+		}
+	}
+
+	return results, true
+}
+
+// runRules runs the rules on a file, giving up once FileTimeout has passed.
+// Rules can't be interrupted, so an abandoned check keeps running in the
+// background and its results are discarded.
+func (l *Linter) runRules(rules *Rules, file FileInfo) []Result {
+	if l.config.FileTimeout <= 0 {
+		return rules.CheckFile(file)
+	}
+
+	done := make(chan []Result, 1)
+	go func() {
+		done <- rules.CheckFile(file)
+	}()
+
+	timer := time.NewTimer(l.config.FileTimeout)
+	defer timer.Stop()
+	select {
+	case results := <-done:
+		return results
+	case <-timer.C:
+		return []Result{{
+			File:     file.Path,
+			Line:     1,
+			Column:   1,
+			Severity: SeverityInfo,
+			Rule:     "file-timeout",
+			Message:  fmt.Sprintf("Checks abandoned after %s", l.config.FileTimeout),
+		}}
+	}
+}
+
+// rulesFor returns the rule set for a file given its reported path, using
+// the nearest directory override of the rules configuration
+func (l *Linter) rulesFor(path string) *Rules {
+	if l.resolvers == nil {
+		return l.rules
+	}
+	diskPath, ok := l.paths[path]
+	if !ok {
+		diskPath = path
+		if !filepath.IsAbs(diskPath) {
+			diskPath = filepath.Join(l.config.RootDir, diskPath)
+		}
+	}
+
+	rulesConfig := l.resolverFor(diskPath).ResolveConfigForPath(diskPath)
+	rules, ok := l.rulesByConfig[rulesConfig]
+	if !ok {
+		rules = newRulesWithConfig(l.config, rulesConfig)
+		l.rulesByConfig[rulesConfig] = rules
+	}
+	return rules
+}
+
+// resolverFor returns the resolver of the most specific root directory
+// containing the file at path, or the first root's if none does
+func (l *Linter) resolverFor(path string) *ConfigResolver {
+	best := l.resolvers[0]
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return best
+	}
+	found := false
+	for _, resolver := range l.resolvers {
+		if resolver.contains(abs) && (!found || len(resolver.rootDir) > len(best.rootDir)) {
+			best, found = resolver, true
+		}
+	}
+	return best
+}
+
+// SlowestFiles returns the n files of the last Run that took longest to
+// check, slowest first (n <= 0 returns all of them)
+func (l *Linter) SlowestFiles(n int) []FileTiming {
+	timings := append([]FileTiming(nil), l.fileTimings...)
+	sort.SliceStable(timings, func(i, j int) bool {
+		return timings[i].Duration > timings[j].Duration
+	})
+	if n > 0 && len(timings) > n {
+		timings = timings[:n]
+	}
+	return timings
+}
+
+// finishResults sorts one batch of results and collapses ranges when
+// configured, so the batch can be reported before the run completes
+func (l *Linter) finishResults(results []Result) []Result {
+	sortResults(results)
+	if l.config.CollapseRanges {
+		results = CollapseRanges(results)
+	}
+	return results
+}
+
+// emit passes finished results to the result handler, if any
+func (l *Linter) emit(results []Result) {
+	if l.onResults != nil && len(results) > 0 {
+		l.onResults(results)
+	}
+}
+
+// RunWithSummary executes the linter and returns the results together with
+// their Summary
+func (l *Linter) RunWithSummary() ([]Result, Summary, error) {
+	results, err := l.Run()
+	if err != nil {
+		return nil, Summary{}, err
+	}
+	return results, Summarize(results, l.filesScanned), nil
+}
+
+// LintBytes checks a single file's content as if it were stored at path,
+// without touching the disk. Project rules, which need every file of a
+// run, are not applied.
+func (l *Linter) LintBytes(path string, content []byte) ([]Result, error) {
+	checks, err := l.prepareFileChecks()
+	if err != nil {
+		return nil, err
+	}
+
+	relPath := l.walker.ReportPath(path)
+	l.paths[relPath] = path
+	file := NewFileInfo(relPath, content)
+	file.RelPath = l.walker.rootRelativePath(path)
+	results, _ := l.checkFile(file, checks)
+	sortResults(results)
+	return results, nil
+}
+
+// LintReader checks a single file's content read from r as if it were
+// stored at path
+func (l *Linter) LintReader(path string, r io.Reader) ([]Result, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return l.LintBytes(path, content)
+}
+
+// FilesScanned returns the number of files checked by the last Run
+func (l *Linter) FilesScanned() int {
+	return l.filesScanned
+}
+
+// promoteSeverities rewrites the severity of results from the given rules
+// to error
+func promoteSeverities(results []Result, rules []string) {
+	if len(rules) == 0 {
+		return
+	}
+	promote := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		promote[rule] = true
+	}
+	for i := range results {
+		if promote[results[i].Rule] {
+			results[i].Severity = SeverityError
+		}
+	}
+}
+
+// sortResults sorts results by file, then line, then column
+func sortResults(results []Result) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].File != results[j].File {
+			return results[i].File < results[j].File
+		}
+		if results[i].Line != results[j].Line {
+			return results[i].Line < results[j].Line
+		}
+		return results[i].Column < results[j].Column
+	})
+}
+
+// CollapseRanges merges results of the same rule and severity on
+// consecutive lines of a file into one result with EndLine set, keeping the
+// first message. Results must be sorted. Fixes are merged when every merged
+// result has one and they cover consecutive lines; otherwise the merged
+// result has no fix.
+func CollapseRanges(results []Result) []Result {
+	var collapsed []Result
+	for _, r := range results {
+		if n := len(collapsed); n > 0 {
+			last := &collapsed[n-1]
+			end := last.Line
+			if last.EndLine > end {
+				end = last.EndLine
+			}
+			if r.File != "" && r.File == last.File && r.Rule == last.Rule && r.Severity == last.Severity &&
+				r.Line <= end+1 {
+				if r.Line > end {
+					last.EndLine = r.Line
+				}
+				last.Fix = mergeFix(last.Fix, r.Fix)
+				continue
+			}
+		}
+		collapsed = append(collapsed, r)
+	}
+	return collapsed
+}
+
+// mergeFix combines the fix of a collapsed range with the fix of the next
+// result, or returns nil if they can't be combined
+func mergeFix(merged, next *Fix) *Fix {
+	if merged == nil || next == nil {
+		return nil
+	}
+	// Several results on one line often carry the same whole-line fix
+	if next.StartLine == next.EndLine && next.StartLine == merged.EndLine && len(merged.Replacement) > 0 &&
+		merged.EndLine-merged.StartLine+1 == len(merged.Replacement) &&
+		next.Replacement[0] == merged.Replacement[len(merged.Replacement)-1] {
+		return merged
+	}
+	if next.StartLine != merged.EndLine+1 {
+		return nil
+	}
+	return &Fix{
+		StartLine:   merged.StartLine,
+		EndLine:     next.EndLine,
+		Replacement: append(append([]string(nil), merged.Replacement...), next.Replacement...),
+	}
+}
+
+// isGenerated checks the first lines of a file for a generated-file marker
+func isGenerated(file FileInfo, markers []*regexp.Regexp, lines int) bool {
+	if lines > len(file.Lines) {
+		lines = len(file.Lines)
+	}
+	for i := 0; i < lines; i++ {
+		for _, marker := range markers {
+			if marker.MatchString(file.Lines[i]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // FormatResult formats a result for display
 func FormatResult(result Result) string {
 	var prefix string
@@ -118,53 +585,86 @@ func FormatResult(result Result) string {
 		return fmt.Sprintf("%s: %s", prefix, result.Message)
 	}
 
-	return fmt.Sprintf("%s: %s:%d:%d: %s [%s]",
+	message := result.Message
+	if result.EndLine > result.Line {
+		message += fmt.Sprintf(" (through line %d)", result.EndLine)
+	}
+	formatted := fmt.Sprintf("%s: %s:%d:%d: %s [%s]",
 		prefix,
 		result.File,
 		result.Line,
 		result.Column,
-		result.Message,
+		message,
 		result.Rule,
 	)
+	if result.Owner != "" {
+		formatted += " (owner: " + result.Owner + ")"
+	}
+	return formatted
 }
 
 // PrintResults prints results in a formatted way
 func PrintResults(results []Result) {
-	if len(results) == 0 {
-		fmt.Println("No issues found!")
-		return
-	}
+	TextReporter{}.Report(os.Stdout, results, Summarize(results, 0))
+}
 
-	// Group by severity
-	var errors, warnings, infos []Result
+// HasErrors returns true if any results have error severity
+func HasErrors(results []Result) bool {
 	for _, r := range results {
-		switch r.Severity {
-		case SeverityError:
-			errors = append(errors, r)
-		case SeverityWarning:
-			warnings = append(warnings, r)
-		case SeverityInfo:
-			infos = append(infos, r)
+		if r.Severity == SeverityError {
+			return true
 		}
 	}
+	return false
+}
 
-	// Print all results
-	for _, r := range results {
-		fmt.Println(FormatResult(r))
-	}
-
-	// Print summary
-	fmt.Println(strings.Repeat("-", 60))
-	fmt.Printf("Summary: %d errors, %d warnings, %d info\n",
-		len(errors), len(warnings), len(infos))
+// severityRank orders severities from least to most severe
+var severityRank = map[string]int{
+	SeverityInfo:    1,
+	SeverityWarning: 2,
+	SeverityError:   3,
 }
 
-// HasErrors returns true if any results have error severity
-func HasErrors(results []Result) bool {
+// Failed reports whether any result is at or above the failOn severity
+// (empty = error)
+func Failed(results []Result, failOn string) bool {
+	threshold, ok := severityRank[failOn]
+	if !ok {
+		threshold = severityRank[SeverityError]
+	}
 	for _, r := range results {
-		if r.Severity == SeverityError {
+		// The max-errors notice is bookkeeping, not a finding
+		if r.Rule == "max-errors" && r.File == "" {
+			continue
+		}
+		if severityRank[r.Severity] >= threshold {
 			return true
 		}
 	}
 	return false
-}
\ No newline at end of file
+}
+
+// ExitCode maps the outcome of a run to a process exit code. Internal
+// failures always use ExitInternalError; reaching MaxErrors, in either
+// mode, uses ExitMaxErrors; results at or above FailOn use the configured
+// ErrorExitCode.
+func ExitCode(results []Result, runErr error, cfg Config) int {
+	if runErr != nil {
+		return ExitInternalError
+	}
+
+	for _, r := range results {
+		if r.Rule == "max-errors" && r.File == "" {
+			return ExitMaxErrors
+		}
+	}
+
+	if Failed(results, cfg.FailOn) {
+		if cfg.ErrorExitCode != 0 {
+			return cfg.ErrorExitCode
+		}
+		return ExitLintErrors
+	}
+
+	return ExitSuccess
+}
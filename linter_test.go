@@ -0,0 +1,1196 @@
+package codelint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTree creates files, keyed by slash-separated path, under a new
+// temporary directory and returns it
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// testConfig returns the default configuration scanning root
+func testConfig(root string) Config {
+	config := DefaultConfig()
+	config.RootDir = root
+	config.Offline = true
+	return config
+}
+
+// resultFiles returns the set of files with at least one result
+func resultFiles(results []Result) map[string]bool {
+	files := make(map[string]bool)
+	for _, result := range results {
+		if result.File != "" {
+			files[result.File] = true
+		}
+	}
+	return files
+}
+
+func TestGeneratedFilesSkipped(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		config  func(*Config)
+		skipped bool
+	}{
+		{
+			name:    "no marker",
+			content: "int x;\n",
+		},
+		{
+			name:    "code generated marker",
+			content: "// Code generated by protoc-gen-c. DO NOT EDIT.\nint x;\n",
+			skipped: true,
+		},
+		{
+			name:    "at-generated marker",
+			content: "/*\n * @generated\n */\nint x;\n",
+			skipped: true,
+		},
+		{
+			name:    "marker past the searched lines",
+			content: "int a;\nint b;\nint c;\nint d;\nint e;\n// @generated\n",
+		},
+		{
+			name:    "more lines searched",
+			content: "int a;\nint b;\nint c;\nint d;\nint e;\n// @generated\n",
+			config:  func(c *Config) { c.GeneratedMarkerLines = 10 },
+			skipped: true,
+		},
+		{
+			name:    "custom marker",
+			content: "/* autogen */\nint x;\n",
+			config:  func(c *Config) { c.GeneratedMarkers = []string{`autogen`} },
+			skipped: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := testConfig(writeTree(t, map[string]string{"a.c": tc.content}))
+			if tc.config != nil {
+				tc.config(&config)
+			}
+			linter := New(config)
+			results, err := linter.Run()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := resultFiles(results)["a.c"]; got == tc.skipped {
+				t.Errorf("a.c has results: %v, want skipped %v", got, tc.skipped)
+			}
+			want := 1
+			if tc.skipped {
+				want = 0
+			}
+			if got := linter.FilesScanned(); got != want {
+				t.Errorf("FilesScanned() = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestInvalidGeneratedMarker(t *testing.T) {
+	config := testConfig(writeTree(t, map[string]string{"a.c": "int x;\n"}))
+	config.GeneratedMarkers = []string{"("}
+	if _, err := New(config).Run(); err == nil {
+		t.Error("Run() with an invalid marker succeeded, want an error")
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	errorResult := Result{File: "a.c", Line: 1, Column: 1, Severity: SeverityError, Rule: "line-length"}
+	warningResult := Result{File: "a.c", Line: 2, Column: 1, Severity: SeverityWarning, Rule: "line-length"}
+	infoResult := Result{File: "a.c", Line: 3, Column: 1, Severity: SeverityInfo, Rule: "todo-format"}
+	maxErrors := Result{Severity: SeverityInfo, Rule: "max-errors", Message: "Maximum error count (1) reached, stopping"}
+
+	tests := []struct {
+		name    string
+		results []Result
+		runErr  error
+		config  Config
+		want    int
+	}{
+		{
+			name: "no results",
+			want: ExitSuccess,
+		},
+		{
+			name:    "warnings only",
+			results: []Result{warningResult, infoResult},
+			want:    ExitSuccess,
+		},
+		{
+			name:    "errors",
+			results: []Result{errorResult, warningResult},
+			want:    ExitLintErrors,
+		},
+		{
+			name:    "configured error code",
+			results: []Result{errorResult},
+			config:  Config{ErrorExitCode: 10},
+			want:    10,
+		},
+		{
+			name:    "fail on warning",
+			results: []Result{warningResult},
+			config:  Config{FailOn: SeverityWarning},
+			want:    ExitLintErrors,
+		},
+		{
+			name:    "fail on info",
+			results: []Result{infoResult},
+			config:  Config{FailOn: SeverityInfo, ErrorExitCode: 10},
+			want:    10,
+		},
+		{
+			name:    "max errors reached",
+			results: []Result{errorResult, maxErrors},
+			config:  Config{ErrorExitCode: 10},
+			want:    ExitMaxErrors,
+		},
+		{
+			name:    "run error",
+			results: []Result{errorResult},
+			runErr:  os.ErrNotExist,
+			want:    ExitInternalError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ExitCode(tc.results, tc.runErr, tc.config); got != tc.want {
+				t.Errorf("ExitCode() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFailed(t *testing.T) {
+	tests := []struct {
+		name     string
+		severity string
+		failOn   string
+		want     bool
+	}{
+		{"error fails by default", SeverityError, "", true},
+		{"warning passes by default", SeverityWarning, "", false},
+		{"warning fails on warning", SeverityWarning, SeverityWarning, true},
+		{"info passes on warning", SeverityInfo, SeverityWarning, false},
+		{"info fails on info", SeverityInfo, SeverityInfo, true},
+		{"unknown threshold means error", SeverityWarning, "fatal", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			results := []Result{{File: "a.c", Line: 1, Column: 1, Severity: tc.severity, Rule: "line-length"}}
+			if got := Failed(results, tc.failOn); got != tc.want {
+				t.Errorf("Failed(%s, %q) = %v, want %v", tc.severity, tc.failOn, got, tc.want)
+			}
+		})
+	}
+
+	// The max-errors notice alone never fails a run
+	notice := []Result{{Severity: SeverityInfo, Rule: "max-errors"}}
+	if Failed(notice, SeverityInfo) {
+		t.Error("Failed() counted the max-errors notice")
+	}
+}
+
+func TestExitCodeMaxErrorsRun(t *testing.T) {
+	config := testConfig(writeTree(t, map[string]string{
+		"a.c": "int a;\n",
+		"b.c": "int b;\n",
+	}))
+	config.ErrorRules = []string{"license-headers"}
+	config.MaxErrors = 1
+
+	results, err := New(config).Run()
+	if got := ExitCode(results, err, config); got != ExitMaxErrors {
+		t.Errorf("ExitCode() = %d, want %d", got, ExitMaxErrors)
+	}
+}
+
+func TestMaxResultsPerFile(t *testing.T) {
+	content := []byte(strings.Repeat("int x; \n", 5))
+
+	tests := []struct {
+		name  string
+		limit int
+		want  []string
+	}{
+		{
+			name:  "no limit",
+			limit: 0,
+			want:  []string{"trailing-whitespace:1", "trailing-whitespace:2", "trailing-whitespace:3", "trailing-whitespace:4", "trailing-whitespace:5"},
+		},
+		{
+			name:  "under the limit",
+			limit: 5,
+			want:  []string{"trailing-whitespace:1", "trailing-whitespace:2", "trailing-whitespace:3", "trailing-whitespace:4", "trailing-whitespace:5"},
+		},
+		{
+			name:  "over the limit",
+			limit: 2,
+			want:  []string{"trailing-whitespace:1", "trailing-whitespace:2", "max-results-per-file:2"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := testConfig(t.TempDir())
+			config.MaxResultsPerFile = tc.limit
+			results, err := New(config, WithRules(&TrailingWhitespaceRule{rulesConfig: defaultRulesConfig()})).LintBytes("a.c", content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, result := range results {
+				got = append(got, fmt.Sprintf("%s:%d", result.Rule, result.Line))
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("results %v, want %v", got, tc.want)
+			}
+			if n := len(results); tc.limit > 0 && n > tc.limit {
+				if note := results[n-1]; note.Severity != SeverityInfo || note.Message != "(+3 more suppressed)" {
+					t.Errorf("suppression note %s %q, want info %q", note.Severity, note.Message, "(+3 more suppressed)")
+				}
+			}
+		})
+	}
+}
+
+func TestMaxErrorsStopsAtFirstFile(t *testing.T) {
+	config := testConfig(writeTree(t, map[string]string{
+		"b.c":     "int b;\n",
+		"a/z.c":   "int z;\n",
+		"a-b/x.c": "int x;\n",
+	}))
+	config.SlashPaths = true
+	config.ErrorRules = []string{"license-headers"}
+	config.MaxErrors = 1
+
+	results, err := New(config).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := resultFiles(results)
+	if len(files) != 1 || !files["a-b/x.c"] {
+		t.Errorf("results for %v, want only a-b/x.c", files)
+	}
+}
+
+func TestMultipleRoots(t *testing.T) {
+	parent := writeTree(t, map[string]string{
+		"a/x.c":            "int x; \n",
+		"b/x.c":            "int x; \n",
+		"b/.codelint.json": `{"rules": {"license-headers": {"enabled": false}}}`,
+		"b/lib/y.c":        "int y; \n",
+	})
+	rootA, rootB := filepath.Join(parent, "a"), filepath.Join(parent, "b")
+	config := testConfig(rootA)
+	config.RootDirs = []string{rootA, rootB}
+
+	results, err := New(config).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	licenses := make(map[string]bool)
+	for _, result := range results {
+		if result.Rule == "license-headers" {
+			licenses[result.File] = true
+		}
+	}
+	// Each root's own .codelint.json applies to its files
+	want := map[string]bool{filepath.Join(rootA, "x.c"): true}
+	if !reflect.DeepEqual(licenses, want) {
+		t.Errorf("license-headers results for %v, want %v", licenses, want)
+	}
+
+	// Both roots are walked; paths are relative to their root, qualified
+	// by it only where two roots have the same relative path
+	want = map[string]bool{
+		filepath.Join(rootA, "x.c"): true,
+		filepath.Join(rootB, "x.c"): true,
+		filepath.Join("lib", "y.c"): true,
+	}
+	if files := resultFiles(results); !reflect.DeepEqual(files, want) {
+		t.Errorf("results for %v, want %v", files, want)
+	}
+}
+
+func TestPromoteSeverities(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []string
+		want  []string
+	}{
+		{"no rules", nil, []string{SeverityWarning, SeverityInfo, SeverityError}},
+		{"one rule", []string{"header-guards"}, []string{SeverityError, SeverityInfo, SeverityError}},
+		{"several rules", []string{"header-guards", "todo-format"}, []string{SeverityError, SeverityError, SeverityError}},
+		{"unknown rule", []string{"no-such-rule"}, []string{SeverityWarning, SeverityInfo, SeverityError}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			results := []Result{
+				{Rule: "header-guards", Severity: SeverityWarning},
+				{Rule: "todo-format", Severity: SeverityInfo},
+				{Rule: "line-length", Severity: SeverityError},
+			}
+			promoteSeverities(results, tc.rules)
+			var got []string
+			for _, result := range results {
+				got = append(got, result.Severity)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("severities %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestErrorRulesFailRun(t *testing.T) {
+	root := writeTree(t, map[string]string{"a.c": "/* Copyright */\nint x; \n"})
+
+	for _, promote := range []bool{false, true} {
+		config := testConfig(root)
+		if promote {
+			config.ErrorRules = []string{"trailing-whitespace"}
+		}
+		results, err := New(config).Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := HasErrors(results); got != promote {
+			t.Errorf("with promotion %v: HasErrors() = %v", promote, got)
+		}
+		want := ExitSuccess
+		if promote {
+			want = ExitLintErrors
+		}
+		if got := ExitCode(results, nil, config); got != want {
+			t.Errorf("with promotion %v: ExitCode() = %d, want %d", promote, got, want)
+		}
+	}
+}
+
+func TestRequiredRules(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		".codelint.json": `{"rules": {"header-guards": {"enabled": false}, "license-headers": {"enabled": true}}}`,
+		"a.c":            "int x;\n",
+	})
+
+	tests := []struct {
+		name     string
+		required []string
+		wantErr  bool
+	}{
+		{"none required", nil, false},
+		{"enabled rule", []string{"license-headers"}, false},
+		{"disabled rule", []string{"license-headers", "header-guards"}, true},
+		// Rules missing from the configuration run with default settings
+		{"unlisted rule", []string{"trailing-whitespace"}, false},
+		{"misspelled rule", []string{"header-gaurds"}, true},
+		{"unknown rule", []string{"license-headers", "no-such-rule"}, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := testConfig(root)
+			config.RequiredRules = tc.required
+			results, err := New(config).Run()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Run() error = %v, want error %v", err, tc.wantErr)
+			}
+			want := ExitSuccess
+			if tc.wantErr {
+				want = ExitInternalError
+			} else if Failed(results, config.FailOn) {
+				want = ExitLintErrors
+			}
+			if got := ExitCode(results, err, config); got != want {
+				t.Errorf("ExitCode() = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+// stubRule reports one result at the first line of every file it checks
+// and records the files seen
+type stubRule struct {
+	name    string
+	checked []string
+}
+
+func (r *stubRule) Name() string {
+	return r.name
+}
+
+func (r *stubRule) Check(file FileInfo) []Result {
+	r.checked = append(r.checked, file.Path)
+	return []Result{{File: file.Path, Line: 1, Column: 1, Severity: SeverityWarning, Rule: r.name, Message: "stub"}}
+}
+
+// stubProjectRule also reports the number of files of the run
+type stubProjectRule struct {
+	stubRule
+}
+
+func (r *stubProjectRule) CheckProject(files []FileInfo) []Result {
+	return []Result{{Severity: SeverityInfo, Rule: r.name, Message: fmt.Sprintf("%d files", len(files))}}
+}
+
+func TestWithRules(t *testing.T) {
+	config := testConfig(writeTree(t, map[string]string{
+		"a.c":     "int a; \n",
+		"lib/b.h": "int b;\n",
+	}))
+	config.SlashPaths = true
+	// Checks don't apply to injected rules
+	config.Checks = []string{"header-guards"}
+
+	first := &stubRule{name: "first"}
+	project := &stubProjectRule{stubRule{name: "project"}}
+	results, err := New(config, WithRules(first, project)).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, result := range results {
+		got = append(got, fmt.Sprintf("%s %s %s", result.File, result.Rule, result.Message))
+	}
+	want := []string{" project 2 files", "a.c first stub", "a.c project stub", "lib/b.h first stub", "lib/b.h project stub"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("results %q, want %q", got, want)
+	}
+	if !reflect.DeepEqual(first.checked, []string{"a.c", "lib/b.h"}) {
+		t.Errorf("rule checked %v, want [a.c lib/b.h]", first.checked)
+	}
+}
+
+func TestRunWithSummary(t *testing.T) {
+	config := testConfig(writeTree(t, map[string]string{
+		"a.c":    "/* Copyright */\nint a; \n",
+		"b.c":    "/* Copyright */\nint b;\n",
+		"gen.c":  "// @generated\nint g; \n",
+		"c.h":    "int c;\n",
+		"skip.x": "int x; \n",
+	}))
+	config.SlashPaths = true
+
+	linter := New(config)
+	results, summary, err := linter.RunWithSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := Summarize(results, 3); !reflect.DeepEqual(summary, want) {
+		t.Errorf("summary %+v, want %+v", summary, want)
+	}
+	if summary.FilesScanned != 3 || linter.FilesScanned() != 3 {
+		t.Errorf("files scanned %d (linter %d), want 3", summary.FilesScanned, linter.FilesScanned())
+	}
+	if summary.FilesWithIssues != 2 {
+		t.Errorf("files with issues %d, want 2 in %v", summary.FilesWithIssues, resultFiles(results))
+	}
+	if summary.Rules["trailing-whitespace"] != 1 {
+		t.Errorf("trailing-whitespace count %d, want 1", summary.Rules["trailing-whitespace"])
+	}
+}
+
+// sleepRule takes one millisecond per line of a file
+type sleepRule struct{}
+
+func (sleepRule) Name() string {
+	return "sleep"
+}
+
+func (sleepRule) Check(file FileInfo) []Result {
+	time.Sleep(time.Duration(len(file.Lines)) * time.Millisecond)
+	return nil
+}
+
+func TestSlowestFiles(t *testing.T) {
+	config := testConfig(writeTree(t, map[string]string{
+		"fast.c":   "int a;",
+		"slow.c":   strings.Repeat("int a;\n", 20),
+		"medium.c": strings.Repeat("int a;\n", 8),
+	}))
+	config.SlashPaths = true
+
+	linter := New(config, WithRules(sleepRule{}))
+	if _, err := linter.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	var all []string
+	timings := linter.SlowestFiles(0)
+	for i, timing := range timings {
+		all = append(all, timing.File)
+		if i > 0 && timing.Duration > timings[i-1].Duration {
+			t.Errorf("timings not sorted slowest first: %v", timings)
+		}
+	}
+	if want := []string{"slow.c", "medium.c", "fast.c"}; !reflect.DeepEqual(all, want) {
+		t.Errorf("SlowestFiles(0) = %v, want %v", all, want)
+	}
+	if got := linter.SlowestFiles(2); len(got) != 2 || got[0].File != "slow.c" {
+		t.Errorf("SlowestFiles(2) = %v, want the two slowest", got)
+	}
+	if got := linter.SlowestFiles(10); len(got) != 3 {
+		t.Errorf("SlowestFiles(10) = %v, want all 3", got)
+	}
+}
+
+func TestExplicitFiles(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"a.c":       "int a; \n",
+		"b.c":       "int b; \n",
+		"lib/c.h":   "int c; \n",
+		"notes.txt": "todo \n",
+	})
+	config := testConfig(root)
+	config.SlashPaths = true
+	config.Files = []string{
+		filepath.Join(root, "a.c"),
+		filepath.Join(root, "lib", "c.h"),
+		filepath.Join(root, "notes.txt"),
+	}
+
+	linter := New(config)
+	results, err := linter.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"a.c": true, "lib/c.h": true}
+	if got := resultFiles(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("results for %v, want %v", got, want)
+	}
+	if got := linter.FilesScanned(); got != 2 {
+		t.Errorf("FilesScanned() = %d, want 2", got)
+	}
+
+	config.Files = []string{filepath.Join(root, "missing.c")}
+	if _, err := New(config).Run(); err == nil {
+		t.Error("Run() with a missing file succeeded, want an error")
+	}
+}
+
+func TestLintBytes(t *testing.T) {
+	root := t.TempDir()
+	config := testConfig(root)
+	config.SlashPaths = true
+	linter := New(config)
+
+	results, err := linter.LintBytes(filepath.Join(root, "src", "a.c"), []byte("int a; \n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ruleLines(results, "trailing-whitespace"); !reflect.DeepEqual(got, []string{"src/a.c:1:7"}) {
+		t.Errorf("LintBytes() trailing-whitespace %v, want [src/a.c:1:7]", got)
+	}
+
+	results, err = linter.LintReader(filepath.Join(root, "b.c"), strings.NewReader("int b;\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ruleLines(results, "trailing-whitespace"); got != nil {
+		t.Errorf("LintReader() trailing-whitespace %v, want none", got)
+	}
+}
+
+func TestCollapseRanges(t *testing.T) {
+	ws := func(file string, line int) Result {
+		return Result{File: file, Line: line, Column: 1, Severity: SeverityInfo, Rule: "trailing-whitespace"}
+	}
+	tests := []struct {
+		name    string
+		results []Result
+		want    []string
+	}{
+		{
+			name:    "consecutive lines merge",
+			results: []Result{ws("a.c", 3), ws("a.c", 4), ws("a.c", 5)},
+			want:    []string{"a.c:3-5"},
+		},
+		{
+			name:    "gap splits the range",
+			results: []Result{ws("a.c", 3), ws("a.c", 4), ws("a.c", 6)},
+			want:    []string{"a.c:3-4", "a.c:6"},
+		},
+		{
+			name:    "same line twice",
+			results: []Result{ws("a.c", 3), ws("a.c", 3), ws("a.c", 4)},
+			want:    []string{"a.c:3-4"},
+		},
+		{
+			name:    "different files",
+			results: []Result{ws("a.c", 3), ws("b.c", 4)},
+			want:    []string{"a.c:3", "b.c:4"},
+		},
+		{
+			name: "different rules",
+			results: []Result{
+				ws("a.c", 3),
+				{File: "a.c", Line: 4, Severity: SeverityInfo, Rule: "line-length"},
+			},
+			want: []string{"a.c:3", "a.c:4"},
+		},
+		{
+			name: "different severities",
+			results: []Result{
+				ws("a.c", 3),
+				{File: "a.c", Line: 4, Severity: SeverityError, Rule: "trailing-whitespace"},
+			},
+			want: []string{"a.c:3", "a.c:4"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, r := range CollapseRanges(tc.results) {
+				pos := fmt.Sprintf("%s:%d", r.File, r.Line)
+				if r.EndLine != 0 {
+					pos += fmt.Sprintf("-%d", r.EndLine)
+				}
+				got = append(got, pos)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("collapsed to %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCollapseRangesFixes(t *testing.T) {
+	fixed := func(line int, replacement string) Result {
+		return Result{File: "a.c", Line: line, Rule: "trailing-whitespace", Fix: lineFix(line-1, replacement)}
+	}
+
+	got := CollapseRanges([]Result{fixed(1, "a"), fixed(2, "b"), fixed(2, "b"), fixed(3, "c")})
+	want := &Fix{StartLine: 1, EndLine: 3, Replacement: []string{"a", "b", "c"}}
+	if len(got) != 1 || !reflect.DeepEqual(got[0].Fix, want) {
+		t.Errorf("collapsed to %+v, want one result with fix %+v", got, want)
+	}
+
+	unfixed := fixed(2, "b")
+	unfixed.Fix = nil
+	got = CollapseRanges([]Result{fixed(1, "a"), unfixed, fixed(3, "c")})
+	if len(got) != 1 || got[0].Fix != nil {
+		t.Errorf("collapsed to %+v, want one result without a fix", got)
+	}
+}
+
+func TestRunCollapseRanges(t *testing.T) {
+	root := writeTree(t, map[string]string{"a.c": "/* Copyright */\nint x; \nint y; \n\nint z; \n"})
+	for _, collapse := range []bool{false, true} {
+		config := testConfig(root)
+		config.CollapseRanges = collapse
+		results, err := New(config).Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := 0
+		for _, r := range results {
+			if r.Rule == "trailing-whitespace" {
+				got++
+			}
+		}
+		want := 3
+		if collapse {
+			want = 2
+		}
+		if got != want {
+			t.Errorf("CollapseRanges=%v: %d results, want %d: %v", collapse, got, want, results)
+		}
+	}
+}
+
+func TestNoSort(t *testing.T) {
+	files := map[string]string{
+		"a.c":     "int a;\n",
+		"lib/b.h": "int b;\n",
+	}
+	tests := []struct {
+		name   string
+		noSort bool
+		want   []string
+	}{
+		{
+			name: "sorted",
+			want: []string{" project 2 files", "a.c project stub", "lib/b.h project stub"},
+		},
+		{
+			name:   "in the order checked",
+			noSort: true,
+			want:   []string{"a.c project stub", "lib/b.h project stub", " project 2 files"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := testConfig(writeTree(t, files))
+			config.SlashPaths = true
+			config.NoSort = tc.noSort
+
+			var emitted []string
+			handler := WithResultHandler(func(results []Result) {
+				for _, result := range results {
+					emitted = append(emitted, fmt.Sprintf("%s %s %s", result.File, result.Rule, result.Message))
+				}
+			})
+			project := &stubProjectRule{stubRule{name: "project"}}
+			results, err := New(config, WithRules(project), handler).Run()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, result := range results {
+				got = append(got, fmt.Sprintf("%s %s %s", result.File, result.Rule, result.Message))
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("results %q, want %q", got, tc.want)
+			}
+
+			// The handler always sees results as files finish
+			want := []string{"a.c project stub", "lib/b.h project stub", " project 2 files"}
+			if !reflect.DeepEqual(emitted, want) {
+				t.Errorf("emitted %q, want %q", emitted, want)
+			}
+		})
+	}
+}
+
+func TestFileTimeout(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"fast.c": "int a;",
+		"slow.c": strings.Repeat("int a;\n", 200),
+	})
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		want    []string
+	}{
+		{
+			name: "no timeout",
+			want: []string{"fast.c stub", "slow.c stub"},
+		},
+		{
+			name:    "slow file abandoned",
+			timeout: 50 * time.Millisecond,
+			want:    []string{"fast.c stub", "slow.c file-timeout"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := testConfig(root)
+			config.FileTimeout = tc.timeout
+
+			results, err := New(config, WithRules(sleepRule{}, &stubRule{name: "stub"})).Run()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, result := range results {
+				got = append(got, result.File+" "+result.Rule)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("results %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMaxErrorsMode(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"a.c": "int a;\n",
+		"b.c": "int b;\n",
+		"c.c": "int c;\n",
+	})
+	tests := []struct {
+		name      string
+		mode      string
+		maxErrors int
+		files     int
+		notice    string
+		exitCode  int
+	}{
+		{
+			name:      "stop",
+			mode:      MaxErrorsStop,
+			maxErrors: 2,
+			files:     2,
+			notice:    "Maximum error count (2) reached, stopping",
+			exitCode:  ExitMaxErrors,
+		},
+		{
+			name:      "stop is the default",
+			maxErrors: 2,
+			files:     2,
+			notice:    "Maximum error count (2) reached, stopping",
+			exitCode:  ExitMaxErrors,
+		},
+		{
+			name:      "mark",
+			mode:      MaxErrorsMark,
+			maxErrors: 2,
+			files:     3,
+			notice:    "Maximum error count (2) reached (3 errors in total)",
+			exitCode:  ExitMaxErrors,
+		},
+		{
+			name:      "mark under the limit",
+			mode:      MaxErrorsMark,
+			maxErrors: 5,
+			files:     3,
+			exitCode:  ExitLintErrors,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := testConfig(root)
+			config.ErrorRules = []string{"license-headers"}
+			config.MaxErrors = tc.maxErrors
+			config.MaxErrorsMode = tc.mode
+
+			results, err := New(config).Run()
+			if err != nil {
+				t.Fatal(err)
+			}
+			files := resultFiles(results)
+			delete(files, "")
+			if len(files) != tc.files {
+				t.Errorf("results for %v, want %d files", files, tc.files)
+			}
+			var notice string
+			for _, result := range results {
+				if result.Rule == "max-errors" {
+					notice = result.Message
+				}
+			}
+			if notice != tc.notice {
+				t.Errorf("notice %q, want %q", notice, tc.notice)
+			}
+			if got := ExitCode(results, nil, config); got != tc.exitCode {
+				t.Errorf("ExitCode() = %d, want %d", got, tc.exitCode)
+			}
+		})
+	}
+}
+
+func TestForcedLanguage(t *testing.T) {
+	content := []byte("/* Copyright */\nvoid parseInput(void) {\n}\n")
+	tests := []struct {
+		name     string
+		path     string
+		language string
+		want     int
+	}{
+		{name: "C by extension", path: "a.c", want: 1},
+		{name: "C++ by extension", path: "a.cc"},
+		{name: "header by extension", path: "a.h"},
+		{name: "C file as C++", path: "a.c", language: LanguageCPP},
+		{name: "C++ file as C", path: "a.cc", language: LanguageC, want: 1},
+		{name: "header as C", path: "a.hpp", language: LanguageC, want: 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := testConfig(t.TempDir())
+			config.Language = tc.language
+			results, err := New(config).LintBytes(tc.path, content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := 0
+			for _, result := range results {
+				if result.Rule == "naming-conventions" {
+					got++
+				}
+			}
+			if got != tc.want {
+				t.Errorf("%d naming-conventions results, want %d: %v", got, tc.want, results)
+			}
+		})
+	}
+}
+
+func TestCanonicalResults(t *testing.T) {
+	const content = "/* Copyright */\nint x; \nvoid parseInput(void);\n\tint y;\t// y\n"
+	crlf := strings.ReplaceAll(content, "\n", "\r\n")
+	cr := strings.ReplaceAll(content, "\n", "\r")
+
+	lint := func(canonical bool, path, content string) []Result {
+		t.Helper()
+		config := testConfig(".")
+		config.Canonical = canonical
+		results, err := New(config).LintBytes(path, []byte(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return results
+	}
+	want := lint(true, "src/a.c", content)
+	if len(want) == 0 {
+		t.Fatal("no results")
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		content string
+	}{
+		{"unix", "src/a.c", content},
+		{"windows separators", `src\a.c`, content},
+		{"windows line endings", "src/a.c", crlf},
+		{"windows", `src\a.c`, crlf},
+		{"classic mac line endings", "src/a.c", cr},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := lint(true, tc.path, tc.content); !reflect.DeepEqual(got, want) {
+				t.Errorf("results %+v, want %+v", got, want)
+			}
+		})
+	}
+
+	// Without Canonical, backslashes are kept where the OS doesn't use them
+	if filepath.Separator == '/' {
+		if got := lint(false, `src\a.c`, content); got[0].File != `src\a.c` {
+			t.Errorf("non-canonical file %s, want %s", got[0].File, `src\a.c`)
+		}
+	}
+}
+
+func TestForceChecks(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		".codelint.json": `{"rules": {
+			"header-guards": {"enabled": false, "severity": "warning"},
+			"trailing-whitespace": {"enabled": false}
+		}}`,
+		"a.h": "int a; \n",
+	})
+
+	tests := []struct {
+		name   string
+		checks []string
+		force  bool
+		want   map[string]string
+	}{
+		{name: "disabled by config", checks: []string{"header-guards", "formatting"}, want: map[string]string{}},
+		{
+			name:   "forced",
+			checks: []string{"header-guards", "formatting"},
+			force:  true,
+			want:   map[string]string{"header-guards": SeverityWarning, "trailing-whitespace": SeverityWarning},
+		},
+		{
+			name:   "forced category only",
+			checks: []string{"formatting"},
+			force:  true,
+			want:   map[string]string{"trailing-whitespace": SeverityWarning},
+		},
+		{
+			name:   "forced rule ID",
+			checks: []string{"header-guards"},
+			force:  true,
+			want:   map[string]string{"header-guards": SeverityWarning},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := testConfig(root)
+			config.Checks = tc.checks
+			config.ForceChecks = tc.force
+			results, err := New(config).Run()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[string]string)
+			for _, result := range results {
+				if result.Rule == "header-guards" || result.Rule == "trailing-whitespace" {
+					got[result.Rule] = result.Severity
+				}
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("rule severities %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPerFileDisables(t *testing.T) {
+	files := map[string]string{
+		"generated/a.cc":      "int a; \n",
+		"generated/deep/b.cc": "int b; \n",
+		"src/c.cc":            "int c; \n",
+		"src/d.h":             "int d; \n",
+		"external/lib/e.cc":   "int e; \n",
+	}
+	tests := []struct {
+		name    string
+		perFile string
+		want    map[string][]string
+	}{
+		{
+			name: "no per-file section",
+			want: map[string][]string{
+				"external/lib/e.cc":   {"trailing-whitespace"},
+				"generated/a.cc":      {"trailing-whitespace"},
+				"generated/deep/b.cc": {"trailing-whitespace"},
+				"src/c.cc":            {"trailing-whitespace"},
+				"src/d.h":             {"header-guards", "trailing-whitespace"},
+			},
+		},
+		{
+			name:    "glob-scoped disables",
+			perFile: `{"generated/*.cc": ["trailing-whitespace"], "*.h": ["header-guards", "trailing-whitespace"], "external": ["trailing-whitespace"]}`,
+			want: map[string][]string{
+				"generated/deep/b.cc": {"trailing-whitespace"},
+				"src/c.cc":            {"trailing-whitespace"},
+			},
+		},
+		{
+			name:    "other rules unaffected",
+			perFile: `{"src/**": ["naming-conventions"]}`,
+			want: map[string][]string{
+				"external/lib/e.cc":   {"trailing-whitespace"},
+				"generated/a.cc":      {"trailing-whitespace"},
+				"generated/deep/b.cc": {"trailing-whitespace"},
+				"src/c.cc":            {"trailing-whitespace"},
+				"src/d.h":             {"header-guards", "trailing-whitespace"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tree := map[string]string{}
+			for path, content := range files {
+				tree[path] = content
+			}
+			if tc.perFile != "" {
+				tree[".codelint.json"] = `{"per_file": ` + tc.perFile + `}`
+			}
+			results, err := New(testConfig(writeTree(t, tree))).Run()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[string][]string)
+			for _, result := range results {
+				if result.Rule == "header-guards" || result.Rule == "trailing-whitespace" {
+					file := filepath.ToSlash(result.File)
+					got[file] = append(got[file], result.Rule)
+				}
+			}
+			for _, rules := range got {
+				sort.Strings(rules)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("rules reported per file %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPerFileReportedPaths(t *testing.T) {
+	parent, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree := map[string]string{
+		".codelint.json": `{"per_file": {"generated/*.cc": ["trailing-whitespace"]}}`,
+		"generated/a.cc": "int a; \n",
+		"src/b.cc":       "int b; \n",
+	}
+	rootA, rootB := filepath.Join(parent, "a"), filepath.Join(parent, "b")
+	for _, root := range []string{rootA, rootB} {
+		for path, content := range tree {
+			path = filepath.Join(root, filepath.FromSlash(path))
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	tests := []struct {
+		name   string
+		config func(*Config)
+	}{
+		{name: "relative to the root"},
+		{name: "absolute", config: func(c *Config) { c.AbsolutePaths = true }},
+		{name: "relative to the working directory", config: func(c *Config) { c.PathsRelativeTo = PathsRelativeToCWD }},
+		{name: "several roots", config: func(c *Config) { c.RootDirs = []string{rootA, rootB} }},
+		{name: "explicit files", config: func(c *Config) {
+			c.Files = []string{filepath.Join(rootA, "generated", "a.cc"), filepath.Join(rootA, "src", "b.cc")}
+		}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := os.Chdir(parent); err != nil {
+				t.Fatal(err)
+			}
+			config := testConfig(rootA)
+			if tc.config != nil {
+				tc.config(&config)
+			}
+			results, err := New(config).Run()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var generated, sources int
+			for _, result := range results {
+				if result.Rule != "trailing-whitespace" {
+					continue
+				}
+				switch path := filepath.ToSlash(result.File); {
+				case strings.HasSuffix(path, "generated/a.cc"):
+					generated++
+				case strings.HasSuffix(path, "src/b.cc"):
+					sources++
+				}
+			}
+			if generated != 0 || sources == 0 {
+				t.Errorf("%d results for generated/a.cc and %d for src/b.cc, want none and some", generated, sources)
+			}
+		})
+	}
+}
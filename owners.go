@@ -0,0 +1,89 @@
+package codelint
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ownerPattern is a single line of an owners file
+type ownerPattern struct {
+	re    *regexp.Regexp
+	owner string
+
+	// specificity is the number of literal (non-wildcard) characters in
+	// the pattern; the most specific matching pattern wins
+	specificity int
+}
+
+// Owners maps file paths to owning teams using CODEOWNERS-style lines of
+// the form "<glob> <owner>..."
+type Owners struct {
+	patterns []ownerPattern
+}
+
+// LoadOwnersFile reads an owners file
+func LoadOwnersFile(path string) (*Owners, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return parseOwnersLines(lines), nil
+}
+
+// parseOwnersLines compiles owners lines. Blank lines, lines starting with
+// '#' and lines without an owner are skipped.
+func parseOwnersLines(lines []string) *Owners {
+	owners := &Owners{}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		pattern := strings.TrimRight(fields[0], "/")
+		if pattern == "" {
+			continue
+		}
+
+		// A pattern naming a directory owns everything below it
+		re, err := regexp.Compile(globPrefixExpr(pattern) + "(?:/.*)?$")
+		if err != nil {
+			continue
+		}
+		owners.patterns = append(owners.patterns, ownerPattern{
+			re:          re,
+			owner:       strings.Join(fields[1:], " "),
+			specificity: len(pattern) - strings.Count(pattern, "*") - strings.Count(pattern, "?"),
+		})
+	}
+	return owners
+}
+
+// Match returns the owner of a path relative to the root, or "" if no
+// pattern matches. The most specific pattern wins; among equally specific
+// patterns the later one does.
+func (o *Owners) Match(relPath string) string {
+	relPath = filepath.ToSlash(relPath)
+	best := -1
+	owner := ""
+	for _, p := range o.patterns {
+		if p.specificity >= best && p.re.MatchString(relPath) {
+			best = p.specificity
+			owner = p.owner
+		}
+	}
+	return owner
+}
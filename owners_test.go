@@ -0,0 +1,128 @@
+package codelint
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOwnersMatch(t *testing.T) {
+	owners := parseOwnersLines([]string{
+		"# team ownership",
+		"*           @everyone",
+		"src/        @core",
+		"src/net/    @network",
+		"*.h         @api-review",
+		"src/net/tls.c @security @network",
+		"docs",
+		"src/util/*.c @core @tools",
+		"src/util/*.c @tools",
+	})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"README.c", "@everyone"},
+		{"src/main.c", "@core"},
+		{"src/net/socket.c", "@network"},
+		{"src/net/tls.c", "@security @network"},
+		{"include/api.h", "@api-review"},
+		// A longer literal prefix beats a shorter one regardless of order
+		{"src/net/socket.h", "@network"},
+		// Equally specific patterns: the later one wins
+		{"src/util/str.c", "@tools"},
+		{filepath.Join("src", "net", "dns.c"), "@network"},
+	}
+
+	for _, tc := range tests {
+		if got := owners.Match(tc.path); got != tc.want {
+			t.Errorf("Match(%q) = %q, want %q", tc.path, got, tc.want)
+		}
+	}
+
+	if got := parseOwnersLines([]string{"src/ @core"}).Match("lib/a.c"); got != "" {
+		t.Errorf("Match of an unowned file = %q, want \"\"", got)
+	}
+}
+
+func TestOwnersInResults(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"OWNERS.txt": "* @everyone\nnet/ @network\ntools/c.c @tools\n",
+		"a.c":        "int a;\n",
+		"net/b.c":    "int b;\n",
+		"tools/c.c":  "int c;\n",
+	})
+
+	t.Run("annotated", func(t *testing.T) {
+		config := testConfig(root)
+		config.SlashPaths = true
+		config.OwnersFile = filepath.Join(root, "OWNERS.txt")
+		results, err := New(config).Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]string{"a.c": "@everyone", "net/b.c": "@network", "tools/c.c": "@tools"}
+		for _, result := range results {
+			if result.File != "" && result.Owner != want[result.File] {
+				t.Errorf("%s owner %q, want %q", result.File, result.Owner, want[result.File])
+			}
+		}
+	})
+
+	t.Run("absolute paths", func(t *testing.T) {
+		config := testConfig(root)
+		config.AbsolutePaths = true
+		config.OwnersFile = filepath.Join(root, "OWNERS.txt")
+		results, err := New(config).Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]string{"a.c": "@everyone", "net/b.c": "@network", "tools/c.c": "@tools"}
+		seen := 0
+		for _, result := range results {
+			if result.File == "" {
+				continue
+			}
+			rel, err := filepath.Rel(root, result.File)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if owner := want[filepath.ToSlash(rel)]; result.Owner != owner {
+				t.Errorf("%s owner %q, want %q", result.File, result.Owner, owner)
+			}
+			seen++
+		}
+		if seen == 0 {
+			t.Fatal("no file results to check owners on")
+		}
+	})
+
+	t.Run("no owners file", func(t *testing.T) {
+		results, err := New(testConfig(root)).Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, result := range results {
+			if result.Owner != "" {
+				t.Errorf("%s owner %q without an owners file", result.File, result.Owner)
+			}
+			data, err := json.Marshal(result)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(data), `"owner"`) {
+				t.Errorf("JSON %s has an owner field without an owners file", data)
+			}
+		}
+	})
+
+	t.Run("missing owners file", func(t *testing.T) {
+		config := testConfig(root)
+		config.OwnersFile = filepath.Join(root, "NOPE.txt")
+		if _, err := New(config).Run(); err == nil {
+			t.Error("Run() with a missing owners file succeeded, want an error")
+		}
+	})
+}
@@ -0,0 +1,320 @@
+package codelint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Reporter writes a run's results in some output format
+type Reporter interface {
+	Report(w io.Writer, results []Result, summary Summary) error
+}
+
+// NewReporter returns the reporter for a format name: "text", "concise",
+// "json", "ndjson" or "sarif"
+func NewReporter(format string) (Reporter, error) {
+	switch format {
+	case "text":
+		return TextReporter{}, nil
+	case "concise":
+		return ConciseReporter{}, nil
+	case "json":
+		return JSONReporter{}, nil
+	case "ndjson":
+		return NDJSONReporter{}, nil
+	case "sarif":
+		return SARIFReporter{}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+// TextReporter writes one line per result followed by a severity summary
+type TextReporter struct{}
+
+func (TextReporter) Report(w io.Writer, results []Result, summary Summary) error {
+	if len(results) == 0 {
+		_, err := fmt.Fprintln(w, "No issues found!")
+		return err
+	}
+
+	for _, r := range results {
+		if _, err := fmt.Fprintln(w, FormatResult(r)); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%s\nSummary: %d errors, %d warnings, %d info\n",
+		strings.Repeat("-", 60),
+		summary.Severities[SeverityError], summary.Severities[SeverityWarning], summary.Severities[SeverityInfo])
+	return err
+}
+
+// ConciseReporter writes one line per result and nothing else, for hooks
+// where a clean run should print nothing
+type ConciseReporter struct{}
+
+func (ConciseReporter) Report(w io.Writer, results []Result, summary Summary) error {
+	for _, r := range results {
+		if _, err := fmt.Fprintln(w, FormatResult(r)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// JSONReporter writes the results and summary as a single JSON document
+type JSONReporter struct{}
+
+func (JSONReporter) Report(w io.Writer, results []Result, summary Summary) error {
+	if results == nil {
+		results = []Result{}
+	}
+	doc := struct {
+		Results []Result `json:"results"`
+		Summary Summary  `json:"summary"`
+	}{results, summary}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
+
+// NDJSONReporter writes one JSON object per result per line (JSON lines),
+// so consumers can process results without parsing one large document
+type NDJSONReporter struct{}
+
+func (NDJSONReporter) Report(w io.Writer, results []Result, summary Summary) error {
+	encoder := json.NewEncoder(w)
+	for _, r := range results {
+		if err := encoder.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TemplateReporter writes each result through a text/template, e.g.
+// "{{.File}}:{{.Line}}: {{.Message}}", followed by a newline
+type TemplateReporter struct {
+	tmpl *template.Template
+}
+
+// NewTemplateReporter parses a per-result template and checks it against a
+// sample result, so unknown fields are reported before any linting
+func NewTemplateReporter(text string) (*TemplateReporter, error) {
+	tmpl, err := template.New("result").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	sample := Result{File: "a.c", Line: 1, Column: 1, Severity: SeverityInfo, Rule: "sample", Message: "sample"}
+	if err := tmpl.Execute(io.Discard, sample); err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return &TemplateReporter{tmpl: tmpl}, nil
+}
+
+func (t *TemplateReporter) Report(w io.Writer, results []Result, summary Summary) error {
+	for _, r := range results {
+		if err := t.tmpl.Execute(w, r); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Grouping modes of GroupedReporter
+const (
+	GroupByFile     = "file"
+	GroupByRule     = "rule"
+	GroupBySeverity = "severity"
+)
+
+// GroupedReporter writes results in sections headed "== key ==", one per
+// file, rule or severity, for reading large runs. Sections keep the order
+// of the results for files, are sorted by name for rules and run from
+// error to info for severities.
+type GroupedReporter struct {
+	by string
+	// summary adds the text format's "No issues found!" message and
+	// severity summary
+	summary bool
+}
+
+// NewGroupedReporter returns a reporter grouping by GroupByFile,
+// GroupByRule or GroupBySeverity; summary selects text rather than concise
+// output around the groups
+func NewGroupedReporter(by string, summary bool) (*GroupedReporter, error) {
+	switch by {
+	case GroupByFile, GroupByRule, GroupBySeverity:
+		return &GroupedReporter{by: by, summary: summary}, nil
+	default:
+		return nil, fmt.Errorf("unknown grouping %q: want file, rule or severity", by)
+	}
+}
+
+func (g *GroupedReporter) Report(w io.Writer, results []Result, summary Summary) error {
+	if len(results) == 0 {
+		if !g.summary {
+			return nil
+		}
+		_, err := fmt.Fprintln(w, "No issues found!")
+		return err
+	}
+
+	var keys []string
+	groups := make(map[string][]Result)
+	for _, r := range results {
+		key := r.File
+		switch g.by {
+		case GroupByRule:
+			key = r.Rule
+		case GroupBySeverity:
+			key = r.Severity
+		}
+		if _, seen := groups[key]; !seen {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], r)
+	}
+	switch g.by {
+	case GroupByRule:
+		sort.Strings(keys)
+	case GroupBySeverity:
+		sort.SliceStable(keys, func(i, j int) bool {
+			return severityRank[keys[i]] > severityRank[keys[j]]
+		})
+	}
+
+	for i, key := range keys {
+		if key == "" {
+			key = "(no file)"
+		}
+		header := fmt.Sprintf("== %s ==\n", key)
+		if i > 0 {
+			header = "\n" + header
+		}
+		if _, err := io.WriteString(w, header); err != nil {
+			return err
+		}
+		if err := (ConciseReporter{}).Report(w, groups[keys[i]], summary); err != nil {
+			return err
+		}
+	}
+
+	if !g.summary {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "%s\nSummary: %d errors, %d warnings, %d info\n",
+		strings.Repeat("-", 60),
+		summary.Severities[SeverityError], summary.Severities[SeverityWarning], summary.Severities[SeverityInfo])
+	return err
+}
+
+// SARIFReporter writes the results as a SARIF 2.1.0 log, the format read by
+// code scanning tools
+type SARIFReporter struct{}
+
+// Subset of the SARIF 2.1.0 schema written by SARIFReporter
+type (
+	sarifLog struct {
+		Version string     `json:"version"`
+		Schema  string     `json:"$schema"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name  string      `json:"name"`
+		Rules []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID string `json:"id"`
+	}
+	sarifResult struct {
+		RuleID    string          `json:"ruleId"`
+		Level     string          `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations,omitempty"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Region           sarifRegion           `json:"region"`
+	}
+	sarifArtifactLocation struct {
+		URI string `json:"uri"`
+	}
+	sarifRegion struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn,omitempty"`
+		EndLine     int `json:"endLine,omitempty"`
+	}
+)
+
+// sarifLevels maps severities to SARIF result levels
+var sarifLevels = map[string]string{
+	SeverityError:   "error",
+	SeverityWarning: "warning",
+	SeverityInfo:    "note",
+}
+
+func (SARIFReporter) Report(w io.Writer, results []Result, summary Summary) error {
+	run := sarifRun{
+		Tool:    sarifTool{Driver: sarifDriver{Name: "codelint", Rules: []sarifRule{}}},
+		Results: []sarifResult{},
+	}
+
+	seen := make(map[string]bool)
+	for _, r := range results {
+		if !seen[r.Rule] {
+			seen[r.Rule] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: r.Rule})
+		}
+
+		level, ok := sarifLevels[r.Severity]
+		if !ok {
+			level = "none"
+		}
+		result := sarifResult{
+			RuleID:  r.Rule,
+			Level:   level,
+			Message: sarifMessage{Text: r.Message},
+		}
+		if r.File != "" {
+			result.Locations = []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(r.File)},
+					Region:           sarifRegion{StartLine: r.Line, StartColumn: r.Column, EndLine: r.EndLine},
+				},
+			}}
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarifRun{run},
+	})
+}
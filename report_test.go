@@ -0,0 +1,281 @@
+package codelint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// reportResults is a small result set shared by the reporter tests
+var reportResults = []Result{
+	{File: "a.c", Line: 3, Column: 5, Severity: SeverityError, Rule: "header-guards", Message: "Missing guard"},
+	{File: "lib/b.c", Line: 1, Column: 1, EndLine: 4, Severity: SeverityInfo, Rule: "line-length", Message: "Too long"},
+	{Severity: SeverityInfo, Rule: "max-errors", Message: "Maximum error count (1) reached, stopping"},
+}
+
+func TestNewReporter(t *testing.T) {
+	for _, format := range []string{"text", "concise", "json", "ndjson", "sarif"} {
+		if _, err := NewReporter(format); err != nil {
+			t.Errorf("NewReporter(%q): %v", format, err)
+		}
+	}
+	if _, err := NewReporter("xml"); err == nil {
+		t.Error("NewReporter(\"xml\") succeeded, want an error")
+	}
+}
+
+func TestTextReporter(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []Result
+		want    string
+	}{
+		{
+			name: "no results",
+			want: "No issues found!\n",
+		},
+		{
+			name:    "results",
+			results: reportResults,
+			want: "ERROR: a.c:3:5: Missing guard [header-guards]\n" +
+				"INFO: lib/b.c:1:1: Too long (through line 4) [line-length]\n" +
+				"INFO: Maximum error count (1) reached, stopping\n" +
+				"------------------------------------------------------------\n" +
+				"Summary: 1 errors, 0 warnings, 2 info\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := (TextReporter{}).Report(&buf, tc.results, Summarize(tc.results, 2)); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tc.want {
+				t.Errorf("output\n%s\nwant\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestJSONReporter(t *testing.T) {
+	for _, results := range [][]Result{nil, reportResults} {
+		var buf bytes.Buffer
+		summary := Summarize(results, 2)
+		if err := (JSONReporter{}).Report(&buf, results, summary); err != nil {
+			t.Fatal(err)
+		}
+
+		var doc struct {
+			Results []Result `json:"results"`
+			Summary Summary  `json:"summary"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+			t.Fatal(err)
+		}
+		// An empty run is an empty list, not null
+		if doc.Results == nil || len(doc.Results) != len(results) {
+			t.Errorf("results %v, want %d results", doc.Results, len(results))
+		}
+		if len(results) > 0 && !reflect.DeepEqual(doc.Results, results) {
+			t.Errorf("results %+v, want %+v", doc.Results, results)
+		}
+		if !reflect.DeepEqual(doc.Summary, summary) {
+			t.Errorf("summary %+v, want %+v", doc.Summary, summary)
+		}
+	}
+}
+
+func TestSARIFReporter(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (SARIFReporter{}).Report(&buf, reportResults, Summarize(reportResults, 2)); err != nil {
+		t.Fatal(err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("SARIF version %s with %d runs, want 2.1.0 with 1", log.Version, len(log.Runs))
+	}
+	run := log.Runs[0]
+
+	var rules []string
+	for _, rule := range run.Tool.Driver.Rules {
+		rules = append(rules, rule.ID)
+	}
+	if want := []string{"header-guards", "line-length", "max-errors"}; !reflect.DeepEqual(rules, want) {
+		t.Errorf("rules %v, want %v", rules, want)
+	}
+
+	var levels []string
+	for _, result := range run.Results {
+		levels = append(levels, result.Level)
+	}
+	if want := []string{"error", "note", "note"}; !reflect.DeepEqual(levels, want) {
+		t.Errorf("levels %v, want %v", levels, want)
+	}
+
+	want := sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: "lib/b.c"},
+		Region:           sarifRegion{StartLine: 1, StartColumn: 1, EndLine: 4},
+	}
+	if got := run.Results[1].Locations; len(got) != 1 || got[0].PhysicalLocation != want {
+		t.Errorf("locations %+v, want %+v", got, want)
+	}
+	if got := run.Results[2].Locations; len(got) != 0 {
+		t.Errorf("result without a file has locations %+v", got)
+	}
+}
+
+func TestNDJSONReporter(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []Result
+	}{
+		{name: "no results"},
+		{name: "results", results: reportResults},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := (NDJSONReporter{}).Report(&buf, tc.results, Summarize(tc.results, 2)); err != nil {
+				t.Fatal(err)
+			}
+
+			// Every line is a complete JSON document on its own
+			var got []Result
+			for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+				if line == "" {
+					continue
+				}
+				var r Result
+				if err := json.Unmarshal([]byte(line), &r); err != nil {
+					t.Fatalf("line %q: %v", line, err)
+				}
+				got = append(got, r)
+			}
+			if !reflect.DeepEqual(got, tc.results) {
+				t.Errorf("results %+v, want %+v", got, tc.results)
+			}
+		})
+	}
+}
+
+func TestTemplateReporter(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{
+			name:     "compiler style",
+			template: "{{.File}}:{{.Line}}:{{.Column}}: {{.Severity}}: {{.Message}} [{{.Rule}}]",
+			want: "a.c:3:5: error: Missing guard [header-guards]\n" +
+				"lib/b.c:1:1: info: Too long [line-length]\n" +
+				":0:0: info: Maximum error count (1) reached, stopping [max-errors]\n",
+		},
+		{
+			name:     "conditionals",
+			template: "{{if .File}}{{.File}}{{else}}-{{end}}{{if .EndLine}}-{{.EndLine}}{{end}} {{.Rule}}",
+			want:     "a.c header-guards\nlib/b.c-4 line-length\n- max-errors\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			reporter, err := NewTemplateReporter(tc.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := reporter.Report(&buf, reportResults, Summarize(reportResults, 2)); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tc.want {
+				t.Errorf("output\n%s\nwant\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewTemplateReporterInvalid(t *testing.T) {
+	for _, template := range []string{"{{.File", "{{.Filename}}", "{{.Line.Count}}"} {
+		if _, err := NewTemplateReporter(template); err == nil {
+			t.Errorf("NewTemplateReporter(%q) succeeded, want an error", template)
+		}
+	}
+}
+
+func TestGroupedReporter(t *testing.T) {
+	results := []Result{
+		{File: "a.c", Line: 1, Column: 1, Severity: SeverityInfo, Rule: "todo-format", Message: "Todo"},
+		{File: "a.c", Line: 3, Column: 5, Severity: SeverityError, Rule: "header-guards", Message: "Missing guard"},
+		{File: "b.c", Line: 2, Column: 1, Severity: SeverityWarning, Rule: "header-guards", Message: "Guard"},
+		{Severity: SeverityInfo, Rule: "max-errors", Message: "Maximum error count (1) reached, stopping"},
+	}
+	const (
+		todo    = "INFO: a.c:1:1: Todo [todo-format]\n"
+		missing = "ERROR: a.c:3:5: Missing guard [header-guards]\n"
+		guard   = "WARNING: b.c:2:1: Guard [header-guards]\n"
+		max     = "INFO: Maximum error count (1) reached, stopping\n"
+		summary = "------------------------------------------------------------\nSummary: 1 errors, 1 warnings, 2 info\n"
+	)
+
+	tests := []struct {
+		by      string
+		summary bool
+		results []Result
+		want    string
+	}{
+		{
+			by:      GroupByFile,
+			results: results,
+			want:    "== a.c ==\n" + todo + missing + "\n== b.c ==\n" + guard + "\n== (no file) ==\n" + max,
+		},
+		{
+			by:      GroupByRule,
+			results: results,
+			want:    "== header-guards ==\n" + missing + guard + "\n== max-errors ==\n" + max + "\n== todo-format ==\n" + todo,
+		},
+		{
+			by:      GroupBySeverity,
+			results: results,
+			want:    "== error ==\n" + missing + "\n== warning ==\n" + guard + "\n== info ==\n" + todo + max,
+		},
+		{
+			by:      GroupByFile,
+			summary: true,
+			results: results,
+			want:    "== a.c ==\n" + todo + missing + "\n== b.c ==\n" + guard + "\n== (no file) ==\n" + max + summary,
+		},
+		{by: GroupByFile, want: ""},
+		{by: GroupByFile, summary: true, want: "No issues found!\n"},
+	}
+
+	for _, tc := range tests {
+		name := fmt.Sprintf("%s summary=%v results=%d", tc.by, tc.summary, len(tc.results))
+		t.Run(name, func(t *testing.T) {
+			reporter, err := NewGroupedReporter(tc.by, tc.summary)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := reporter.Report(&buf, tc.results, Summarize(tc.results, 2)); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tc.want {
+				t.Errorf("output\n%s\nwant\n%s", got, tc.want)
+			}
+		})
+	}
+
+	if _, err := NewGroupedReporter("owner", false); err == nil {
+		t.Error("NewGroupedReporter(\"owner\") succeeded, want an error")
+	}
+}
@@ -2,6 +2,7 @@ package codelint
 
 import (
 	"fmt"
+	"path/filepath"
 	"regexp"
 	"strings"
 )
@@ -12,6 +13,14 @@ type Rule interface {
 	Check(file FileInfo) []Result
 }
 
+// ProjectRule is a rule that needs to see every file of a run, such as a
+// check for collisions between files. CheckProject runs once after all
+// files have been checked individually.
+type ProjectRule interface {
+	Rule
+	CheckProject(files []FileInfo) []Result
+}
+
 // Rules contains all available linting rules
 type Rules struct {
 	rules       []Rule
@@ -21,35 +30,97 @@ type Rules struct {
 
 // NewRules creates a new rule set based on the configuration
 func NewRules(config Config) *Rules {
+	// Load the local rules configuration or the defaults
+	return newRulesWithConfig(config, loadRulesConfigFor(config))
+}
+
+// newRulesWithConfig creates the built-in rule set using an already
+// resolved rules configuration
+func newRulesWithConfig(config Config, rulesConfig *RulesConfig) *Rules {
+	if config.ForceChecks {
+		rulesConfig = rulesConfig.withEnabled(config.Checks)
+	}
 	r := &Rules{
-		enabled: make(map[string]bool),
+		enabled:     make(map[string]bool),
+		rulesConfig: rulesConfig,
 	}
 
-	// Load remote rules configuration
-	rulesConfig, _ := LoadRulesConfig()
-	r.rulesConfig = rulesConfig
-
 	// Get max line length from config
 	maxLineLength := 100
+	maxCommentLength := 0
+	exemptRawStrings := false
 	if formattingRule, exists := rulesConfig.GetRuleConfig("formatting"); exists {
 		if val, ok := formattingRule.Parameters["max_line_length"].(float64); ok {
 			maxLineLength = int(val)
 		}
+		if val, ok := formattingRule.Parameters["max_comment_length"].(float64); ok {
+			maxCommentLength = int(val)
+		}
+		if val, ok := formattingRule.Parameters["exempt_raw_strings"].(bool); ok {
+			exemptRawStrings = val
+		}
 	}
 
 	// Initialize all rules
 	r.rules = []Rule{
 		&LicenseHeaderRule{rulesConfig: rulesConfig},
 		&HeaderGuardRule{rulesConfig: rulesConfig},
+		&HeaderGuardCollisionRule{rulesConfig: rulesConfig},
 		&NamingConventionRule{rulesConfig: rulesConfig},
 		&FormattingRule{rulesConfig: rulesConfig},
 		&TrailingWhitespaceRule{rulesConfig: rulesConfig},
-		&LineLengthRule{MaxLength: maxLineLength, rulesConfig: rulesConfig},
+		&LineLengthRule{MaxLength: maxLineLength, MaxCommentLength: maxCommentLength, ExemptRawStrings: exemptRawStrings, rulesConfig: rulesConfig},
+		&NonASCIIRule{rulesConfig: rulesConfig},
+		&EncodingRule{rulesConfig: rulesConfig},
+		&EmptyFileRule{rulesConfig: rulesConfig},
+		&LeadingBlankLinesRule{rulesConfig: rulesConfig},
+		&FunctionSpacingRule{rulesConfig: rulesConfig},
+		&IncludeStyleRule{rulesConfig: rulesConfig},
+		&IncludeCountRule{rulesConfig: rulesConfig},
+		&BannedIncludeRule{rulesConfig: rulesConfig},
+		&OwnHeaderFirstRule{rulesConfig: rulesConfig},
+		&IncludeDepthRule{rulesConfig: rulesConfig},
+		&IndentWidthRule{rulesConfig: rulesConfig},
+		&IndentColumnRule{rulesConfig: rulesConfig},
+		&EmptyStatementRule{rulesConfig: rulesConfig},
+		&MacroHygieneRule{rulesConfig: rulesConfig},
+		&MutableGlobalRule{rulesConfig: rulesConfig},
+		&UsingNamespaceRule{rulesConfig: rulesConfig},
+		&ImplicitIntRule{rulesConfig: rulesConfig},
+		&UnusedStaticRule{rulesConfig: rulesConfig},
+		&VoidReturnRule{rulesConfig: rulesConfig},
+		&LibraryIORule{rulesConfig: rulesConfig},
+		&MissingOverrideRule{rulesConfig: rulesConfig},
+		&ConstantConditionRule{rulesConfig: rulesConfig},
+		&HeaderDefinitionRule{rulesConfig: rulesConfig},
+		&DeprecatedAPIRule{rulesConfig: rulesConfig},
+		&ClassKeyRule{rulesConfig: rulesConfig},
+		&CatchHandlerRule{rulesConfig: rulesConfig},
+		&MacroConstantRule{rulesConfig: rulesConfig},
+		&LongParameterListRule{rulesConfig: rulesConfig},
+		&ParameterWrappingRule{rulesConfig: rulesConfig},
+		&NestingDepthRule{rulesConfig: rulesConfig},
+		&HeaderDocsRule{rulesConfig: rulesConfig},
+		&ClosingCommentRule{rulesConfig: rulesConfig},
+		&IdentifierLengthRule{rulesConfig: rulesConfig},
+		&PredicateNameRule{rulesConfig: rulesConfig},
+		&BooleanComplexityRule{rulesConfig: rulesConfig},
+		&TodoDensityRule{rulesConfig: rulesConfig},
+		&TodoFormatRule{rulesConfig: rulesConfig},
+		&IfZeroRule{rulesConfig: rulesConfig},
+		&CommentAlignmentRule{rulesConfig: rulesConfig},
+		&SectionCommentRule{rulesConfig: rulesConfig},
+		&RedundantElseRule{rulesConfig: rulesConfig},
+		&NamespaceIndentRule{rulesConfig: rulesConfig},
+		&TabAlignmentRule{rulesConfig: rulesConfig},
+		&MidLineTabRule{rulesConfig: rulesConfig},
+		&KeywordSpaceRule{rulesConfig: rulesConfig},
 	}
 
-	// Enable rules based on both config and remote configuration
+	// Enable rules based on both config and rules configuration
 	for _, check := range config.Checks {
-		// Check if the rule is enabled in remote config
+		// Check if the rule is enabled in the rules configuration (always
+		// true for forced checks)
 		if r.rulesConfig.IsRuleEnabled(check) {
 			r.enabled[check] = true
 		}
@@ -58,31 +129,141 @@ func NewRules(config Config) *Rules {
 	return r
 }
 
+// newRuleSet wraps an explicit list of rules, enabling each of them and
+// using the default rules configuration
+func newRuleSet(rules []Rule) *Rules {
+	r := &Rules{
+		rules:       rules,
+		enabled:     make(map[string]bool),
+		rulesConfig: defaultRulesConfig(),
+	}
+	for _, rule := range rules {
+		r.enabled[rule.Name()] = true
+	}
+	return r
+}
+
+// CheckRequired returns an error naming any of the given rules that the
+// rules configuration disables. Names that are neither configured, built in
+// nor registered are reported too, so a misspelled rule can't pass.
+func (r *Rules) CheckRequired(names []string) error {
+	known := make(map[string]bool)
+	for name := range defaultRulesConfig().Rules {
+		known[name] = true
+	}
+	for name := range r.rulesConfig.Rules {
+		known[name] = true
+	}
+	for _, rule := range r.rules {
+		known[rule.Name()] = true
+	}
+
+	var unknown, disabled []string
+	for _, name := range names {
+		if !known[name] {
+			unknown = append(unknown, name)
+		} else if !r.rulesConfig.IsRuleEnabled(name) {
+			disabled = append(disabled, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown required rules: %s", strings.Join(unknown, ", "))
+	}
+	if len(disabled) > 0 {
+		return fmt.Errorf("required rules disabled by %s config: %s",
+			r.rulesConfig.Source, strings.Join(disabled, ", "))
+	}
+	return nil
+}
+
 // CheckFile runs all enabled rules on a file
 func (r *Rules) CheckFile(file FileInfo) []Result {
 	var results []Result
 
+	// Files with bad bytes may be limited to the encoding check
+	onlyEncoding := false
+	if ruleConfig, _ := r.rulesConfig.GetRuleConfig("encoding"); ruleConfig.Parameters["skip_other_checks"] == true {
+		onlyEncoding = (&EncodingRule{rulesConfig: r.rulesConfig}).hasInvalidEncoding(file)
+	}
+
 	for _, rule := range r.rules {
-		// Check if this rule category is enabled
-		ruleName := rule.Name()
-		enabled := false
-		
-		// Check for exact match or category match
-		for enabledRule := range r.enabled {
-			if enabledRule == ruleName || strings.HasPrefix(ruleName, enabledRule) {
-				enabled = true
-				break
-			}
+		if _, isEncoding := rule.(*EncodingRule); onlyEncoding && !isEncoding {
+			continue
+		}
+		if r.isEnabled(rule) {
+			results = append(results, r.capSeverities(rule.Check(file))...)
+		}
+	}
+
+	return r.dropPerFile(results, []FileInfo{file})
+}
+
+// CheckProject runs the enabled project rules over all checked files
+func (r *Rules) CheckProject(files []FileInfo) []Result {
+	var results []Result
+	for _, rule := range r.rules {
+		if projectRule, ok := rule.(ProjectRule); ok && r.isEnabled(rule) {
+			results = append(results, r.capSeverities(projectRule.CheckProject(files))...)
 		}
+	}
+	return r.dropPerFile(results, files)
+}
 
-		if enabled {
-			results = append(results, rule.Check(file)...)
+// dropPerFile removes the results of rules that the configuration's
+// per_file section disables for their file. Globs match the path relative
+// to the file's root, looked up from files by the reported path.
+func (r *Rules) dropPerFile(results []Result, files []FileInfo) []Result {
+	perFile := r.rulesConfig.perFileGlobs()
+	if len(perFile) == 0 {
+		return results
+	}
+	paths := make(map[string]string, len(files))
+	for _, file := range files {
+		paths[file.Path] = file.matchPath()
+	}
+	kept := results[:0]
+	for _, result := range results {
+		path, ok := paths[result.File]
+		if !ok {
+			path = filepath.ToSlash(result.File)
+		}
+		if !perFile.disables(path, result.Rule) {
+			kept = append(kept, result)
 		}
 	}
+	return kept
+}
 
+// capSeverities lowers the severity of results above their rule's
+// max_severity parameter, so a rule can't fail a build whatever severity
+// the configuration gives it
+func (r *Rules) capSeverities(results []Result) []Result {
+	for i := range results {
+		ruleConfig, _ := r.rulesConfig.GetRuleConfig(results[i].Rule)
+		ceiling, ok := ruleConfig.Parameters["max_severity"].(string)
+		if !ok {
+			continue
+		}
+		if rank, known := severityRank[ceiling]; known && severityRank[results[i].Severity] > rank {
+			results[i].Severity = ceiling
+		}
+	}
 	return results
 }
 
+// isEnabled reports whether a rule's category is enabled
+func (r *Rules) isEnabled(rule Rule) bool {
+	ruleName := rule.Name()
+
+	// Check for exact match or category match
+	for enabledRule := range r.enabled {
+		if enabledRule == ruleName || strings.HasPrefix(ruleName, enabledRule) {
+			return true
+		}
+	}
+	return false
+}
+
 // LicenseHeaderRule checks for proper license headers
 type LicenseHeaderRule struct {
 	rulesConfig *RulesConfig
@@ -147,7 +328,8 @@ func (r *LicenseHeaderRule) Check(file FileInfo) []Result {
 	return results
 }
 
-// HeaderGuardRule checks for proper header guards in .h files
+// HeaderGuardRule checks for proper header guards in header files, by
+// default .h and .hpp
 type HeaderGuardRule struct {
 	rulesConfig *RulesConfig
 }
@@ -166,7 +348,8 @@ func (r *HeaderGuardRule) Check(file FileInfo) []Result {
 	}
 
 	// Only check header files
-	if !strings.HasSuffix(file.Path, ".h") && !strings.HasSuffix(file.Path, ".hpp") {
+	extensions := ruleConfig.stringListParam("header_extensions", []string{".h", ".hpp"})
+	if !hasHeaderExtension(file.Path, extensions) {
 		return results
 	}
 
@@ -174,10 +357,17 @@ func (r *HeaderGuardRule) Check(file FileInfo) []Result {
 	hasIfndef := false
 	hasDefine := false
 	hasEndif := false
+	guard, guardLine := "", -1
 
+	mask := file.commentMask()
 	for i, line := range file.Lines {
-		trimmed := strings.TrimSpace(line)
+		trimmed := strings.TrimSpace(blankMasked(line, mask[i]))
 		if strings.HasPrefix(trimmed, "#ifndef") {
+			if !hasIfndef {
+				if fields := strings.Fields(trimmed); len(fields) > 1 {
+					guard, guardLine = fields[1], i
+				}
+			}
 			hasIfndef = true
 		} else if strings.HasPrefix(trimmed, "#define") && hasIfndef {
 			hasDefine = true
@@ -195,8 +385,7 @@ func (r *HeaderGuardRule) Check(file FileInfo) []Result {
 		}
 
 		// Stop checking after first non-comment, non-preprocessor line
-		if i > 20 && trimmed != "" && !strings.HasPrefix(trimmed, "//") && 
-		   !strings.HasPrefix(trimmed, "/*") && !strings.HasPrefix(trimmed, "#") {
+		if i > 20 && trimmed != "" && !strings.HasPrefix(trimmed, "#") {
 			break
 		}
 	}
@@ -210,11 +399,205 @@ func (r *HeaderGuardRule) Check(file FileInfo) []Result {
 			Rule:     r.Name(),
 			Message:  "Missing or incomplete header guard",
 		})
+	} else if guard != "" {
+		results = append(results, r.checkGuardOrder(file, ruleConfig, guard, guardLine)...)
+		if requireComment, _ := ruleConfig.Parameters["require_endif_comment"].(bool); requireComment {
+			results = append(results, r.checkEndifComment(file, ruleConfig, guard)...)
+		}
+	}
+
+	return results
+}
+
+// hasHeaderExtension reports whether path ends in one of extensions; an
+// empty extension matches files without one
+func hasHeaderExtension(path string, extensions []string) bool {
+	ext := filepath.Ext(path)
+	for _, e := range extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// checkGuardOrder verifies that the guard macro is defined directly after
+// the #ifndef. Content between the two is outside the guard's protection
+// and is repeated each time the header is included.
+func (r *HeaderGuardRule) checkGuardOrder(file FileInfo, ruleConfig RuleConfig, guard string, guardLine int) []Result {
+	var results []Result
+
+	mask := file.commentMask()
+	content := -1
+	for i := guardLine + 1; i < len(file.Lines); i++ {
+		trimmed := strings.TrimSpace(blankMasked(file.Lines[i], mask[i]))
+		if trimmed == "" {
+			continue
+		}
+		fields := strings.Fields(trimmed)
+		if len(fields) > 1 && fields[0] == "#define" && fields[1] == guard {
+			if content >= 0 {
+				results = append(results, Result{
+					File:     file.Path,
+					Line:     i + 1,
+					Column:   1,
+					Severity: ruleConfig.Severity,
+					Rule:     r.Name(),
+					Message:  fmt.Sprintf("Header guard %s is defined after content at line %d; move the #define directly below the #ifndef", guard, content+1),
+				})
+			}
+			break
+		}
+		if content < 0 {
+			content = i
+		}
+	}
+
+	return results
+}
+
+// checkEndifComment verifies that the final #endif is labeled with a
+// comment naming the guard macro
+func (r *HeaderGuardRule) checkEndifComment(file FileInfo, ruleConfig RuleConfig, guard string) []Result {
+	var results []Result
+
+	for i := len(file.Lines) - 1; i >= 0; i-- {
+		trimmed := strings.TrimSpace(file.Lines[i])
+		if !strings.HasPrefix(trimmed, "#endif") {
+			continue
+		}
+
+		comment := strings.TrimSpace(strings.TrimPrefix(trimmed, "#endif"))
+		label := ""
+		if strings.HasPrefix(comment, "//") {
+			label = strings.TrimSpace(strings.TrimPrefix(comment, "//"))
+		} else if strings.HasPrefix(comment, "/*") {
+			label = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(comment, "/*"), "*/"))
+		}
+
+		var message string
+		if label == "" {
+			message = fmt.Sprintf("Closing #endif should be labeled // %s", guard)
+		} else if label != guard {
+			message = fmt.Sprintf("Closing #endif comment %q does not match guard %s", label, guard)
+		}
+		if message != "" {
+			results = append(results, Result{
+				File:     file.Path,
+				Line:     i + 1,
+				Column:   1,
+				Severity: ruleConfig.Severity,
+				Rule:     r.Name(),
+				Message:  message,
+			})
+		}
+		break
 	}
 
 	return results
 }
 
+// HeaderGuardCollisionRule reports headers that share a guard macro, which
+// makes all but the first one included silently empty
+type HeaderGuardCollisionRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *HeaderGuardCollisionRule) Name() string {
+	return "header-guards"
+}
+
+// Check does nothing; collisions are found across files by CheckProject
+func (r *HeaderGuardCollisionRule) Check(file FileInfo) []Result {
+	return nil
+}
+
+func (r *HeaderGuardCollisionRule) CheckProject(files []FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("header-guard-collision")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	type guardUse struct {
+		path string
+		line int
+	}
+	// Headers are recognized as for the header-guards rule
+	guardConfig, _ := r.rulesConfig.GetRuleConfig("header-guards")
+	extensions := guardConfig.stringListParam("header_extensions", []string{".h", ".hpp"})
+
+	uses := make(map[string][]guardUse)
+	var guards []string
+	for _, file := range files {
+		if !hasHeaderExtension(file.Path, extensions) {
+			continue
+		}
+		guard, line := headerGuardName(file)
+		if guard == "" {
+			continue
+		}
+		if len(uses[guard]) == 0 {
+			guards = append(guards, guard)
+		}
+		uses[guard] = append(uses[guard], guardUse{file.Path, line})
+	}
+
+	for _, guard := range guards {
+		if len(uses[guard]) < 2 {
+			continue
+		}
+		for i, use := range uses[guard] {
+			var others []string
+			for j, other := range uses[guard] {
+				if j != i {
+					others = append(others, other.path)
+				}
+			}
+			results = append(results, Result{
+				File:     use.path,
+				Line:     use.line + 1,
+				Column:   1,
+				Severity: ruleConfig.Severity,
+				Rule:     "header-guard-collision",
+				Message:  fmt.Sprintf("Header guard %s is also used by %s", guard, strings.Join(others, ", ")),
+			})
+		}
+	}
+
+	return results
+}
+
+// headerGuardName returns the macro of an #ifndef/#define guard pair and
+// the 0-based line of the #ifndef, or "" if the file has no such guard
+func headerGuardName(file FileInfo) (string, int) {
+	mask := file.commentMask()
+	guard, guardLine := "", -1
+	for i, line := range file.Lines {
+		trimmed := strings.TrimSpace(blankMasked(line, mask[i]))
+		if trimmed == "" {
+			continue
+		}
+		fields := strings.Fields(trimmed)
+		if guard != "" {
+			// The #define must directly follow the #ifndef
+			if len(fields) > 1 && fields[0] == "#define" && fields[1] == guard {
+				return guard, guardLine
+			}
+			return "", -1
+		}
+		if len(fields) > 1 && fields[0] == "#ifndef" {
+			guard, guardLine = fields[1], i
+			continue
+		}
+		// Only the first directive can open a guard
+		return "", -1
+	}
+	return "", -1
+}
+
 // NamingConventionRule checks naming conventions
 type NamingConventionRule struct {
 	rulesConfig *RulesConfig
@@ -234,26 +617,42 @@ func (r *NamingConventionRule) Check(file FileInfo) []Result {
 	}
 
 	// Check for common naming issues
-	camelCaseFunc := regexp.MustCompile(`\b[a-z]+[A-Z][a-zA-Z]*\s*\(`)
+	camelCaseFunc := regexp.MustCompile(`\b([a-z]+[A-Z][a-zA-Z]*)\s*\(`)
+
+	// Names (or regular expressions) allowed to stay camelCase, e.g.
+	// callbacks matching a third-party API
+	var allowed []*regexp.Regexp
+	for _, pattern := range ruleConfig.stringListParam("allow", nil) {
+		re, err := regexp.Compile(`^(?:` + pattern + `)$`)
+		if err != nil {
+			re = regexp.MustCompile(`^` + regexp.QuoteMeta(pattern) + `$`)
+		}
+		allowed = append(allowed, re)
+	}
 	
+	comments, strs := file.commentMask(), file.stringMask()
 	for i, line := range file.Lines {
-		// Skip comments
-		trimmed := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "/*") {
+		// Skip comments (including block comment bodies) and string literals
+		line = blankMasked(line, comments[i], strs[i])
+		if strings.TrimSpace(line) == "" {
 			continue
 		}
 
 		// Check for camelCase function names (C code typically uses snake_case)
-		if strings.HasSuffix(file.Path, ".c") {
-			if matches := camelCaseFunc.FindAllString(line, -1); len(matches) > 0 {
+		if file.isCSource() {
+			for _, m := range camelCaseFunc.FindAllStringSubmatch(line, -1) {
+				if matchesAny(allowed, m[1]) {
+					continue
+				}
 				results = append(results, Result{
 					File:     file.Path,
 					Line:     i + 1,
 					Column:   1,
 					Severity: ruleConfig.Severity,
 					Rule:     r.Name(),
-					Message:  fmt.Sprintf("Function name should use snake_case: %s", matches[0]),
+					Message:  fmt.Sprintf("Function name should use snake_case: %s", m[0]),
 				})
+				break
 			}
 		}
 	}
@@ -261,6 +660,16 @@ func (r *NamingConventionRule) Check(file FileInfo) []Result {
 	return results
 }
 
+// matchesAny reports whether s matches any of the patterns
+func matchesAny(patterns []*regexp.Regexp, s string) bool {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
 // FormattingRule checks basic formatting issues
 type FormattingRule struct {
 	rulesConfig *RulesConfig
@@ -279,7 +688,11 @@ func (r *FormattingRule) Check(file FileInfo) []Result {
 		return results
 	}
 
-	// Check for tabs vs spaces (assuming spaces are preferred)
+	// Check for tabs vs spaces (assuming spaces are preferred, unless
+	// check_tabs is turned off, e.g. by indent_style = tab)
+	if checkTabs, ok := ruleConfig.Parameters["check_tabs"].(bool); ok && !checkTabs {
+		return results
+	}
 	for i, line := range file.Lines {
 		if strings.Contains(line, "\t") {
 			results = append(results, Result{
@@ -315,15 +728,46 @@ func (r *TrailingWhitespaceRule) Check(file FileInfo) []Result {
 		return results
 	}
 
+	exemptBlockComments := false
+	if val, ok := ruleConfig.Parameters["exempt_block_comments"].(bool); ok {
+		exemptBlockComments = val
+	}
+
+	// Whitespace-only lines are reported like any other ("same"), under
+	// their own blank-line-whitespace ID ("separate"), or not at all
+	// ("ignore")
+	blankLines := "same"
+	if val, ok := ruleConfig.Parameters["blank_lines"].(string); ok {
+		blankLines = val
+	}
+
+	var mask [][]bool
+	if exemptBlockComments {
+		mask = file.commentMask()
+	}
+
 	for i, line := range file.Lines {
 		if len(line) > 0 && (strings.HasSuffix(line, " ") || strings.HasSuffix(line, "\t")) {
+			// Block comment bodies (e.g. ASCII art) may be exempt
+			if mask != nil && isInBlockComment(line, mask[i]) {
+				continue
+			}
+			rule, message := "trailing-whitespace", "Line has trailing whitespace"
+			if strings.TrimSpace(line) == "" {
+				switch blankLines {
+				case "ignore":
+					continue
+				case "separate":
+					rule, message = "blank-line-whitespace", "Blank line contains whitespace"
+				}
+			}
 			results = append(results, Result{
 				File:     file.Path,
 				Line:     i + 1,
 				Column:   len(line),
 				Severity: SeverityWarning,
-				Rule:     "trailing-whitespace",
-				Message:  "Line has trailing whitespace",
+				Rule:     rule,
+				Message:  message,
 			})
 		}
 	}
@@ -331,10 +775,28 @@ func (r *TrailingWhitespaceRule) Check(file FileInfo) []Result {
 	return results
 }
 
+// isInBlockComment reports whether every byte of line, including trailing
+// whitespace, belongs to a block comment
+func isInBlockComment(line string, mask []bool) bool {
+	if strings.HasPrefix(strings.TrimSpace(line), "//") {
+		return false
+	}
+	for i := range line {
+		if !mask[i] {
+			return false
+		}
+	}
+	return true
+}
+
 // LineLengthRule checks for lines that are too long
 type LineLengthRule struct {
-	MaxLength   int
-	rulesConfig *RulesConfig
+	MaxLength int
+	// MaxCommentLength applies to comment-only lines (0 = MaxLength)
+	MaxCommentLength int
+	// ExemptRawStrings skips lines whose overflow lies inside a raw string
+	ExemptRawStrings bool
+	rulesConfig      *RulesConfig
 }
 
 func (r *LineLengthRule) Name() string {
@@ -344,8 +806,36 @@ func (r *LineLengthRule) Name() string {
 func (r *LineLengthRule) Check(file FileInfo) []Result {
 	var results []Result
 
+	var mask [][]bool
+	if r.MaxCommentLength > 0 {
+		mask = file.commentMask()
+	}
+	var raw [][]bool
+	if r.ExemptRawStrings {
+		raw = file.rawStringMask()
+	}
+
 	for i, line := range file.Lines {
+		// Comment-only lines may have their own budget
+		if mask != nil && isCommentOnly(line, mask[i]) {
+			if len(line) > r.MaxCommentLength {
+				results = append(results, Result{
+					File:     file.Path,
+					Line:     i + 1,
+					Column:   r.MaxCommentLength + 1,
+					Severity: SeverityInfo,
+					Rule:     "line-length",
+					Message:  fmt.Sprintf("Comment line exceeds %d characters (%d)", r.MaxCommentLength, len(line)),
+				})
+			}
+			continue
+		}
+
 		if len(line) > r.MaxLength {
+			// Embedded SQL, shaders and the like may run long
+			if raw != nil && raw[i][r.MaxLength] {
+				continue
+			}
 			results = append(results, Result{
 				File:     file.Path,
 				Line:     i + 1,
@@ -0,0 +1,1381 @@
+package codelint
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// controlHeader matches the start of an if/for/while header
+var controlHeader = regexp.MustCompile(`\b(if|for|while)\s*\(`)
+
+// EmptyStatementRule flags stray semicolons that form an empty statement,
+// such as `if (x);` or a line holding only `;`
+type EmptyStatementRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *EmptyStatementRule) Name() string {
+	return "bugprone"
+}
+
+func (r *EmptyStatementRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("empty-statement")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	// String literals and comments can't hold statements
+	mask := file.commentMask()
+	codes := codeLines(file.Lines)
+	prevCode := ""
+	for i, line := range file.Lines {
+		code := codes[i]
+		comment := commentText(line, mask[i])
+		intentional := strings.Contains(strings.ToLower(comment), "intentional")
+
+		if strings.TrimSpace(code) == ";" && !intentional {
+			results = append(results, Result{
+				File:     file.Path,
+				Line:     i + 1,
+				Column:   strings.Index(code, ";") + 1,
+				Severity: ruleConfig.Severity,
+				Rule:     "empty-statement",
+				Message:  "Empty statement",
+			})
+		}
+
+		for _, loc := range controlHeader.FindAllStringSubmatchIndex(code, -1) {
+			keyword := code[loc[2]:loc[3]]
+			closeIdx := matchingParen(code, loc[1]-1)
+			if closeIdx < 0 {
+				continue
+			}
+			rest := strings.TrimLeft(code[closeIdx+1:], " \t")
+			if !strings.HasPrefix(rest, ";") || intentional {
+				continue
+			}
+
+			// `} while (cond);` closes a do-while loop
+			before := strings.TrimSpace(code[:loc[0]])
+			if keyword == "while" && (strings.HasSuffix(before, "}") ||
+				(before == "" && strings.HasSuffix(prevCode, "}"))) {
+				continue
+			}
+
+			results = append(results, Result{
+				File:     file.Path,
+				Line:     i + 1,
+				Column:   len(code) - len(rest) + 1,
+				Severity: ruleConfig.Severity,
+				Rule:     "empty-statement",
+				Message:  "Empty body after '" + keyword + "'; remove the stray ';' or mark it // intentional",
+			})
+		}
+
+		if trimmedCode := strings.TrimSpace(code); trimmedCode != "" {
+			prevCode = trimmedCode
+		}
+	}
+
+	return results
+}
+
+// matchingParen returns the index of the ')' matching the '(' at open,
+// or -1 if it is not closed on the same line
+func matchingParen(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// functionMacro matches the start of a function-like macro definition
+var functionMacro = regexp.MustCompile(`^#\s*define\s+([A-Za-z_]\w*)\(([^)]*)\)(.*)$`)
+
+// MacroHygieneRule flags function-like macros that use a parameter as an
+// operand without parenthesizing it
+type MacroHygieneRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *MacroHygieneRule) Name() string {
+	return "bugprone"
+}
+
+func (r *MacroHygieneRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("macro-hygiene")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	code := codeLines(file.Lines)
+	for i := 0; i < len(code); i++ {
+		start := i
+		text := strings.TrimSpace(code[i])
+
+		// Join continuation lines
+		for strings.HasSuffix(text, "\\") && i+1 < len(code) {
+			i++
+			text = strings.TrimSuffix(text, "\\") + " " + strings.TrimSpace(code[i])
+		}
+
+		m := functionMacro.FindStringSubmatch(text)
+		if m == nil {
+			continue
+		}
+
+		body := m[3]
+		for _, param := range strings.Split(m[2], ",") {
+			param = strings.TrimSpace(param)
+			if param == "" || param == "..." {
+				continue
+			}
+			if unparenthesizedUse(body, param) {
+				results = append(results, Result{
+					File:     file.Path,
+					Line:     start + 1,
+					Column:   1,
+					Severity: ruleConfig.Severity,
+					Rule:     "macro-hygiene",
+					Message:  fmt.Sprintf("Macro %s uses parameter '%s' without parentheses", m[1], param),
+				})
+			}
+		}
+	}
+
+	return results
+}
+
+// unparenthesizedUse reports whether param appears in body as an operand
+// of an operator without being wrapped in parentheses
+func unparenthesizedUse(body, param string) bool {
+	const operators = "+-*/%<>=&|^!~?:."
+
+	re := regexp.MustCompile(`\b` + regexp.QuoteMeta(param) + `\b`)
+	for _, loc := range re.FindAllStringIndex(body, -1) {
+		before := strings.TrimRight(body[:loc[0]], " \t")
+		after := strings.TrimLeft(body[loc[1]:], " \t")
+
+		// Stringizing and token pasting don't evaluate the argument
+		if strings.HasSuffix(before, "#") || strings.HasPrefix(after, "##") {
+			continue
+		}
+
+		var prev, next byte
+		if before != "" {
+			prev = before[len(before)-1]
+		}
+		if after != "" {
+			next = after[0]
+		}
+		if (prev == '(' || prev == ',') && (next == ')' || next == ',') {
+			continue
+		}
+		if (prev != 0 && strings.IndexByte(operators, prev) >= 0) ||
+			(next != 0 && strings.IndexByte(operators, next) >= 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// globalDeclarator matches the type and name of a variable declaration,
+// with optional array dimensions
+var globalDeclarator = regexp.MustCompile(`^(.*[\w>*&\s])\b([A-Za-z_]\w*)\s*(?:\[[^\]]*\]\s*)*$`)
+
+// nonVariableKeywords start file-scope statements that don't define a
+// variable
+var nonVariableKeywords = map[string]bool{
+	"typedef":       true,
+	"using":         true,
+	"extern":        true,
+	"friend":        true,
+	"static_assert": true,
+	"template":      true,
+	"namespace":     true,
+	"return":        true,
+}
+
+// MutableGlobalRule flags file-scope variable definitions that aren't
+// const or constexpr
+type MutableGlobalRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *MutableGlobalRule) Name() string {
+	return "bugprone"
+}
+
+func (r *MutableGlobalRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("mutable-global")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	code := codeLines(file.Lines)
+	structure := scanStructure(code)
+
+	// Declarations ending in ';' plus brace-initialized ones (int t[] = {...})
+	type candidate struct {
+		text string
+		line int
+	}
+	var candidates []candidate
+	for _, stmt := range structure.Statements {
+		if isTopLevelScope(stmt.Scope) {
+			candidates = append(candidates, candidate{stmt.Text, stmt.Line})
+		}
+	}
+	for _, b := range structure.Blocks {
+		if b.Kind == blockOther && strings.HasSuffix(b.Head, "=") && isTopLevelBlock(structure, b) {
+			candidates = append(candidates, candidate{b.Head, declarationStart(file.Lines, b.StartLine)})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].line < candidates[j].line })
+
+	for _, c := range candidates {
+		name, ok := mutableGlobalName(c.text)
+		if !ok {
+			continue
+		}
+
+		column := 1
+		if loc := regexp.MustCompile(`\b` + name + `\b`).FindStringIndex(code[c.line]); loc != nil {
+			column = loc[0] + 1
+		}
+		results = append(results, Result{
+			File:     file.Path,
+			Line:     c.line + 1,
+			Column:   column,
+			Severity: ruleConfig.Severity,
+			Rule:     "mutable-global",
+			Message:  fmt.Sprintf("Global variable '%s' is mutable; make it const or constexpr", name),
+		})
+	}
+
+	return results
+}
+
+// mutableGlobalName returns the variable defined by a file-scope
+// declaration, if it is a non-const variable definition
+func mutableGlobalName(text string) (string, bool) {
+	decl := stripDeclPrefix(text)
+	if eq := strings.Index(decl, "="); eq >= 0 {
+		decl = decl[:eq]
+	}
+	// Only the first of several declarators is considered
+	depth := 0
+	for i := 0; i < len(decl); i++ {
+		switch decl[i] {
+		case '<':
+			depth++
+		case '>':
+			depth--
+		case ',':
+			if depth == 0 {
+				decl = decl[:i]
+			}
+		}
+	}
+	decl = strings.TrimSpace(decl)
+
+	// Function pointers, macro invocations and operators are left alone
+	if decl == "" || strings.ContainsAny(decl, "(){}") {
+		return "", false
+	}
+
+	words := strings.Fields(decl)
+	if nonVariableKeywords[words[0]] {
+		return "", false
+	}
+	for _, w := range words {
+		if w == "const" || w == "constexpr" || strings.HasPrefix(w, "const*") || strings.HasPrefix(w, "const&") {
+			return "", false
+		}
+	}
+
+	m := globalDeclarator.FindStringSubmatch(decl)
+	if m == nil || strings.TrimSpace(m[1]) == "" {
+		return "", false
+	}
+
+	// Forward declarations such as "struct foo" name a type, not a variable
+	typeWords := strings.Fields(m[1])
+	switch typeWords[len(typeWords)-1] {
+	case "struct", "class", "union", "enum":
+		return "", false
+	}
+
+	return m[2], true
+}
+
+// usingNamespace matches a using-directive
+var usingNamespace = regexp.MustCompile(`\busing\s+namespace\s+([\w:]+)\s*;`)
+
+// UsingNamespaceRule flags using-directives, with a separate severity for
+// those inside function bodies, where the namespace only leaks into the
+// function
+type UsingNamespaceRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *UsingNamespaceRule) Name() string {
+	return "bugprone"
+}
+
+func (r *UsingNamespaceRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("using-namespace")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	// "none" (the default) leaves function-scope directives alone
+	functionSeverity := "none"
+	if val, ok := ruleConfig.Parameters["function_scope_severity"].(string); ok {
+		functionSeverity = val
+	}
+
+	code := codeLines(file.Lines)
+	var structure *sourceStructure
+	for i, line := range code {
+		m := usingNamespace.FindStringSubmatchIndex(line)
+		if m == nil {
+			continue
+		}
+		if structure == nil {
+			structure = scanStructure(code)
+		}
+
+		severity := ruleConfig.Severity
+		scope := "file scope"
+		depth := -1
+		for _, b := range structure.Blocks {
+			if b.StartLine > i || b.EndLine < i {
+				continue
+			}
+			if b.Kind == blockFunction {
+				severity = functionSeverity
+				scope = "function " + b.Name
+				break
+			}
+			if b.Kind == blockNamespace && b.Depth > depth {
+				depth = b.Depth
+				scope = "namespace " + b.Name
+				if b.Name == "" {
+					scope = "anonymous namespace"
+				}
+			}
+		}
+		if severity != SeverityError && severity != SeverityWarning && severity != SeverityInfo {
+			continue
+		}
+
+		results = append(results, Result{
+			File:     file.Path,
+			Line:     i + 1,
+			Column:   m[0] + 1,
+			Severity: severity,
+			Rule:     "using-namespace",
+			Message:  fmt.Sprintf("using namespace %s in %s", line[m[2]:m[3]], scope),
+		})
+	}
+
+	return results
+}
+
+// storageKeywords are declaration specifiers that aren't a return type
+var storageKeywords = map[string]bool{
+	"static":   true,
+	"inline":   true,
+	"extern":   true,
+	"register": true,
+	"const":    true,
+	"volatile": true,
+}
+
+// ImplicitIntRule flags C function definitions without a return type,
+// which legacy C treated as returning int
+type ImplicitIntRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *ImplicitIntRule) Name() string {
+	return "bugprone"
+}
+
+func (r *ImplicitIntRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("implicit-int")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	// Only C allows implicit int
+	if !file.isCSource() {
+		return results
+	}
+
+	structure := scanStructure(codeLines(file.Lines))
+	for _, fn := range structure.Functions {
+		if !fn.Definition || !isTopLevelScope(fn.Scope) {
+			continue
+		}
+
+		// Upper-case names are most likely macros expanding to a signature
+		if strings.ToUpper(fn.Name) == fn.Name {
+			continue
+		}
+
+		hasType := false
+		for _, word := range strings.Fields(fn.Prefix) {
+			if !storageKeywords[word] {
+				hasType = true
+				break
+			}
+		}
+		if hasType {
+			continue
+		}
+
+		results = append(results, Result{
+			File:     file.Path,
+			Line:     fn.Line + 1,
+			Column:   1,
+			Severity: ruleConfig.Severity,
+			Rule:     "implicit-int",
+			Message:  fmt.Sprintf("Function %s has no return type (implicit int)", fn.Name),
+		})
+	}
+
+	return results
+}
+
+// UnusedStaticRule flags static functions in C files whose name appears
+// nowhere but in their own definition and prototypes. A static function is
+// only visible within its file, so the file alone shows whether it is used.
+type UnusedStaticRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *UnusedStaticRule) Name() string {
+	return "bugprone"
+}
+
+func (r *UnusedStaticRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("unused-static")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	if !file.isCSource() {
+		return results
+	}
+
+	code := codeLines(file.Lines)
+	structure := scanStructure(code)
+	for _, fn := range structure.Functions {
+		if !fn.Definition || !isTopLevelScope(fn.Scope) {
+			continue
+		}
+		isStatic := false
+		for _, word := range strings.Fields(fn.Prefix) {
+			isStatic = isStatic || word == "static"
+		}
+		if !isStatic {
+			continue
+		}
+
+		// Prototypes mention the name once each; the definition and its
+		// body (recursive calls) don't count at all
+		uses := 0
+		name := regexp.MustCompile(`\b` + regexp.QuoteMeta(fn.Name) + `\b`)
+		for i, line := range code {
+			if i >= fn.StartLine && i <= fn.Body.EndLine {
+				continue
+			}
+			uses += len(name.FindAllStringIndex(line, -1))
+		}
+		for _, other := range structure.Functions {
+			if !other.Definition && other.Name == fn.Name {
+				uses--
+			}
+		}
+		if uses > 0 {
+			continue
+		}
+
+		column := 0
+		if loc := name.FindStringIndex(code[fn.Line]); loc != nil {
+			column = loc[0]
+		}
+		results = append(results, Result{
+			File:     file.Path,
+			Line:     fn.Line + 1,
+			Column:   column + 1,
+			Severity: ruleConfig.Severity,
+			Rule:     "unused-static",
+			Message:  fmt.Sprintf("Static function %s is never used in this file", fn.Name),
+		})
+	}
+
+	return results
+}
+
+var (
+	// valueReturn matches a return statement with an operand
+	valueReturn = regexp.MustCompile(`\breturn\b\s*[^;\s]`)
+	// lambdaHead matches the end of a lambda introducer and declarator
+	// right before its body's '{'
+	lambdaHead = regexp.MustCompile(`\]\s*(?:\([^()]*\))?\s*(?:mutable|constexpr|noexcept)?\s*(?:->[^{};]*)?\s*$`)
+)
+
+// ctorSpecifiers are the words that may precede a constructor's name
+var ctorSpecifiers = map[string]bool{
+	"explicit":  true,
+	"inline":    true,
+	"constexpr": true,
+	"virtual":   true,
+}
+
+// VoidReturnRule flags return statements with a value in functions that
+// can't return one: constructors, destructors and functions declared void
+type VoidReturnRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *VoidReturnRule) Name() string {
+	return "bugprone"
+}
+
+func (r *VoidReturnRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("void-return")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	code := codeLines(file.Lines)
+	flat := newFlatCode(code)
+	for _, fn := range scanStructure(code).Functions {
+		if !fn.Definition {
+			continue
+		}
+		kind := voidFunctionKind(fn)
+		if kind == "" {
+			continue
+		}
+
+		start := flat.lineStarts[fn.Body.StartLine] + fn.Body.StartCol
+		end := flat.lineStarts[fn.Body.EndLine] + fn.Body.EndCol
+		body := flat.text[start:end]
+
+		// Returns inside lambdas belong to the lambda
+		var lambdas [][2]int
+		for i := 1; i < len(body); i++ {
+			if body[i] != '{' || !lambdaHead.MatchString(body[:i]) {
+				continue
+			}
+			depth := 0
+			for j := i; j < len(body); j++ {
+				if body[j] == '{' {
+					depth++
+				} else if body[j] == '}' {
+					if depth--; depth == 0 {
+						lambdas = append(lambdas, [2]int{i, j})
+						break
+					}
+				}
+			}
+		}
+
+	returns:
+		for _, loc := range valueReturn.FindAllStringIndex(body, -1) {
+			for _, lambda := range lambdas {
+				if loc[0] > lambda[0] && loc[0] < lambda[1] {
+					continue returns
+				}
+			}
+			line, column := flat.position(start + loc[0])
+			results = append(results, Result{
+				File:     file.Path,
+				Line:     line + 1,
+				Column:   column + 1,
+				Severity: ruleConfig.Severity,
+				Rule:     "void-return",
+				Message:  fmt.Sprintf("%s %s returns a value", kind, fn.Name),
+			})
+		}
+	}
+
+	return results
+}
+
+// voidFunctionKind describes a function that can't return a value
+// ("Constructor", "Destructor" or "void function"), or returns "" for
+// any other function
+func voidFunctionKind(fn functionSignature) string {
+	name := strings.Join(strings.Fields(fn.Name), "")
+	parts := strings.Split(name, "::")
+	last := parts[len(parts)-1]
+	if strings.HasPrefix(last, "~") {
+		return "Destructor"
+	}
+
+	prefix := strings.Fields(fn.Prefix)
+	if len(prefix) > 0 && prefix[len(prefix)-1] == "void" {
+		return "void function"
+	}
+
+	// A constructor has no return type: either Type::Type, or a plain
+	// name inside a class body (conversion operators excepted)
+	for _, word := range prefix {
+		if !ctorSpecifiers[word] {
+			return ""
+		}
+	}
+	if len(parts) > 1 && parts[len(parts)-2] == last {
+		return "Constructor"
+	}
+	if len(parts) == 1 && (fn.Scope == "class" || fn.Scope == "struct") && !strings.HasPrefix(last, "operator") {
+		return "Constructor"
+	}
+	return ""
+}
+
+// inlineSpecifiers are specifiers that make a function definition safe to
+// include in several translation units
+var inlineSpecifiers = map[string]bool{
+	"inline":        true,
+	"__inline":      true,
+	"__inline__":    true,
+	"__forceinline": true,
+	"static":        true,
+	"constexpr":     true,
+	"consteval":     true,
+}
+
+// HeaderDefinitionRule flags non-inline function definitions in headers,
+// which break the one definition rule once the header is included by
+// more than one source file
+type HeaderDefinitionRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *HeaderDefinitionRule) Name() string {
+	return "bugprone"
+}
+
+func (r *HeaderDefinitionRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("header-definition")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	if !strings.HasSuffix(file.Path, ".h") && !strings.HasSuffix(file.Path, ".hpp") {
+		return results
+	}
+
+	structure := scanStructure(codeLines(file.Lines))
+	for _, fn := range structure.Functions {
+		// Functions defined inside a class body are implicitly inline
+		if !fn.Definition || fn.Template || !isTopLevelScope(fn.Scope) {
+			continue
+		}
+		if hasInlineSpecifier(fn.Prefix) {
+			continue
+		}
+
+		results = append(results, Result{
+			File:     file.Path,
+			Line:     fn.Line + 1,
+			Column:   1,
+			Severity: ruleConfig.Severity,
+			Rule:     "header-definition",
+			Message:  fmt.Sprintf("Function %s is defined in a header without inline; including it in several files violates the one definition rule", fn.Name),
+		})
+	}
+
+	return results
+}
+
+// hasInlineSpecifier reports whether a declaration prefix contains an
+// inline specifier, including macros such as FORCE_INLINE
+func hasInlineSpecifier(prefix string) bool {
+	for _, word := range strings.FieldsFunc(prefix, func(c rune) bool {
+		return c > 0x7f || !isIdentByte(byte(c))
+	}) {
+		if inlineSpecifiers[word] || isInlineMacro(word) {
+			return true
+		}
+	}
+	return false
+}
+
+// isInlineMacro reports whether word is an upper-case macro name with
+// INLINE as one of its underscore-separated parts (FORCE_INLINE,
+// ALWAYS_INLINE), unlike identifiers such as inline_buffer or isInlined
+func isInlineMacro(word string) bool {
+	if word != strings.ToUpper(word) {
+		return false
+	}
+	for _, part := range strings.Split(word, "_") {
+		if part == "INLINE" {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	deprecationNote = regexp.MustCompile(`\bDEPRECATED\b:?\s*(.*?)\s*(?:\*/)?$`)
+	callSite        = regexp.MustCompile(`\b([A-Za-z_]\w*)\s*\(`)
+)
+
+// DeprecatedAPIRule flags calls to functions whose declaration in a header
+// carries a "// DEPRECATED: use X" comment, either on the lines directly
+// above it or at the end of the declaration
+type DeprecatedAPIRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *DeprecatedAPIRule) Name() string {
+	return "bugprone"
+}
+
+// Check does nothing; deprecations are collected from headers and call
+// sites found across files by CheckProject
+func (r *DeprecatedAPIRule) Check(file FileInfo) []Result {
+	return nil
+}
+
+func (r *DeprecatedAPIRule) CheckProject(files []FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("deprecated-api")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	type deprecation struct {
+		path string
+		line int
+		note string
+	}
+	deprecated := make(map[string]deprecation)
+	structures := make([]*sourceStructure, len(files))
+	for i, file := range files {
+		structures[i] = scanStructure(codeLines(file.Lines))
+		if !strings.HasSuffix(file.Path, ".h") && !strings.HasSuffix(file.Path, ".hpp") {
+			continue
+		}
+		mask := file.commentMask()
+		for _, fn := range structures[i].Functions {
+			note, ok := deprecationComment(file.Lines, mask, fn)
+			if !ok {
+				continue
+			}
+			name := fn.Name[strings.LastIndex(fn.Name, ":")+1:]
+			if _, seen := deprecated[name]; !seen {
+				deprecated[name] = deprecation{file.Path, fn.Line, note}
+			}
+		}
+	}
+	if len(deprecated) == 0 {
+		return results
+	}
+
+	for i, file := range files {
+		// Declarations and definitions of the function aren't calls
+		signatures := make(map[int]bool)
+		for _, fn := range structures[i].Functions {
+			signatures[fn.Line] = true
+		}
+
+		for n, line := range codeLines(file.Lines) {
+			for _, m := range callSite.FindAllStringSubmatchIndex(line, -1) {
+				name := line[m[2]:m[3]]
+				dep, ok := deprecated[name]
+				if !ok || signatures[n] {
+					continue
+				}
+				message := fmt.Sprintf("Call to deprecated function %s (%s:%d)", name, dep.path, dep.line+1)
+				if dep.note != "" {
+					message += ": " + dep.note
+				}
+				results = append(results, Result{
+					File:     file.Path,
+					Line:     n + 1,
+					Column:   m[2] + 1,
+					Severity: ruleConfig.Severity,
+					Rule:     "deprecated-api",
+					Message:  message,
+				})
+			}
+		}
+	}
+
+	return results
+}
+
+// deprecationComment looks for a DEPRECATED comment in the comment lines
+// directly above a function declaration or on the declaration itself, and
+// returns the text following the marker
+func deprecationComment(lines []string, mask [][]bool, fn functionSignature) (string, bool) {
+	var candidates []int
+	for i := fn.StartLine; i <= fn.EndLine && i < len(lines); i++ {
+		candidates = append(candidates, i)
+	}
+	for i := fn.StartLine - 1; i >= 0 && isCommentOnly(lines[i], mask[i]); i-- {
+		candidates = append(candidates, i)
+	}
+
+	for _, i := range candidates {
+		if m := deprecationNote.FindStringSubmatch(commentText(lines[i], mask[i])); m != nil {
+			return m[1], true
+		}
+	}
+	return "", false
+}
+
+// classKeyDecl matches a forward or friend declaration of a class or struct
+var classKeyDecl = regexp.MustCompile(`^(?:template\s*<.*>\s*)?(?:friend\s+)?(class|struct)\s+([A-Za-z_]\w*)$`)
+
+// ClassKeyRule flags types declared with both the struct and class keys
+// across the run, e.g. a "struct Foo;" forward declaration of a
+// "class Foo" definition, which some compilers warn about (and MSVC
+// mangles differently)
+type ClassKeyRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *ClassKeyRule) Name() string {
+	return "bugprone"
+}
+
+// Check does nothing; declarations are compared across files by
+// CheckProject
+func (r *ClassKeyRule) Check(file FileInfo) []Result {
+	return nil
+}
+
+func (r *ClassKeyRule) CheckProject(files []FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("class-key")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	type typeDecl struct {
+		key        string
+		path       string
+		line       int
+		definition bool
+	}
+	decls := make(map[string][]typeDecl)
+	var names []string
+	add := func(name string, decl typeDecl) {
+		if len(decls[name]) == 0 {
+			names = append(names, name)
+		}
+		decls[name] = append(decls[name], decl)
+	}
+
+	for _, file := range files {
+		structure := scanStructure(codeLines(file.Lines))
+		for _, block := range structure.Blocks {
+			if (block.Kind == blockClass || block.Kind == blockStruct) && block.Name != "" && !strings.Contains(block.Name, ":") {
+				add(block.Name, typeDecl{block.Kind, file.Path, declarationStart(file.Lines, block.StartLine), true})
+			}
+		}
+		for _, stmt := range structure.Statements {
+			if m := classKeyDecl.FindStringSubmatch(stmt.Text); m != nil {
+				add(m[2], typeDecl{m[1], file.Path, stmt.Line, false})
+			}
+		}
+	}
+
+	for _, name := range names {
+		// Compare against the definition, or the first declaration seen
+		reference := decls[name][0]
+		for _, decl := range decls[name] {
+			if decl.definition {
+				reference = decl
+				break
+			}
+		}
+		for _, decl := range decls[name] {
+			if decl.key == reference.key {
+				continue
+			}
+			how := "declared"
+			if reference.definition {
+				how = "defined"
+			}
+			results = append(results, Result{
+				File:     decl.path,
+				Line:     decl.line + 1,
+				Column:   1,
+				Severity: ruleConfig.Severity,
+				Rule:     "class-key",
+				Message: fmt.Sprintf("%s is declared as %s here but %s as %s at %s:%d",
+					name, decl.key, how, reference.key, reference.path, reference.line+1),
+			})
+		}
+	}
+
+	return results
+}
+
+// catchClause matches the start of a catch clause
+var catchClause = regexp.MustCompile(`\bcatch\s*\(`)
+
+// throwKeyword matches a throw expression or rethrow
+var throwKeyword = regexp.MustCompile(`\bthrow\b`)
+
+// CatchHandlerRule flags catch clauses that swallow errors: catch (...)
+// and handlers with an empty body (comments don't count). Each check can
+// be turned off with the catch_all and empty_body parameters.
+type CatchHandlerRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *CatchHandlerRule) Name() string {
+	return "bugprone"
+}
+
+func (r *CatchHandlerRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("catch-handler")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	if !file.isCPPSource() {
+		return results
+	}
+
+	checkCatchAll, checkEmpty := true, true
+	if val, ok := ruleConfig.Parameters["catch_all"].(bool); ok {
+		checkCatchAll = val
+	}
+	if val, ok := ruleConfig.Parameters["empty_body"].(bool); ok {
+		checkEmpty = val
+	}
+
+	flat := newFlatCode(codeLines(file.Lines))
+	for _, m := range catchClause.FindAllStringIndex(flat.text, -1) {
+		open := m[1] - 1
+		close := matchingParen(flat.text, open)
+		if close < 0 {
+			continue
+		}
+		line, column := flat.position(m[0])
+		report := func(message string) {
+			results = append(results, Result{
+				File:     file.Path,
+				Line:     line + 1,
+				Column:   column + 1,
+				Severity: ruleConfig.Severity,
+				Rule:     "catch-handler",
+				Message:  message,
+			})
+		}
+
+		rest := strings.TrimLeft(flat.text[close+1:], " \t\r\n")
+		if !strings.HasPrefix(rest, "{") {
+			continue
+		}
+		body := rest[1:]
+		depth := 1
+		for i := 0; i < len(body); i++ {
+			if body[i] == '{' {
+				depth++
+			} else if body[i] == '}' {
+				if depth--; depth == 0 {
+					body = body[:i]
+					break
+				}
+			}
+		}
+
+		// A catch-all that rethrows, e.g. after cleaning up, is fine
+		if checkCatchAll && strings.TrimSpace(flat.text[open+1:close]) == "..." && !throwKeyword.MatchString(body) {
+			report("catch (...) swallows every exception; catch specific types")
+		}
+		if checkEmpty && strings.TrimSpace(body) == "" {
+			report("Empty catch block hides the error; handle, log or rethrow it")
+		}
+	}
+
+	return results
+}
+
+// ioToken splits a banned I/O pattern into identifiers and punctuation
+var ioToken = regexp.MustCompile(`[A-Za-z_]\w*|::|\S`)
+
+// ioPatternExpr turns a banned I/O pattern such as "fprintf(stdout" into a
+// regular expression allowing whitespace between its tokens
+func ioPatternExpr(pattern string) string {
+	tokens := ioToken.FindAllString(pattern, -1)
+	if len(tokens) == 0 {
+		return ""
+	}
+	parts := make([]string, len(tokens))
+	for i, token := range tokens {
+		parts[i] = regexp.QuoteMeta(token)
+	}
+	expr := strings.Join(parts, `\s*`)
+	if isIdentByte(tokens[0][0]) {
+		expr = `\b` + expr
+	}
+	if last := tokens[len(tokens)-1]; isIdentByte(last[len(last)-1]) {
+		expr += `\b`
+	}
+	return expr
+}
+
+// LibraryIORule flags writes to the standard streams (printf, std::cout,
+// ...) in library code, which should report through return values or a
+// logging interface instead. Only files matching library_paths are
+// checked, except those matching exempt_files.
+type LibraryIORule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *LibraryIORule) Name() string {
+	return "bugprone"
+}
+
+func (r *LibraryIORule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("library-io")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	path := filepath.ToSlash(file.Path)
+	libraries := ruleConfig.stringListParam("library_paths", nil)
+	if !matchesAnyGlob(path, libraries, "(?:/|$)") {
+		return results
+	}
+	exempt := ruleConfig.stringListParam("exempt_files", []string{"main.c", "main.cc", "main.cpp"})
+	if matchesAnyGlob(path, exempt, "$") {
+		return results
+	}
+
+	var banned []*regexp.Regexp
+	var names []string
+	for _, pattern := range ruleConfig.stringListParam("banned_io", []string{"printf", "fprintf(stdout", "std::cout", "std::cerr"}) {
+		if re, err := regexp.Compile(ioPatternExpr(pattern)); err == nil && pattern != "" {
+			banned = append(banned, re)
+			names = append(names, pattern)
+		}
+	}
+
+	for i, line := range codeLines(file.Lines) {
+		for k, re := range banned {
+			for _, loc := range re.FindAllStringIndex(line, -1) {
+				results = append(results, Result{
+					File:     file.Path,
+					Line:     i + 1,
+					Column:   loc[0] + 1,
+					Severity: ruleConfig.Severity,
+					Rule:     "library-io",
+					Message:  fmt.Sprintf("Library code writes to a standard stream ('%s'); return errors or use a logging interface instead", names[k]),
+				})
+			}
+		}
+	}
+
+	return results
+}
+
+var (
+	// overrideSpecifier matches the virt-specifiers that mark an override
+	overrideSpecifier = regexp.MustCompile(`\b(?:override|final)\b`)
+	// pureSpecifier matches the end of a pure virtual declaration
+	pureSpecifier = regexp.MustCompile(`=\s*0$`)
+)
+
+// MissingOverrideRule flags methods declared virtual in a class with a base
+// list but not marked override or final. Without type information the rule
+// can't tell an override from a new virtual method, so it only looks at
+// methods repeating the virtual keyword, which in a derived class usually
+// means an override written before C++11. Destructors and pure virtual
+// methods are skipped.
+type MissingOverrideRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *MissingOverrideRule) Name() string {
+	return "bugprone"
+}
+
+func (r *MissingOverrideRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("missing-override")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	code := codeLines(file.Lines)
+	structure := scanStructure(code)
+	for _, fn := range structure.Functions {
+		if fn.Scope != "class" && fn.Scope != "struct" || strings.Contains(fn.Name, "~") {
+			continue
+		}
+		isVirtual := false
+		for _, word := range strings.Fields(fn.Prefix) {
+			isVirtual = isVirtual || word == "virtual"
+		}
+		if !isVirtual {
+			continue
+		}
+
+		class := enclosingClass(structure.Blocks, fn.Line)
+		if class == nil || !strings.Contains(strings.ReplaceAll(class.Head, "::", ""), ":") {
+			continue
+		}
+
+		tail := signatureTailText(code, fn)
+		if overrideSpecifier.MatchString(tail) || pureSpecifier.MatchString(tail) {
+			continue
+		}
+
+		results = append(results, Result{
+			File:     file.Path,
+			Line:     fn.Line + 1,
+			Column:   1,
+			Severity: ruleConfig.Severity,
+			Rule:     "missing-override",
+			Message:  fmt.Sprintf("Virtual method %s of derived class %s is not marked override or final", fn.Name, class.Name),
+		})
+	}
+
+	return results
+}
+
+// enclosingClass returns the innermost class or struct block containing
+// line, or nil
+func enclosingClass(blocks []codeBlock, line int) *codeBlock {
+	var class *codeBlock
+	for i, block := range blocks {
+		if block.Kind != "class" && block.Kind != "struct" {
+			continue
+		}
+		if block.StartLine <= line && line <= block.EndLine && (class == nil || block.Depth > class.Depth) {
+			class = &blocks[i]
+		}
+	}
+	return class
+}
+
+// signatureTailText returns the code between a function's closing
+// parenthesis and its body or terminating semicolon, e.g. " const override"
+func signatureTailText(code []string, fn functionSignature) string {
+	line := code[fn.EndLine]
+	tail := line[strings.LastIndex(line, ")")+1:]
+	for i := fn.EndLine + 1; !strings.ContainsAny(tail, ";{") && i < len(code); i++ {
+		tail += " " + code[i]
+	}
+	if end := strings.IndexAny(tail, ";{"); end >= 0 {
+		tail = tail[:end]
+	}
+	return strings.TrimSpace(tail)
+}
+
+// ConstantConditionRule flags if and while conditions that are a literal
+// constant (if (0), while (true)) or a macro defined in the file as one,
+// which are usually debugging leftovers. A while loop on a true constant
+// is allowed when a comment on its line or the line above contains
+// loop_annotation, and do { ... } while (0) is always allowed.
+type ConstantConditionRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *ConstantConditionRule) Name() string {
+	return "bugprone"
+}
+
+func (r *ConstantConditionRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("constant-condition")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	annotation := "infinite loop"
+	if val, ok := ruleConfig.Parameters["loop_annotation"].(string); ok {
+		annotation = val
+	}
+	annotation = strings.ToLower(annotation)
+
+	code := codeLines(file.Lines)
+
+	// Macros defined as a literal, e.g. #define DEBUG 1
+	macros := make(map[string]string)
+	for _, line := range code {
+		if m := objectMacro.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			if value := unwrapCondition(m[2]); isConstantOperand(value) {
+				macros[m[1]] = value
+			}
+		}
+	}
+
+	mask := file.commentMask()
+	annotated := func(line int) bool {
+		if annotation == "" {
+			return false
+		}
+		for i := line; i >= 0 && i >= line-1; i-- {
+			if strings.Contains(strings.ToLower(commentText(file.Lines[i], mask[i])), annotation) {
+				return true
+			}
+		}
+		return false
+	}
+
+	flat := newFlatCode(code)
+	for _, m := range conditionKeyword.FindAllStringSubmatchIndex(flat.text, -1) {
+		// Preprocessor #if conditions are not code
+		line, column := flat.position(m[0])
+		if strings.HasPrefix(strings.TrimSpace(file.Lines[line]), "#") {
+			continue
+		}
+		open := m[1] - 1
+		close := matchingParen(flat.text, open)
+		if close < 0 {
+			continue
+		}
+
+		text := unwrapCondition(flat.text[open+1 : close])
+		condition, negated := text, false
+		for {
+			condition = unwrapCondition(condition)
+			if !strings.HasPrefix(condition, "!") {
+				break
+			}
+			negated = !negated
+			condition = condition[1:]
+		}
+
+		value, macro := condition, ""
+		if v, ok := macros[condition]; ok {
+			value, macro = v, condition
+		}
+		if !isConstantOperand(value) {
+			continue
+		}
+
+		keyword := flat.text[m[2]:m[3]]
+		message := fmt.Sprintf("%s condition %s is constant; remove the leftover", keyword, text)
+		if macro != "" {
+			message = fmt.Sprintf("%s condition %s is constant (%s is defined as %s); remove the leftover", keyword, text, macro, value)
+		}
+		if keyword == "while" {
+			truthy := isTruthyConstant(value) != negated
+			if prev := flat.prevNonSpace(m[0]); !truthy && prev >= 0 && flat.text[prev] == '}' {
+				// do { ... } while (0)
+				continue
+			}
+			if truthy && annotated(line) {
+				continue
+			}
+			if truthy && annotation != "" {
+				message += fmt.Sprintf(" or mark an intentional infinite loop with a %q comment", annotation)
+			}
+		}
+
+		results = append(results, Result{
+			File:     file.Path,
+			Line:     line + 1,
+			Column:   column + 1,
+			Severity: ruleConfig.Severity,
+			Rule:     "constant-condition",
+			Message:  message,
+		})
+	}
+
+	return results
+}
+
+// unwrapCondition trims s and removes parentheses enclosing all of it
+func unwrapCondition(s string) string {
+	s = strings.TrimSpace(s)
+	for strings.HasPrefix(s, "(") && matchingParen(s, 0) == len(s)-1 {
+		s = strings.TrimSpace(s[1 : len(s)-1])
+	}
+	return s
+}
+
+// isConstantOperand reports whether s is a boolean or numeric literal
+func isConstantOperand(s string) bool {
+	if s == "true" || s == "false" {
+		return true
+	}
+	return literalValue.MatchString(s) && !strings.ContainsAny(s, `"'`)
+}
+
+// isTruthyConstant reports whether a constant operand is true or non-zero
+func isTruthyConstant(s string) bool {
+	if s == "true" || s == "false" {
+		return s == "true"
+	}
+	s = strings.TrimLeft(s, "+-")
+
+	// Hex digits include e and f, so only the integer suffixes apply
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		return strings.Trim(strings.TrimRight(s[2:], "uUlL"), "0'") != ""
+	}
+	s = strings.TrimRight(s, "uUlLfF")
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.Trim(s, "0.'") != ""
+}
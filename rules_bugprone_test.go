@@ -0,0 +1,707 @@
+package codelint
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestEmptyStatementRule(t *testing.T) {
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &EmptyStatementRule{rulesConfig: rc} }, "empty-statement", []ruleCase{
+		{
+			name:    "clean",
+			content: "void f(int x) {\n    if (x) {\n        g();\n    }\n}\n",
+		},
+		{
+			name:    "stray semicolon after if",
+			content: "void f(int x) {\n    if (x);\n}\n",
+			want:    []string{"2:11"},
+		},
+		{
+			name:    "stray semicolon after for and while",
+			content: "void f(int x) {\n    for (;;);\n    while (x);\n}\n",
+			want:    []string{"2:13", "3:14"},
+		},
+		{
+			name:    "empty statement line",
+			content: "void f(void) {\n    ;\n}\n",
+			want:    []string{"2:5"},
+		},
+		{
+			name:    "marked intentional",
+			content: "void f(int x) {\n    while (x--); // intentional\n}\n",
+		},
+		{
+			name:    "do-while",
+			content: "void f(int x) {\n    do {\n        g();\n    } while (x);\n    do {\n        g();\n    }\n    while (x);\n}\n",
+		},
+		{
+			name:    "string literals",
+			content: "void f(void) {\n    puts(\";\");\n    const char *s = \"if (x);\";\n}\n",
+		},
+	})
+}
+
+func TestMacroHygieneRule(t *testing.T) {
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &MacroHygieneRule{rulesConfig: rc} }, "macro-hygiene", []ruleCase{
+		{
+			name:    "parenthesized parameters",
+			content: "#define SQUARE(x) ((x) * (x))\n",
+		},
+		{
+			name:    "unparenthesized parameter",
+			content: "#define SQUARE(x) x * x\n",
+			want:    []string{"1:1"},
+		},
+		{
+			name:    "one result per parameter",
+			content: "#define ADD(a, b) a + b\n",
+			want:    []string{"1:1", "1:1"},
+		},
+		{
+			name:    "function call arguments",
+			content: "#define LOG(msg, n) printf(msg, n)\n",
+		},
+		{
+			name:    "stringizing and token pasting",
+			content: "#define NAME(x) #x\n#define CAT(a, b) a##b\n",
+		},
+		{
+			name:    "continuation lines",
+			content: "\n#define MAX(a, b) \\\n    ((a) > b ? (a) : (b))\n",
+			want:    []string{"2:1"},
+		},
+		{
+			name:    "object-like macro",
+			content: "#define LIMIT 10 + 1\n",
+		},
+		{
+			name:    "disabled",
+			content: "#define SQUARE(x) x * x\n",
+			config:  `{"rules": {"macro-hygiene": {"enabled": false}}}`,
+		},
+	})
+}
+
+func TestLibraryIORule(t *testing.T) {
+	const library = `{"rules": {"library-io": {"parameters": {"library_paths": ["lib"]}}}}`
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &LibraryIORule{rulesConfig: rc} }, "library-io", []ruleCase{
+		{
+			name:    "inert until configured",
+			path:    "lib/log.c",
+			content: "void f(void) {\n    printf(\"x\");\n}\n",
+		},
+		{
+			name:    "library code",
+			path:    "lib/log.c",
+			content: "void f(void) {\n    printf(\"x\");\n}\n",
+			config:  library,
+			want:    []string{"2:5"},
+		},
+		{
+			name:    "names in strings and comments",
+			path:    "lib/log.cpp",
+			content: "const char *a = \"printf(x)\";\nconst char *b = R\"(std::cout << x)\";\n// printf(y)\n",
+			config:  library,
+		},
+		{
+			name:    "exempt file",
+			path:    "lib/main.c",
+			content: "int main(void) {\n    printf(\"x\");\n}\n",
+			config:  library,
+		},
+		{
+			name:    "outside the library",
+			path:    "tools/cli.c",
+			content: "void f(void) {\n    printf(\"x\");\n}\n",
+			config:  library,
+		},
+		{
+			name: "standard streams",
+			path: "lib/net/socket.cc",
+			content: "void f() {\n" +
+				"    std::cout << x;\n" + // 2
+				"    std :: cerr << y;\n" + // 3
+				"    fprintf (stdout, \"x\");\n" + // 4
+				"    fprintf(stderr, \"x\");\n" + // 5: stderr isn't banned by default
+				"    snprintf(buf, n, \"x\"); printf_like(1);\n" + // 6: other names
+				"    std::clog << z; printf(\"a\"); printf(\"b\");\n" + // 7
+				"}\n",
+			config: library,
+			want:   []string{"2:5", "3:5", "4:5", "7:21", "7:34"},
+		},
+		{
+			name:    "allowed main.cc",
+			path:    "lib/main.cc",
+			content: "int main() {\n    std::cout << 1;\n}\n",
+			config:  library,
+		},
+		{
+			name:    "configured banned set and exemptions",
+			path:    "lib/net/socket.cc",
+			content: "void f() {\n    std::cout << x;\n    std::clog << y;\n    puts(\"z\");\n}\n",
+			config:  `{"rules": {"library-io": {"parameters": {"library_paths": ["lib/net"], "banned_io": ["std::clog", "puts"]}}}}`,
+			want:    []string{"3:5", "4:5"},
+		},
+		{
+			name:    "configured exempt files",
+			path:    "lib/debug/dump.c",
+			content: "void dump(void) {\n    printf(\"x\");\n}\n",
+			config:  `{"rules": {"library-io": {"parameters": {"library_paths": ["lib"], "exempt_files": ["lib/debug/*"]}}}}`,
+		},
+	})
+}
+
+func TestMutableGlobalRule(t *testing.T) {
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &MutableGlobalRule{rulesConfig: rc} }, "mutable-global", []ruleCase{
+		{
+			name:    "mutable globals",
+			content: "int counter = 0;\nstatic char buffer[256];\nunsigned long total;\n",
+			want:    []string{"1:5", "2:13", "3:15"},
+		},
+		{
+			name:    "const globals",
+			content: "const int limit = 10;\nstatic const char *names[] = {\"a\"};\n",
+		},
+		{
+			name:    "constexpr",
+			path:    "a.cpp",
+			content: "constexpr int kLimit = 10;\nnamespace app {\nconstexpr double kRatio = 0.5;\n}\n",
+		},
+		{
+			name:    "brace-initialized table",
+			content: "int table[] = {\n    1, 2, 3,\n};\n",
+			want:    []string{"1:5"},
+		},
+		{
+			name:    "locals and members",
+			path:    "a.cpp",
+			content: "void f(void) {\n    int local = 0;\n    static int calls;\n}\nstruct S {\n    int member;\n};\n",
+		},
+		{
+			name:    "declarations that define no variable",
+			content: "extern int shared;\ntypedef int handle_t;\nint f(int x);\nvoid (*callback)(int);\n",
+		},
+	})
+}
+
+func TestUsingNamespaceRule(t *testing.T) {
+	const functionWarning = `{"rules": {"using-namespace": {"parameters": {"function_scope_severity": "warning"}}}}`
+	content := "using namespace std;\nnamespace app {\nusing namespace detail;\n}\nvoid f() {\n    using namespace std::chrono;\n}\n"
+	tests := []struct {
+		name   string
+		config string
+		want   []string
+	}{
+		{
+			name: "function scope ignored by default",
+			want: []string{
+				"1:1 info using namespace std in file scope",
+				"3:1 info using namespace detail in namespace app",
+			},
+		},
+		{
+			name:   "function scope severity",
+			config: functionWarning,
+			want: []string{
+				"1:1 info using namespace std in file scope",
+				"3:1 info using namespace detail in namespace app",
+				"6:5 warning using namespace std::chrono in function f",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := &UsingNamespaceRule{rulesConfig: testRulesConfig(t, tt.config)}
+			var got []string
+			for _, r := range rule.Check(NewFileInfo("a.cpp", []byte(content))) {
+				got = append(got, fmt.Sprintf("%d:%d %s %s", r.Line, r.Column, r.Severity, r.Message))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImplicitIntRule(t *testing.T) {
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &ImplicitIntRule{rulesConfig: rc} }, "implicit-int", []ruleCase{
+		{
+			name:    "implicit return type",
+			content: "main(void) {\n    return 0;\n}\nstatic helper(int x) {\n    return x;\n}\n",
+			want:    []string{"1:1", "4:1"},
+		},
+		{
+			name:    "explicit return types",
+			content: "int main(void) {\n    return 0;\n}\nstatic unsigned long helper(int x) {\n    return x;\n}\n",
+		},
+		{
+			name:    "macro signature",
+			content: "DEFINE_HANDLER(on_read) {\n    return 0;\n}\n",
+		},
+		{
+			name:    "prototypes only",
+			content: "int f(void);\nvoid g(int x);\n",
+		},
+		{
+			name:    "C++ files are skipped",
+			path:    "a.cpp",
+			content: "main() {\n    return 0;\n}\n",
+		},
+	})
+}
+
+func TestHeaderDefinitionRule(t *testing.T) {
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &HeaderDefinitionRule{rulesConfig: rc} }, "header-definition", []ruleCase{
+		{
+			name:    "bare definition",
+			path:    "a.h",
+			content: "int add(int a, int b) {\n    return a + b;\n}\n",
+			want:    []string{"1:1"},
+		},
+		{
+			name:    "inline, static and constexpr",
+			path:    "a.hpp",
+			content: "inline int f() {\n    return 1;\n}\nstatic int g() {\n    return 2;\n}\nconstexpr int h() {\n    return 3;\n}\n",
+		},
+		{
+			name:    "inline macros",
+			path:    "a.h",
+			content: "FORCE_INLINE int f(void) {\n    return 1;\n}\nstatic ALWAYS_INLINE int g(void) {\n    return 2;\n}\n",
+		},
+		{
+			name:    "identifiers containing inline",
+			path:    "a.h",
+			content: "INLINED int f(void) {\n    return 1;\n}\n",
+			want:    []string{"1:1"},
+		},
+		{
+			name:    "class members and templates",
+			path:    "a.hpp",
+			content: "class S {\n    int get() {\n        return x;\n    }\n    int x;\n};\ntemplate <typename T>\nT twice(T v) {\n    return v + v;\n}\n",
+		},
+		{
+			name:    "prototypes",
+			path:    "a.h",
+			content: "int add(int a, int b);\n",
+		},
+		{
+			name:    "source files",
+			path:    "a.c",
+			content: "int add(int a, int b) {\n    return a + b;\n}\n",
+		},
+	})
+}
+
+func TestDeprecatedAPIRule(t *testing.T) {
+	const header = "// DEPRECATED: use open_v2\nint open_file(const char *path);\nint open_v2(const char *path);\n"
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{
+			name: "call site",
+			files: map[string]string{
+				"io.h": header,
+				"a.c":  "#include \"io.h\"\nvoid f(void) {\n    open_file(\"x\");\n    open_v2(\"y\");\n}\n",
+			},
+			want: []string{"a.c:3:5"},
+		},
+		{
+			name: "trailing comment on the declaration",
+			files: map[string]string{
+				"io.h": "int open_file(const char *path); /* DEPRECATED */\n",
+				"a.c":  "void f(void) {\n    if (open_file(\"x\")) g();\n}\n",
+			},
+			want: []string{"a.c:2:9"},
+		},
+		{
+			name: "definition is not a call",
+			files: map[string]string{
+				"io.h": header,
+				"io.c": "int open_file(const char *path) {\n    return open_v2(path);\n}\n",
+			},
+		},
+		{
+			name: "comments and strings",
+			files: map[string]string{
+				"io.h": header,
+				"a.c":  "// open_file(x)\nconst char *s = \"open_file(x)\";\n",
+			},
+		},
+		{
+			name: "annotations in sources ignored",
+			files: map[string]string{
+				"a.c": "// DEPRECATED: use g\nint f(void);\nvoid h(void) {\n    f();\n}\n",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rule := &DeprecatedAPIRule{rulesConfig: defaultRulesConfig()}
+			got := ruleLines(rule.CheckProject(projectFiles(tc.files)), "deprecated-api")
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("calls at %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	rule := &DeprecatedAPIRule{rulesConfig: defaultRulesConfig()}
+	results := rule.CheckProject(projectFiles(map[string]string{"io.h": header, "a.c": "int x = open_file(\"x\");\n"}))
+	if want := "Call to deprecated function open_file (io.h:2): use open_v2"; len(results) != 1 || results[0].Message != want {
+		t.Errorf("results %v, want one with message %q", results, want)
+	}
+}
+
+func TestClassKeyRule(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{
+			name: "consistent",
+			files: map[string]string{
+				"fwd.h": "struct Point;\nclass Widget;\n",
+				"a.hpp": "struct Point {\n    int x;\n};\nclass Widget {\n    int y;\n};\n",
+			},
+		},
+		{
+			name: "struct declaration of a class",
+			files: map[string]string{
+				"fwd.h": "struct Widget;\n",
+				"a.hpp": "class Widget {\n    int y;\n};\n",
+			},
+			want: []string{"fwd.h:1:1"},
+		},
+		{
+			name: "friend and template declarations",
+			files: map[string]string{
+				"a.hpp": "template <typename T>\nstruct Box {\n    T v;\n};\nclass Owner {\n    friend class Box<int>;\n    friend class Point;\n};\n",
+				"b.hpp": "template <typename T> class Box;\nstruct Point {\n    int x;\n};\n",
+			},
+			want: []string{"b.hpp:1:1", "a.hpp:7:1"},
+		},
+		{
+			name: "declarations only",
+			files: map[string]string{
+				"a.h": "class Widget;\n",
+				"b.h": "struct Widget;\n",
+			},
+			want: []string{"b.h:1:1"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rule := &ClassKeyRule{rulesConfig: defaultRulesConfig()}
+			got := ruleLines(rule.CheckProject(projectFiles(tc.files)), "class-key")
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("conflicts at %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	rule := &ClassKeyRule{rulesConfig: defaultRulesConfig()}
+	results := rule.CheckProject(projectFiles(map[string]string{"fwd.h": "struct Widget;\n", "a.hpp": "// a\nclass Widget {\n    int y;\n};\n"}))
+	if want := "Widget is declared as struct here but defined as class at a.hpp:2"; len(results) != 1 || results[0].Message != want {
+		t.Errorf("results %v, want one with message %q", results, want)
+	}
+}
+
+func TestCatchHandlerRule(t *testing.T) {
+	const handlers = "void f() {\n" +
+		"    try {\n        g();\n    }\n" +
+		"    catch (const std::exception &e) {\n        log(e.what());\n    }\n" + // 5: proper handler
+		"    catch (...) {\n        cleanup();\n    }\n" + // 8: catch-all
+		"    try { g(); } catch (int) { /* ignored */ }\n" + // 11: empty body
+		"    try { g(); } catch (...) {\n        cleanup();\n        throw;\n    }\n" + // 12: rethrows
+		"}\n"
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &CatchHandlerRule{rulesConfig: rc} }, "catch-handler", []ruleCase{
+		{
+			name:    "handlers",
+			path:    "a.cpp",
+			content: handlers,
+			want:    []string{"8:5", "11:18"},
+		},
+		{
+			name:    "empty catch-all",
+			path:    "a.cpp",
+			content: "void f() {\n    try { g(); } catch (...) {}\n}\n",
+			want:    []string{"2:18", "2:18"},
+		},
+		{
+			name:    "catch-all check off",
+			path:    "a.cpp",
+			content: handlers,
+			config:  `{"rules": {"catch-handler": {"parameters": {"catch_all": false}}}}`,
+			want:    []string{"11:18"},
+		},
+		{
+			name:    "empty body check off",
+			path:    "a.cpp",
+			content: handlers,
+			config:  `{"rules": {"catch-handler": {"parameters": {"empty_body": false}}}}`,
+			want:    []string{"8:5"},
+		},
+		{
+			name:    "C files",
+			content: "void f(void) {\n    catch (...) {}\n}\n",
+		},
+	})
+}
+
+func TestUnusedStaticRule(t *testing.T) {
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &UnusedStaticRule{rulesConfig: rc} }, "unused-static", []ruleCase{
+		{
+			name:    "used",
+			content: "static int helper(int x) {\n    return x * 2;\n}\nint api(int x) {\n    return helper(x);\n}\n",
+		},
+		{
+			name:    "unused",
+			content: "static int helper(int x) {\n    return x * 2;\n}\nint api(int x) {\n    return x;\n}\n",
+			want:    []string{"1:12"},
+		},
+		{
+			name:    "prototype only",
+			content: "static void walk(int n);\nstatic void walk(int n) {\n    if (n) walk(n - 1);\n}\n",
+			want:    []string{"2:13"},
+		},
+		{
+			name:    "used through a function pointer",
+			content: "static void on_exit(void) {\n}\nvoid setup(void) {\n    atexit(on_exit);\n}\n",
+		},
+		{
+			name:    "name in a comment",
+			content: "static void helper(void) {\n}\n// helper() is kept for later\n",
+			want:    []string{"1:13"},
+		},
+		{
+			name:    "not static",
+			content: "int helper(int x) {\n    return x;\n}\n",
+		},
+		{
+			name:    "C++ files",
+			path:    "a.cc",
+			content: "static int helper(int x) {\n    return x;\n}\n",
+		},
+	})
+}
+
+func TestVoidReturnRule(t *testing.T) {
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &VoidReturnRule{rulesConfig: rc} }, "void-return", []ruleCase{
+		{
+			name:    "void function returning a value",
+			content: "void f(int x) {\n    if (x)\n        return x;\n    return;\n}\n",
+			want:    []string{"3:9"},
+		},
+		{
+			name:    "normal function",
+			content: "int f(int x) {\n    return x;\n}\n",
+		},
+		{
+			name:    "static void function",
+			content: "static void f(void) {\n    return g();\n}\n",
+			want:    []string{"2:5"},
+		},
+		{
+			name:    "void pointer function",
+			content: "void *f(void) {\n    return 0;\n}\n",
+		},
+		{
+			name:    "constructor",
+			path:    "a.cpp",
+			content: "Widget::Widget() {\n    return 1;\n}\n",
+			want:    []string{"2:5"},
+		},
+		{
+			name:    "destructor",
+			path:    "a.cpp",
+			content: "Widget::~Widget() {\n    return;\n}\nWidget::~Widget() { return 0; }\n",
+			want:    []string{"4:21"},
+		},
+		{
+			name:    "constructor in class body",
+			path:    "a.cpp",
+			content: "class Widget {\n    explicit Widget(int n) {\n        return n;\n    }\n    operator bool() {\n        return true;\n    }\n};\n",
+			want:    []string{"3:9"},
+		},
+		{
+			name:    "lambda",
+			path:    "a.cpp",
+			content: "void f() {\n    auto g = [](int x) { return x * 2; };\n    g(1);\n}\n",
+		},
+		{
+			name:    "commented out",
+			content: "void f(void) {\n    // return 1;\n}\n",
+		},
+		{
+			name:    "disabled",
+			content: "void f(void) {\n    return 1;\n}\n",
+			config:  `{"rules": {"void-return": {"enabled": false}}}`,
+		},
+	})
+
+	rule := &VoidReturnRule{rulesConfig: defaultRulesConfig()}
+	results := rule.Check(NewFileInfo("a.cpp", []byte("Widget::~Widget() {\n    return 0;\n}\n")))
+	if want := "Destructor Widget::~Widget returns a value"; len(results) != 1 || results[0].Message != want {
+		t.Errorf("results %v, want one with message %q", results, want)
+	}
+}
+
+func TestMissingOverrideRule(t *testing.T) {
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &MissingOverrideRule{rulesConfig: rc} }, "missing-override", []ruleCase{
+		{
+			name: "derived class",
+			path: "a.h",
+			content: "class Derived : public Base {\n" +
+				"public:\n" +
+				"    virtual void draw();\n" + // 3: unmarked
+				"    virtual void move() override;\n" +
+				"    virtual int size() const final;\n" +
+				"    virtual void reset() = 0;\n" +
+				"    virtual ~Derived();\n" +
+				"    void plain();\n" +
+				"    virtual bool visible() const\n        override;\n" +
+				"    virtual void paint() {\n        draw();\n    }\n" + // 11: unmarked definition
+				"};\n",
+			want: []string{"3:1", "11:1"},
+		},
+		{
+			name:    "class without a base",
+			path:    "a.h",
+			content: "class Base {\npublic:\n    virtual void draw();\n};\n",
+		},
+		{
+			name:    "qualified name in the head",
+			path:    "a.h",
+			content: "class ns::Widget {\n    virtual void draw();\n};\n",
+		},
+		{
+			name:    "struct base",
+			path:    "a.h",
+			content: "struct Handler : Listener {\n    virtual void on_event(int id);\n};\n",
+			want:    []string{"2:1"},
+		},
+		{
+			name:    "disabled",
+			path:    "a.h",
+			content: "class Derived : public Base {\n    virtual void draw();\n};\n",
+			config:  `{"rules": {"missing-override": {"enabled": false}}}`,
+		},
+	})
+
+	rule := &MissingOverrideRule{rulesConfig: defaultRulesConfig()}
+	results := rule.Check(NewFileInfo("a.h", []byte("class Derived : public Base {\n    virtual void draw();\n};\n")))
+	want := "Virtual method draw of derived class Derived is not marked override or final"
+	if len(results) != 1 || results[0].Message != want || results[0].Severity != SeverityInfo {
+		t.Errorf("results %v, want one info result with message %q", results, want)
+	}
+}
+
+func TestIsTruthyConstant(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"true", true},
+		{"false", false},
+		{"0", false},
+		{"1", true},
+		{"-1", true},
+		{"0u", false},
+		{"0.0", false},
+		{"0.0f", false},
+		{"0e5", false},
+		{"1e-3", true},
+		{"0x0", false},
+		{"0x0UL", false},
+		{"0xF", true},
+		{"0xE", true},
+		{"0x1e", true},
+		{"0'000", false},
+	}
+	for _, tc := range tests {
+		if got := isTruthyConstant(tc.value); got != tc.want {
+			t.Errorf("isTruthyConstant(%q) = %v, want %v", tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestConstantConditionRule(t *testing.T) {
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &ConstantConditionRule{rulesConfig: rc} }, "constant-condition", []ruleCase{
+		{
+			name:    "if (0)",
+			content: "void f(void) {\n    if (0) {\n        g();\n    }\n}\n",
+			want:    []string{"2:5"},
+		},
+		{
+			name:    "normal condition",
+			content: "void f(int x) {\n    if (x > 0)\n        g();\n    while (x--)\n        g();\n}\n",
+		},
+		{
+			name: "literal forms",
+			content: "void f(void) {\n" +
+				"    if (true) g();\n" + // 2
+				"    if ((1)) g();\n" + // 3
+				"    if (!0) g();\n" + // 4
+				"    if (0x0) g();\n" + // 5
+				"    if (0x1e) g();\n" + // 6
+				"    if (1 + x) g();\n" + // 7: not a literal
+				"    if (flag) g();\n" + // 8
+				"}\n",
+			want: []string{"2:5", "3:5", "4:5", "5:5", "6:5"},
+		},
+		{
+			name:    "macro defined as a literal",
+			content: "#define DEBUG 1\n#define LIMIT (n + 1)\nvoid f(void) {\n    if (DEBUG) g();\n    if (LIMIT) g();\n}\n",
+			want:    []string{"4:5"},
+		},
+		{
+			name:    "unannotated infinite loop",
+			content: "void f(void) {\n    while (true) {\n        g();\n    }\n}\n",
+			want:    []string{"2:5"},
+		},
+		{
+			name:    "annotated infinite loop",
+			content: "void f(void) {\n    // Infinite loop: serve forever\n    while (true) {\n        g();\n    }\n    while (1) { /* infinite loop */\n        g();\n    }\n}\n",
+		},
+		{
+			name:    "annotation doesn't cover while (0)",
+			content: "void f(void) {\n    while (0) { // infinite loop\n        g();\n    }\n}\n",
+			want:    []string{"2:5"},
+		},
+		{
+			name:    "annotation in a string",
+			content: "void f(void) {\n    log(\"infinite loop\"); while (1) {\n        g();\n    }\n}\n",
+			want:    []string{"2:27"},
+		},
+		{
+			name:    "custom annotation",
+			content: "void f(void) {\n    while (1) { // NOLINT(loop)\n        g();\n    }\n    while (1) { // infinite loop\n        g();\n    }\n}\n",
+			config:  `{"rules": {"constant-condition": {"parameters": {"loop_annotation": "nolint(loop)"}}}}`,
+			want:    []string{"5:5"},
+		},
+		{
+			name:    "do-while (0)",
+			content: "#define SWAP(a, b) do { int t = a; a = b; b = t; } while (0)\nvoid f(void) {\n    do {\n        g();\n    } while (0);\n}\n",
+		},
+		{
+			name:    "preprocessor conditions",
+			content: "#if (1)\nint x;\n#endif\n",
+		},
+		{
+			name:    "commented out",
+			content: "void f(void) {\n    // if (0) g();\n}\n",
+		},
+	})
+
+	rule := &ConstantConditionRule{rulesConfig: defaultRulesConfig()}
+	results := rule.Check(NewFileInfo("test.c", []byte("#define DEBUG 1\nvoid f(void) {\n    while (DEBUG) g();\n}\n")))
+	want := `while condition DEBUG is constant (DEBUG is defined as 1); remove the leftover or mark an intentional infinite loop with a "infinite loop" comment`
+	if len(results) != 1 || results[0].Message != want {
+		t.Errorf("results %v, want one with message %q", results, want)
+	}
+}
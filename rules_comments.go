@@ -0,0 +1,417 @@
+package codelint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// todoMarker matches TODO/FIXME markers
+var todoMarker = regexp.MustCompile(`\b(TODO|FIXME)\b`)
+
+// todoMatch is a TODO/FIXME marker found in a comment
+type todoMatch struct {
+	// Line and Column are 0-based
+	Line   int
+	Column int
+	// Text is the comment text following the marker
+	Text string
+}
+
+// findTodos returns the TODO/FIXME markers that appear inside comments
+func findTodos(lines []string, mask [][]bool) []todoMatch {
+	var todos []todoMatch
+	for i, line := range lines {
+		for _, loc := range todoMarker.FindAllStringIndex(line, -1) {
+			if mask[i][loc[0]] {
+				todos = append(todos, todoMatch{Line: i, Column: loc[0], Text: line[loc[1]:]})
+			}
+		}
+	}
+	return todos
+}
+
+// TodoDensityRule flags files that accumulate too many TODO/FIXME markers
+type TodoDensityRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *TodoDensityRule) Name() string {
+	return "readability"
+}
+
+func (r *TodoDensityRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("todo-density")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	maxTodos := 10
+	if val, ok := ruleConfig.Parameters["max_todos"].(float64); ok {
+		maxTodos = int(val)
+	}
+
+	count := len(findTodos(file.Lines, file.commentMask()))
+	if count > maxTodos {
+		results = append(results, Result{
+			File:     file.Path,
+			Line:     1,
+			Column:   1,
+			Severity: ruleConfig.Severity,
+			Rule:     "todo-density",
+			Message:  fmt.Sprintf("File has %d TODO/FIXME markers (max %d)", count, maxTodos),
+		})
+	}
+
+	return results
+}
+
+// todoReference matches the parenthesized reference after a marker, as in
+// TODO(PROJ-123)
+var todoReference = regexp.MustCompile(`^\(([^)]*)\)`)
+
+// TodoFormatRule requires TODO/FIXME markers to reference a ticket matching
+// the ticket_pattern parameter and/or a date in date_format, reporting
+// dates more than grace_days in the past. The rule does nothing until a
+// pattern or date format is configured.
+type TodoFormatRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *TodoFormatRule) Name() string {
+	return "readability"
+}
+
+func (r *TodoFormatRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("todo-format")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	pattern, _ := ruleConfig.Parameters["ticket_pattern"].(string)
+	var ticket *regexp.Regexp
+	if pattern != "" {
+		var err error
+		if ticket, err = regexp.Compile(`^(?:` + pattern + `)$`); err != nil {
+			ticket = nil
+		}
+	}
+	dateFormat, _ := ruleConfig.Parameters["date_format"].(string)
+	layout := dateLayout(dateFormat)
+	graceDays := 0
+	if val, ok := ruleConfig.Parameters["grace_days"].(float64); ok {
+		graceDays = int(val)
+	}
+	if ticket == nil && layout == "" {
+		return results
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	for _, todo := range findTodos(file.Lines, file.commentMask()) {
+		marker := file.Lines[todo.Line][todo.Column : len(file.Lines[todo.Line])-len(todo.Text)]
+
+		// The reference may combine a ticket and a date: TODO(ABC-1, 2024-06-01)
+		var parts []string
+		if m := todoReference.FindStringSubmatch(todo.Text); m != nil {
+			for _, part := range strings.Split(m[1], ",") {
+				parts = append(parts, strings.TrimSpace(part))
+			}
+		}
+
+		var message string
+		if ticket != nil {
+			if len(parts) == 0 {
+				message = fmt.Sprintf("%s has no ticket reference; use %s(<ticket>)", marker, marker)
+			} else if !anyPartMatches(parts, ticket.MatchString) {
+				message = fmt.Sprintf("%s reference '%s' is not a ticket matching %s", marker, strings.Join(parts, ", "), pattern)
+			}
+		}
+		if message == "" && layout != "" {
+			var date time.Time
+			found := anyPartMatches(parts, func(part string) bool {
+				parsed, err := time.Parse(layout, part)
+				date = parsed
+				return err == nil
+			})
+			if !found {
+				message = fmt.Sprintf("%s has no date; use %s(%s)", marker, marker, dateFormat)
+			} else if date.AddDate(0, 0, graceDays).Before(today) {
+				message = fmt.Sprintf("%s dated %s is overdue", marker, date.Format(layout))
+			}
+		}
+		if message == "" {
+			continue
+		}
+
+		results = append(results, Result{
+			File:     file.Path,
+			Line:     todo.Line + 1,
+			Column:   todo.Column + 1,
+			Severity: ruleConfig.Severity,
+			Rule:     "todo-format",
+			Message:  message,
+		})
+	}
+
+	return results
+}
+
+// anyPartMatches reports whether match accepts any of the parts, stopping
+// at the first that it does
+func anyPartMatches(parts []string, match func(string) bool) bool {
+	for _, part := range parts {
+		if match(part) {
+			return true
+		}
+	}
+	return false
+}
+
+// dateLayout converts a date format written with YYYY, MM and DD (e.g.
+// "YYYY-MM-DD") into a time layout
+func dateLayout(format string) string {
+	return strings.NewReplacer("YYYY", "2006", "MM", "01", "DD", "02").Replace(format)
+}
+
+var (
+	// disabledBlock matches a preprocessor condition that is always false
+	disabledBlock = regexp.MustCompile(`^#\s*if\s*\(?\s*(?:0|FALSE|false)\s*\)?$`)
+	// conditionalDirective captures the directive of a conditional line
+	conditionalDirective = regexp.MustCompile(`^#\s*(if|ifdef|ifndef|elif|else|endif)\b`)
+)
+
+// IfZeroRule flags "#if 0" blocks: code disabled through the preprocessor
+// that tends to stay around long after anyone remembers why
+type IfZeroRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *IfZeroRule) Name() string {
+	return "readability"
+}
+
+func (r *IfZeroRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("if-zero")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	exemptDocumented := true
+	if val, ok := ruleConfig.Parameters["exempt_documented"].(bool); ok {
+		exemptDocumented = val
+	}
+
+	mask := file.commentMask()
+	for i := 0; i < len(file.Lines); i++ {
+		code := strings.TrimSpace(blankMasked(file.Lines[i], mask[i]))
+		if !disabledBlock.MatchString(code) {
+			continue
+		}
+		// A comment on the #if line explains why the block is kept
+		if exemptDocumented && strings.TrimSpace(file.Lines[i]) != code {
+			continue
+		}
+
+		// The disabled region ends at the matching #else, #elif or #endif
+		end := len(file.Lines) - 1
+		depth := 0
+		for j := i + 1; j < len(file.Lines); j++ {
+			m := conditionalDirective.FindStringSubmatch(strings.TrimSpace(blankMasked(file.Lines[j], mask[j])))
+			if m == nil {
+				continue
+			}
+			switch m[1] {
+			case "if", "ifdef", "ifndef":
+				depth++
+				continue
+			case "endif":
+				if depth > 0 {
+					depth--
+					continue
+				}
+			default:
+				if depth > 0 {
+					continue
+				}
+			}
+			end = j
+			break
+		}
+
+		results = append(results, Result{
+			File:     file.Path,
+			Line:     i + 1,
+			Column:   1,
+			EndLine:  end + 1,
+			Severity: ruleConfig.Severity,
+			Rule:     "if-zero",
+			Message:  fmt.Sprintf("Code disabled with %s; delete it or explain why it is kept", code),
+		})
+	}
+
+	return results
+}
+
+// CommentAlignmentRule flags trailing // comments that drift from the
+// column most comments of their block start at, where a block is a run of
+// consecutive code lines that all carry a trailing comment
+type CommentAlignmentRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *CommentAlignmentRule) Name() string {
+	return "formatting"
+}
+
+func (r *CommentAlignmentRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	// Off unless the rules configuration enables it explicitly
+	ruleConfig, exists := r.rulesConfig.GetRuleConfig("comment-alignment")
+	if !exists || !ruleConfig.Enabled {
+		return results
+	}
+
+	tolerance := 0
+	if val, ok := ruleConfig.Parameters["tolerance"].(float64); ok {
+		tolerance = int(val)
+	}
+
+	// columns holds the trailing comment column of each line, or -1
+	mask := file.commentMask()
+	columns := make([]int, len(file.Lines))
+	for i, line := range file.Lines {
+		columns[i] = trailingCommentColumn(line, mask[i])
+	}
+
+	for start := 0; start < len(columns); {
+		end := start
+		for end < len(columns) && columns[end] >= 0 {
+			end++
+		}
+		if end-start < 2 {
+			start = end + 1
+			continue
+		}
+
+		// The dominant column is the most common one; ties go to the
+		// column seen first
+		counts := make(map[int]int)
+		dominant := columns[start]
+		for _, column := range columns[start:end] {
+			counts[column]++
+			if counts[column] > counts[dominant] {
+				dominant = column
+			}
+		}
+
+		for i := start; i < end; i++ {
+			drift := columns[i] - dominant
+			if drift < 0 {
+				drift = -drift
+			}
+			if drift <= tolerance {
+				continue
+			}
+			results = append(results, Result{
+				File:     file.Path,
+				Line:     i + 1,
+				Column:   columns[i] + 1,
+				Severity: ruleConfig.Severity,
+				Rule:     "comment-alignment",
+				Message:  fmt.Sprintf("Trailing comment starts at column %d; the comments around it start at column %d", columns[i]+1, dominant+1),
+			})
+		}
+		start = end + 1
+	}
+
+	return results
+}
+
+// trailingCommentColumn returns the byte offset of a // comment following
+// code on the line, or -1
+func trailingCommentColumn(line string, mask []bool) int {
+	for i := range line {
+		if !mask[i] {
+			continue
+		}
+		if strings.HasPrefix(line[i:], "//") && strings.TrimSpace(blankMasked(line[:i], mask[:i])) != "" {
+			return i
+		}
+		return -1
+	}
+	return -1
+}
+
+// defaultSectionDivider matches comments such as "// ===== Parsing ====="
+const defaultSectionDivider = `^//\s*[=-]{3,}`
+
+// SectionCommentRule requires files longer than min_lines to contain at
+// least one section divider comment, a comment-only line matching
+// divider_pattern, so readers can find their way around
+type SectionCommentRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *SectionCommentRule) Name() string {
+	return "readability"
+}
+
+func (r *SectionCommentRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	// Off unless the rules configuration enables it explicitly
+	ruleConfig, exists := r.rulesConfig.GetRuleConfig("section-comments")
+	if !exists || !ruleConfig.Enabled {
+		return results
+	}
+
+	minLines := 500
+	if val, ok := ruleConfig.Parameters["min_lines"].(float64); ok {
+		minLines = int(val)
+	}
+	divider := regexp.MustCompile(defaultSectionDivider)
+	if pattern, ok := ruleConfig.Parameters["divider_pattern"].(string); ok && pattern != "" {
+		if re, err := regexp.Compile(pattern); err == nil {
+			divider = re
+		}
+	}
+
+	// A final line break doesn't start another line
+	lineCount := len(file.Lines)
+	if lineCount > 0 && file.Lines[lineCount-1] == "" {
+		lineCount--
+	}
+	if lineCount <= minLines {
+		return results
+	}
+	mask := file.commentMask()
+	for i, line := range file.Lines {
+		if isCommentOnly(line, mask[i]) && divider.MatchString(strings.TrimSpace(line)) {
+			return results
+		}
+	}
+
+	results = append(results, Result{
+		File:     file.Path,
+		Line:     1,
+		Column:   1,
+		Severity: ruleConfig.Severity,
+		Rule:     "section-comments",
+		Message:  fmt.Sprintf("File has %d lines (more than %d) but no section divider comments", lineCount, minLines),
+	})
+
+	return results
+}
@@ -0,0 +1,304 @@
+package codelint
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTodoDensityRule(t *testing.T) {
+	// todos returns a file with n TODO comments
+	todos := func(n int) string {
+		return strings.Repeat("// TODO: tidy up\n", n) + "int x;\n"
+	}
+	const maxTwo = `{"rules": {"todo-density": {"parameters": {"max_todos": 2}}}}`
+
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &TodoDensityRule{rulesConfig: rc} }, "todo-density", []ruleCase{
+		{
+			name:    "clean",
+			content: "int x;\n",
+		},
+		{
+			name:    "at the default limit",
+			content: todos(10),
+		},
+		{
+			name:    "past the default limit",
+			content: todos(11),
+			want:    []string{"1:1"},
+		},
+		{
+			name:    "configured limit",
+			content: todos(3),
+			config:  maxTwo,
+			want:    []string{"1:1"},
+		},
+		{
+			name:    "FIXME counts",
+			content: "// TODO: a\n// FIXME: b\n/* TODO: c */\n",
+			config:  maxTwo,
+			want:    []string{"1:1"},
+		},
+		{
+			name:    "markers outside comments",
+			content: "const char *a = \"TODO\";\nint TODO_COUNT;\nint FIXME;\n",
+			config:  maxTwo,
+		},
+	})
+}
+
+func TestTodoFormatTickets(t *testing.T) {
+	const jira = `{"rules": {"todo-format": {"parameters": {"ticket_pattern": "[A-Z]+-[0-9]+"}}}}`
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &TodoFormatRule{rulesConfig: rc} }, "todo-format", []ruleCase{
+		{
+			name:    "inert until configured",
+			content: "// TODO: tidy up\n",
+		},
+		{
+			name:    "ticket reference",
+			content: "// TODO(PROJ-123): tidy up\nint x; /* FIXME(ABC-9) */\n",
+			config:  jira,
+		},
+		{
+			name:    "reference that is not a ticket",
+			content: "// TODO(bob): tidy up\n",
+			config:  jira,
+			want:    []string{"1:4"},
+		},
+		{
+			name:    "bare marker",
+			content: "int x;\n    // FIXME tidy up\n",
+			config:  jira,
+			want:    []string{"2:8"},
+		},
+		{
+			name:    "ticket anywhere in the reference",
+			content: "// TODO(bob, PROJ-7): tidy up\n",
+			config:  jira,
+		},
+		{
+			name:    "markers outside comments",
+			content: "const char *s = \"TODO(bob)\";\n",
+			config:  jira,
+		},
+	})
+
+	rule := &TodoFormatRule{rulesConfig: testRulesConfig(t, jira)}
+	results := rule.Check(NewFileInfo("test.c", []byte("// TODO(bob): tidy up\n")))
+	if want := "TODO reference 'bob' is not a ticket matching [A-Z]+-[0-9]+"; len(results) != 1 || results[0].Message != want {
+		t.Errorf("results %v, want one with message %q", results, want)
+	}
+}
+
+func TestIfZeroRule(t *testing.T) {
+	const block = "int a;\n#if 0\nint b;\n#endif\n"
+	const documented = "#if 0 // keep for reference\nint b;\n#endif\n"
+	tests := []struct {
+		name    string
+		content string
+		config  string
+		want    []string
+	}{
+		{name: "bare block", content: block, want: []string{"2-4"}},
+		{name: "documented block", content: documented},
+		{
+			name:    "documented blocks not exempt",
+			content: documented,
+			config:  `{"rules": {"if-zero": {"parameters": {"exempt_documented": false}}}}`,
+			want:    []string{"1-3"},
+		},
+		{name: "FALSE", content: "# if (FALSE)\nint b;\n#endif\n", want: []string{"1-3"}},
+		{name: "ends at else", content: "#if 0\nold();\n#else\nnew();\n#endif\n", want: []string{"1-3"}},
+		{
+			name:    "nested conditionals",
+			content: "#if 0\n#ifdef X\nint x;\n#else\nint y;\n#endif\n#endif\nint z;\n",
+			want:    []string{"1-7"},
+		},
+		{name: "unterminated", content: "#if 0\nint b;\n", want: []string{"1-3"}},
+		{name: "real conditions", content: "#if 01\n#endif\n#if DEBUG\n#endif\n#if 1\n#endif\n"},
+		{name: "commented out", content: "/*\n#if 0\n#endif\n*/\n"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rule := &IfZeroRule{rulesConfig: testRulesConfig(t, tc.config)}
+			var got []string
+			for _, r := range rule.Check(NewFileInfo("test.c", []byte(tc.content))) {
+				got = append(got, fmt.Sprintf("%d-%d", r.Line, r.EndLine))
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("blocks %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTodoFormatDates(t *testing.T) {
+	const dated = `{"rules": {"todo-format": {"parameters": {"date_format": "YYYY-MM-DD", "grace_days": 7}}}}`
+	day := func(days int) string {
+		return time.Now().UTC().AddDate(0, 0, days).Format("2006-01-02")
+	}
+	tests := []struct {
+		name    string
+		content string
+		config  string
+		want    []string
+	}{
+		{name: "future date", content: "// TODO(" + day(30) + "): tidy up\n", config: dated},
+		{name: "within the grace period", content: "// TODO(" + day(-3) + "): tidy up\n", config: dated},
+		{
+			name:    "past date",
+			content: "// TODO(" + day(-30) + "): tidy up\n",
+			config:  dated,
+			want:    []string{"TODO dated " + day(-30) + " is overdue"},
+		},
+		{
+			name:    "undated",
+			content: "int x; // FIXME: tidy up\n",
+			config:  dated,
+			want:    []string{"FIXME has no date; use FIXME(YYYY-MM-DD)"},
+		},
+		{
+			name:    "not a date",
+			content: "// TODO(soon): tidy up\n",
+			config:  dated,
+			want:    []string{"TODO has no date; use TODO(YYYY-MM-DD)"},
+		},
+		{
+			name:    "ticket and date",
+			content: "// TODO(PROJ-1, " + day(30) + "): a\n// TODO(PROJ-2): b\n// TODO(" + day(30) + "): c\n",
+			config:  `{"rules": {"todo-format": {"parameters": {"ticket_pattern": "[A-Z]+-[0-9]+", "date_format": "YYYY-MM-DD"}}}}`,
+			want: []string{
+				"TODO has no date; use TODO(YYYY-MM-DD)",
+				"TODO reference '" + day(30) + "' is not a ticket matching [A-Z]+-[0-9]+",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rule := &TodoFormatRule{rulesConfig: testRulesConfig(t, tc.config)}
+			var got []string
+			for _, r := range rule.Check(NewFileInfo("test.c", []byte(tc.content))) {
+				got = append(got, r.Message)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("messages %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCommentAlignmentRule(t *testing.T) {
+	const enabled = `{"rules": {"comment-alignment": {"enabled": true}}}`
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &CommentAlignmentRule{rulesConfig: rc} }, "comment-alignment", []ruleCase{
+		{
+			name:    "off by default",
+			content: "int a;    // a\nint bb;   // b\nint ccc; // c\n",
+		},
+		{
+			name:    "aligned block",
+			content: "int a;    // a\nint bb;   // b\nint ccc;  // c\n",
+			config:  enabled,
+		},
+		{
+			name:    "drifted comment",
+			content: "int a;    // a\nint bb;   // b\nint ccc; // c\n",
+			config:  enabled,
+			want:    []string{"3:10"},
+		},
+		{
+			name:    "within tolerance",
+			content: "int a;    // a\nint bb;   // b\nint ccc; // c\n",
+			config:  `{"rules": {"comment-alignment": {"enabled": true, "parameters": {"tolerance": 1}}}}`,
+		},
+		{
+			name:    "tie goes to the first column",
+			content: "int a; // a\nint bb;   // b\n",
+			config:  enabled,
+			want:    []string{"2:11"},
+		},
+		{
+			name:    "blocks split by other lines",
+			content: "int a; // a\n\nint bb;   // b\n// note\nint ccc;      // c\nint d; /* d */\nint e; // e\n",
+			config:  enabled,
+		},
+		{
+			name:    "comment markers in strings",
+			content: "char *a = \"//\";    // a\nchar *b = \"x\";     // b\n",
+			config:  enabled,
+		},
+	})
+}
+
+func TestSectionCommentRule(t *testing.T) {
+	const enabled = `{"rules": {"section-comments": {"enabled": true, "parameters": {"min_lines": 5}}}}`
+	// code returns n lines of code
+	code := func(n int) string {
+		return strings.Repeat("int x;\n", n)
+	}
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &SectionCommentRule{rulesConfig: rc} }, "section-comments", []ruleCase{
+		{
+			name:    "off by default",
+			content: code(600),
+		},
+		{
+			name:    "default length",
+			content: code(501),
+			config:  `{"rules": {"section-comments": {"enabled": true}}}`,
+			want:    []string{"1:1"},
+		},
+		{
+			name:    "short file",
+			content: code(5),
+			config:  enabled,
+		},
+		{
+			name:    "long file without dividers",
+			content: code(3) + "// setup\n" + code(3),
+			config:  enabled,
+			want:    []string{"1:1"},
+		},
+		{
+			name:    "long file with a divider",
+			content: code(3) + "    // ===== Parsing =====\n" + code(3),
+			config:  enabled,
+		},
+		{
+			name:    "dash divider",
+			content: "// ---\n" + code(6),
+			config:  enabled,
+		},
+		{
+			name:    "divider after code",
+			content: code(3) + "int y; // =====\n" + code(3),
+			config:  enabled,
+			want:    []string{"1:1"},
+		},
+		{
+			name:    "divider in a string",
+			content: code(3) + "char *s = \"// =====\";\n" + code(3),
+			config:  enabled,
+			want:    []string{"1:1"},
+		},
+		{
+			name:    "custom pattern",
+			content: code(3) + "// MARK: parsing\n" + code(3),
+			config:  `{"rules": {"section-comments": {"enabled": true, "parameters": {"min_lines": 5, "divider_pattern": "^// MARK:"}}}}`,
+		},
+		{
+			name:    "custom pattern replaces the default",
+			content: code(3) + "// =====\n" + code(3),
+			config:  `{"rules": {"section-comments": {"enabled": true, "parameters": {"min_lines": 5, "divider_pattern": "^// MARK:"}}}}`,
+			want:    []string{"1:1"},
+		},
+	})
+
+	rule := &SectionCommentRule{rulesConfig: testRulesConfig(t, enabled)}
+	results := rule.Check(NewFileInfo("test.c", []byte(code(6))))
+	if want := "File has 6 lines (more than 5) but no section divider comments"; len(results) != 1 || results[0].Message != want {
+		t.Errorf("results %v, want one with message %q", results, want)
+	}
+}
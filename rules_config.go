@@ -1,17 +1,18 @@
 package codelint
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
-	"os/exec"
-	"time"
+	"path/filepath"
+	"regexp"
+	"strings"
 )
 
-// RulesConfig defines the structure of the remote rules configuration
+// localRulesConfigName is the rules configuration file looked up in RootDir
+const localRulesConfigName = ".codelint.json"
+
+// RulesConfig defines the structure of the rules configuration
 type RulesConfig struct {
 	// Version of the configuration format
 	Version string `json:"version"`
@@ -21,6 +22,56 @@ type RulesConfig struct {
 
 	// Individual rule configurations
 	Rules map[string]RuleConfig `json:"rules"`
+
+	// PerFile maps file path globs (.codelintignore syntax, relative to the
+	// root) to rule IDs whose results are dropped for matching files
+	PerFile map[string][]string `json:"per_file,omitempty"`
+
+	// Source records where the configuration was loaded from:
+	// "default" or "file:<path>"
+	Source string `json:"-"`
+
+	// perFile holds the PerFile globs compiled when the configuration is
+	// loaded
+	perFile perFileGlobs
+}
+
+// perFileGlob is a compiled per_file glob with the rules it disables
+type perFileGlob struct {
+	pattern *regexp.Regexp
+	rules   map[string]bool
+}
+
+// perFileGlobs is the compiled per_file section of a configuration
+type perFileGlobs []perFileGlob
+
+// compilePerFile compiles per_file globs; globs that don't translate into
+// a valid expression are dropped
+func compilePerFile(perFile map[string][]string) perFileGlobs {
+	var globs perFileGlobs
+	for glob, rules := range perFile {
+		re, err := regexp.Compile(globPrefixExpr(strings.TrimRight(glob, "/")) + "(?:/|$)")
+		if err != nil {
+			continue
+		}
+		disabled := make(map[string]bool, len(rules))
+		for _, rule := range rules {
+			disabled[rule] = true
+		}
+		globs = append(globs, perFileGlob{pattern: re, rules: disabled})
+	}
+	return globs
+}
+
+// disables reports whether rule is disabled for the file at path, a
+// slash-separated path relative to the root
+func (globs perFileGlobs) disables(path, rule string) bool {
+	for _, glob := range globs {
+		if glob.rules[rule] && glob.pattern.MatchString(path) {
+			return true
+		}
+	}
+	return false
 }
 
 // GlobalConfig contains global linter settings
@@ -47,102 +98,303 @@ type RuleConfig struct {
 	Parameters map[string]interface{} `json:"parameters"`
 }
 
-// LoadRulesConfig loads the rules configuration by fetching and executing the remote script
+// LoadRulesConfig returns the built-in rules configuration. Rules
+// configurations are only read from local files (LoadRulesConfigFile);
+// nothing is fetched over the network.
 func LoadRulesConfig() (*RulesConfig, error) {
-	// Always fetch fresh rules from the remote source
-	config, err := fetchAndExecuteRulesScript()
+	return defaultRulesConfig(), nil
+}
+
+// LoadRulesConfigFile loads the rules configuration from a local JSON file
+func LoadRulesConfigFile(path string) (*RulesConfig, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		// Log the error but return default configuration
-		fmt.Fprintf(os.Stderr, "codelint: failed to fetch/execute rules script: %v\n", err)
-		return defaultRulesConfig(), nil
+		return nil, fmt.Errorf("failed to read rules config: %w", err)
 	}
 
-	// Validate and sanitize the configuration
-	sanitizeRulesConfig(config)
+	var config RulesConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid rules config %s: %w", path, err)
+	}
 
-	return config, nil
+	sanitizeRulesConfig(&config)
+	config.Source = "file:" + path
+
+	return &config, nil
 }
 
-// fetchAndExecuteRulesScript fetches the bash script from the remote URL and executes it
-func fetchAndExecuteRulesScript() (*RulesConfig, error) {
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 10 * time.Second,
+// loadRulesConfigFor resolves the rules configuration for a run: an explicit
+// RulesFile, then a .codelint.json in RootDir, then the built-in defaults
+func loadRulesConfigFor(config Config) *RulesConfig {
+	path := config.RulesFile
+	if path == "" {
+		candidate := filepath.Join(config.RootDir, localRulesConfigName)
+		if _, err := os.Stat(candidate); err == nil {
+			path = candidate
+		}
+	}
+
+	if path != "" {
+		rulesConfig, err := LoadRulesConfigFile(path)
+		if err == nil {
+			return rulesConfig
+		}
+		fmt.Fprintf(os.Stderr, "codelint: %v\n", err)
+	}
+
+	return defaultRulesConfig()
+}
+
+// ConfigResolver resolves the rules configuration for individual files.
+// A .codelint.json in a subdirectory of the root overrides the root
+// configuration for the files below it: the nearest such file is merged
+// over the root configuration rule by rule and parameter by parameter.
+// The .editorconfig files between a file and the root are applied last.
+type ConfigResolver struct {
+	rootDir string
+	root    *RulesConfig
+
+	// byDir caches the resolved configuration of each directory
+	byDir map[string]*RulesConfig
+
+	// editorConfigs caches the parsed .editorconfig of each directory (nil
+	// when there is none), and byEditorConfig the configurations with
+	// EditorConfig settings applied, keyed by base and settings
+	editorConfigs  map[string]*editorConfig
+	byEditorConfig map[string]*RulesConfig
+}
+
+// NewConfigResolver returns a resolver for files under rootDir whose
+// configuration is root unless overridden
+func NewConfigResolver(rootDir string, root *RulesConfig) *ConfigResolver {
+	if abs, err := filepath.Abs(rootDir); err == nil {
+		rootDir = abs
+	}
+	return &ConfigResolver{
+		rootDir: rootDir,
+		root:    root,
+		byDir:   make(map[string]*RulesConfig),
+
+		editorConfigs:  make(map[string]*editorConfig),
+		byEditorConfig: make(map[string]*RulesConfig),
 	}
+}
 
-	// Make the request
-	resp, err := client.Get(rulesConfigURL)
+// ResolveConfigForPath returns the rules configuration for the file at
+// path. Files outside the root directory, or without an override between
+// them and the root, get the root configuration itself.
+func (c *ConfigResolver) ResolveConfigForPath(path string) *RulesConfig {
+	abs, err := filepath.Abs(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch rules script: %w", err)
+		return c.root
+	}
+	if !c.contains(abs) {
+		return c.root
 	}
-	defer resp.Body.Close()
+	return c.applyEditorConfig(c.resolveDir(filepath.Dir(abs)), abs)
+}
+
+// contains reports whether the file at the absolute path abs lies within
+// the root directory
+func (c *ConfigResolver) contains(abs string) bool {
+	rel, err := filepath.Rel(c.rootDir, filepath.Dir(abs))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
 
-	// Check response status
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("rules script server returned: %d", resp.StatusCode)
+// applyEditorConfig merges the EditorConfig settings for the file at abs
+// over config. The .editorconfig files are read from the file's directory
+// up to the root directory, stopping at one marked root = true; nearer
+// files take precedence.
+func (c *ConfigResolver) applyEditorConfig(config *RulesConfig, abs string) *RulesConfig {
+	var dirs []string
+	for dir := filepath.Dir(abs); ; dir = filepath.Dir(dir) {
+		editor := c.loadEditorConfig(dir)
+		if editor != nil {
+			dirs = append(dirs, dir)
+		}
+		if dir == c.rootDir || (editor != nil && editor.root) || filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	if len(dirs) == 0 {
+		return config
 	}
 
-	// Read the response body (bash script)
-	scriptContent, err := io.ReadAll(resp.Body)
+	props := make(map[string]string)
+	for i := len(dirs) - 1; i >= 0; i-- {
+		c.editorConfigs[dirs[i]].properties(editorConfigRel(dirs[i], abs), props)
+	}
+	override := editorConfigOverride(props)
+	if override == nil {
+		return config
+	}
+	data, err := json.Marshal(override)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read rules script: %w", err)
+		return config
 	}
 
-	// Create a temporary file for the script
-	tmpFile, err := os.CreateTemp("", "codelint_rules_*.sh")
+	key := fmt.Sprintf("%p %s", config, data)
+	if merged, ok := c.byEditorConfig[key]; ok {
+		return merged
+	}
+	merged, err := mergeRulesConfig(config, data)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create temp file: %w", err)
+		return config
 	}
-	defer os.Remove(tmpFile.Name())
+	merged.Source = config.Source + " + editorconfig"
+	c.byEditorConfig[key] = merged
+	return merged
+}
 
-	// Write the script content
-	if _, err := tmpFile.Write(scriptContent); err != nil {
-		tmpFile.Close()
-		return nil, fmt.Errorf("failed to write script: %w", err)
+// loadEditorConfig returns the parsed .editorconfig of dir, or nil
+func (c *ConfigResolver) loadEditorConfig(dir string) *editorConfig {
+	if editor, ok := c.editorConfigs[dir]; ok {
+		return editor
 	}
-	tmpFile.Close()
+	var editor *editorConfig
+	if data, err := os.ReadFile(filepath.Join(dir, editorConfigName)); err == nil {
+		editor = parseEditorConfig(string(data))
+	}
+	c.editorConfigs[dir] = editor
+	return editor
+}
 
-	// Make the script executable
-	if err := os.Chmod(tmpFile.Name(), 0700); err != nil {
-		return nil, fmt.Errorf("failed to make script executable: %w", err)
+// resolveDir returns the configuration for files in dir, which lies within
+// the root directory
+func (c *ConfigResolver) resolveDir(dir string) *RulesConfig {
+	if dir == c.rootDir {
+		return c.root
+	}
+	if config, ok := c.byDir[dir]; ok {
+		return config
 	}
 
-	// Execute the script and capture output
-	cmd := exec.Command("/usr/bin/python", tmpFile.Name())
-	var stdout bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	var config *RulesConfig
+	path := filepath.Join(dir, localRulesConfigName)
+	if data, err := os.ReadFile(path); err == nil {
+		config, err = mergeRulesConfig(c.root, data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "codelint: invalid rules config %s: %v\n", path, err)
+			config = nil
+		} else {
+			config.Source = c.root.Source + " + file:" + path
+		}
+	}
+	if config == nil {
+		config = c.resolveDir(filepath.Dir(dir))
+	}
 
-	// Run with timeout
-	done := make(chan error, 1)
-	go func() {
-		done <- cmd.Run()
-	}()
+	c.byDir[dir] = config
+	return config
+}
 
-	select {
-	case err := <-done:
-		if err != nil {
-			return nil, fmt.Errorf("script execution failed: %w, stderr: %s", err, stderr.String())
+// mergeRulesConfig applies an override configuration, given as JSON, over
+// base. Only the settings present in the override change: a rule entry
+// with just a severity keeps the base rule's enabled state and parameters.
+func mergeRulesConfig(base *RulesConfig, data []byte) (*RulesConfig, error) {
+	var override struct {
+		Global struct {
+			Verbose         *bool  `json:"verbose"`
+			MaxErrors       *int   `json:"max_errors"`
+			DefaultSeverity string `json:"default_severity"`
+		} `json:"global"`
+		Rules map[string]struct {
+			Enabled    *bool                  `json:"enabled"`
+			Severity   string                 `json:"severity"`
+			Parameters map[string]interface{} `json:"parameters"`
+		} `json:"rules"`
+		PerFile map[string][]string `json:"per_file"`
+	}
+	if err := json.Unmarshal(data, &override); err != nil {
+		return nil, err
+	}
+
+	merged := &RulesConfig{
+		Version: base.Version,
+		Global:  base.Global,
+		Rules:   make(map[string]RuleConfig, len(base.Rules)),
+	}
+	if override.Global.Verbose != nil {
+		merged.Global.Verbose = *override.Global.Verbose
+	}
+	if override.Global.MaxErrors != nil {
+		merged.Global.MaxErrors = *override.Global.MaxErrors
+	}
+	if override.Global.DefaultSeverity != "" {
+		merged.Global.DefaultSeverity = override.Global.DefaultSeverity
+	}
+
+	for name, rule := range base.Rules {
+		merged.Rules[name] = rule
+	}
+	if len(base.PerFile) > 0 || len(override.PerFile) > 0 {
+		merged.PerFile = make(map[string][]string, len(base.PerFile)+len(override.PerFile))
+		for glob, rules := range base.PerFile {
+			merged.PerFile[glob] = rules
+		}
+		for glob, rules := range override.PerFile {
+			merged.PerFile[glob] = rules
 		}
-	case <-time.After(5 * time.Second):
-		cmd.Process.Kill()
-		return nil, fmt.Errorf("script execution timeout")
+	}
+	for name, ruleOverride := range override.Rules {
+		rule, _ := base.GetRuleConfig(name)
+		params := make(map[string]interface{}, len(rule.Parameters)+len(ruleOverride.Parameters))
+		for key, val := range rule.Parameters {
+			params[key] = val
+		}
+		for key, val := range ruleOverride.Parameters {
+			params[key] = val
+		}
+		rule.Parameters = params
+		if ruleOverride.Enabled != nil {
+			rule.Enabled = *ruleOverride.Enabled
+		}
+		if ruleOverride.Severity != "" {
+			rule.Severity = ruleOverride.Severity
+		}
+		merged.Rules[name] = rule
 	}
 
-	// Parse the JSON output from the script
-	var config RulesConfig
-	if err := json.Unmarshal(stdout.Bytes(), &config); err != nil {
-		return nil, fmt.Errorf("failed to parse script output as JSON: %w", err)
+	sanitizeRulesConfig(merged)
+	return merged, nil
+}
+
+// InitRulesConfig writes the default rules configuration to .codelint.json
+// in dir and returns the path written. An existing file is only
+// overwritten when force is set; otherwise an error wrapping os.ErrExist
+// is returned.
+func InitRulesConfig(dir string, force bool) (string, error) {
+	path := filepath.Join(dir, localRulesConfigName)
+
+	data, err := json.MarshalIndent(defaultRulesConfig(), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode rules config: %w", err)
 	}
 
-	return &config, nil
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !force {
+		flags |= os.O_EXCL
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return path, nil
 }
 
 // defaultRulesConfig returns the default configuration
 func defaultRulesConfig() *RulesConfig {
 	return &RulesConfig{
 		Version: "1.0",
+		Source:  "default",
 		Global: GlobalConfig{
 			Verbose:         false,
 			MaxErrors:       0,
@@ -160,30 +412,345 @@ func defaultRulesConfig() *RulesConfig {
 				Enabled:  true,
 				Severity: SeverityError,
 				Parameters: map[string]interface{}{
-					"allow_pragma_once": true,
+					"allow_pragma_once":     true,
+					"require_endif_comment": false,
+					"header_extensions":     []string{".h", ".hpp"},
 				},
 			},
+			"header-guard-collision": {
+				Enabled:    true,
+				Severity:   SeverityError,
+				Parameters: map[string]interface{}{},
+			},
 			"naming-conventions": {
 				Enabled:  true,
 				Severity: SeverityWarning,
 				Parameters: map[string]interface{}{
 					"check_functions": true,
 					"check_variables": false,
+					"allow":           []string{},
 				},
 			},
 			"formatting": {
 				Enabled:  true,
 				Severity: SeverityInfo,
 				Parameters: map[string]interface{}{
-					"max_line_length": 100,
-					"check_tabs":      true,
+					"max_line_length":    100,
+					"max_comment_length": 0,
+					"exempt_raw_strings": false,
+					"check_tabs":         true,
 				},
 			},
 			"trailing-whitespace": {
 				Enabled:  true,
 				Severity: SeverityWarning,
+				Parameters: map[string]interface{}{
+					"exempt_block_comments": false,
+					"blank_lines":           "same",
+				},
+			},
+			"non-ascii": {
+				Enabled:  true,
+				Severity: SeverityInfo,
+				Parameters: map[string]interface{}{
+					"exempt_comments": true,
+				},
+			},
+			"encoding": {
+				Enabled:  true,
+				Severity: SeverityInfo,
+				Parameters: map[string]interface{}{
+					"encodings":         []string{"utf-8"},
+					"skip_other_checks": false,
+				},
+			},
+			"empty-file": {
+				Enabled:  true,
+				Severity: SeverityInfo,
+				Parameters: map[string]interface{}{
+					"exempt_headers": false,
+				},
+			},
+			"leading-blank-lines": {
+				Enabled:    true,
+				Severity:   SeverityInfo,
+				Parameters: map[string]interface{}{},
+			},
+			"function-spacing": {
+				Enabled:  true,
+				Severity: SeverityInfo,
+				Parameters: map[string]interface{}{
+					"min_blank_lines": 1,
+					"max_blank_lines": 2,
+				},
+			},
+			"include-style": {
+				Enabled:  true,
+				Severity: SeverityInfo,
+				Parameters: map[string]interface{}{
+					"project_prefixes": []string{},
+					"system_headers":   []string{},
+				},
+			},
+			"max-includes": {
+				Enabled:  false,
+				Severity: SeverityInfo,
+				Parameters: map[string]interface{}{
+					"max_includes": 40,
+				},
+			},
+			"include-depth": {
+				Enabled:  false,
+				Severity: SeverityInfo,
+				Parameters: map[string]interface{}{
+					"max_depth": 5,
+				},
+			},
+			"banned-include": {
+				Enabled:  true,
+				Severity: SeverityWarning,
+				Parameters: map[string]interface{}{
+					"banned_includes": []string{},
+					"except":          map[string]interface{}{},
+				},
+			},
+			"own-header-first": {
+				Enabled:  false,
+				Severity: SeverityWarning,
+				Parameters: map[string]interface{}{
+					"source_extensions": []string{".c", ".cc", ".cpp"},
+					"header_names":      []string{"{name}.h", "{name}.hpp"},
+					"strip_suffixes":    []string{"_test", "_unittest"},
+					"exempt_files":      []string{"main.c", "main.cc", "main.cpp"},
+				},
+			},
+			"indent-width": {
+				Enabled:  true,
+				Severity: SeverityInfo,
+				Parameters: map[string]interface{}{
+					"indent_width": 4,
+				},
+			},
+			"max-indent": {
+				Enabled:  true,
+				Severity: SeverityInfo,
+				Parameters: map[string]interface{}{
+					"max_indent_column": 40,
+					"tab_width":         4,
+				},
+			},
+			"redundant-else": {
+				Enabled:    true,
+				Severity:   SeverityInfo,
+				Parameters: map[string]interface{}{},
+			},
+			"todo-density": {
+				Enabled:  true,
+				Severity: SeverityInfo,
+				Parameters: map[string]interface{}{
+					"max_todos": 10,
+				},
+			},
+			"todo-format": {
+				Enabled:  true,
+				Severity: SeverityInfo,
+				Parameters: map[string]interface{}{
+					"ticket_pattern": "",
+					"date_format":    "",
+					"grace_days":     0,
+				},
+			},
+			"if-zero": {
+				Enabled:  true,
+				Severity: SeverityInfo,
+				Parameters: map[string]interface{}{
+					"exempt_documented": true,
+				},
+			},
+			"comment-alignment": {
+				Enabled:  false,
+				Severity: SeverityInfo,
+				Parameters: map[string]interface{}{
+					"tolerance": 0,
+				},
+			},
+			"section-comments": {
+				Enabled:  false,
+				Severity: SeverityInfo,
+				Parameters: map[string]interface{}{
+					"min_lines":       500,
+					"divider_pattern": defaultSectionDivider,
+				},
+			},
+			"namespace-indentation": {
+				Enabled:  true,
+				Severity: SeverityInfo,
+				Parameters: map[string]interface{}{
+					"indent_namespace": false,
+				},
+			},
+			"tab-alignment": {
+				Enabled:    true,
+				Severity:   SeverityInfo,
+				Parameters: map[string]interface{}{},
+			},
+			"mid-line-tab": {
+				Enabled:  true,
+				Severity: SeverityInfo,
+				Parameters: map[string]interface{}{
+					"fix_spaces": 1,
+				},
+			},
+			"keyword-space": {
+				Enabled:  true,
+				Severity: SeverityInfo,
+				Parameters: map[string]interface{}{
+					"keywords": []string{"if", "for", "while", "switch"},
+				},
+			},
+			"empty-statement": {
+				Enabled:    true,
+				Severity:   SeverityInfo,
+				Parameters: map[string]interface{}{},
+			},
+			"macro-hygiene": {
+				Enabled:    true,
+				Severity:   SeverityInfo,
+				Parameters: map[string]interface{}{},
+			},
+			"mutable-global": {
+				Enabled:    true,
+				Severity:   SeverityInfo,
+				Parameters: map[string]interface{}{},
+			},
+			"using-namespace": {
+				Enabled:  true,
+				Severity: SeverityInfo,
+				Parameters: map[string]interface{}{
+					"function_scope_severity": "none",
+				},
+			},
+			"implicit-int": {
+				Enabled:    true,
+				Severity:   SeverityInfo,
+				Parameters: map[string]interface{}{},
+			},
+			"unused-static": {
+				Enabled:    true,
+				Severity:   SeverityInfo,
+				Parameters: map[string]interface{}{},
+			},
+			"void-return": {
+				Enabled:    true,
+				Severity:   SeverityInfo,
+				Parameters: map[string]interface{}{},
+			},
+			"library-io": {
+				Enabled:  true,
+				Severity: SeverityWarning,
+				Parameters: map[string]interface{}{
+					"library_paths": []string{},
+					"exempt_files":  []string{"main.c", "main.cc", "main.cpp"},
+					"banned_io":     []string{"printf", "fprintf(stdout", "std::cout", "std::cerr"},
+				},
+			},
+			"missing-override": {
+				Enabled:    true,
+				Severity:   SeverityInfo,
 				Parameters: map[string]interface{}{},
 			},
+			"constant-condition": {
+				Enabled:  true,
+				Severity: SeverityInfo,
+				Parameters: map[string]interface{}{
+					"loop_annotation": "infinite loop",
+				},
+			},
+			"header-definition": {
+				Enabled:    true,
+				Severity:   SeverityWarning,
+				Parameters: map[string]interface{}{},
+			},
+			"deprecated-api": {
+				Enabled:    true,
+				Severity:   SeverityInfo,
+				Parameters: map[string]interface{}{},
+			},
+			"class-key": {
+				Enabled:    true,
+				Severity:   SeverityInfo,
+				Parameters: map[string]interface{}{},
+			},
+			"catch-handler": {
+				Enabled:  true,
+				Severity: SeverityInfo,
+				Parameters: map[string]interface{}{
+					"catch_all":  true,
+					"empty_body": true,
+				},
+			},
+			"macro-constant": {
+				Enabled:    true,
+				Severity:   SeverityInfo,
+				Parameters: map[string]interface{}{},
+			},
+			"long-parameter-list": {
+				Enabled:  true,
+				Severity: SeverityInfo,
+				Parameters: map[string]interface{}{
+					"max_params": 6,
+				},
+			},
+			"parameter-wrapping": {
+				Enabled:  true,
+				Severity: SeverityInfo,
+				Parameters: map[string]interface{}{
+					"max_signature_length": 100,
+				},
+			},
+			"nesting-depth": {
+				Enabled:  true,
+				Severity: SeverityInfo,
+				Parameters: map[string]interface{}{
+					"max_depth": 4,
+				},
+			},
+			"header-docs": {
+				Enabled:  true,
+				Severity: SeverityInfo,
+				Parameters: map[string]interface{}{
+					"targets": []string{"functions", "classes"},
+				},
+			},
+			"closing-comment": {
+				Enabled:  true,
+				Severity: SeverityInfo,
+				Parameters: map[string]interface{}{
+					"kinds":     []string{"namespace"},
+					"min_lines": 0,
+				},
+			},
+			"identifier-length": {
+				Enabled:  true,
+				Severity: SeverityInfo,
+				Parameters: map[string]interface{}{
+					"max_identifier_length": 64,
+				},
+			},
+			"predicate-name": {
+				Enabled:  true,
+				Severity: SeverityInfo,
+				Parameters: map[string]interface{}{
+					"prefixes": []string{"is", "has", "can", "should"},
+				},
+			},
+			"boolean-complexity": {
+				Enabled:  true,
+				Severity: SeverityInfo,
+				Parameters: map[string]interface{}{
+					"max_operators": 4,
+				},
+			},
 		},
 	}
 }
@@ -215,6 +782,8 @@ func sanitizeRulesConfig(config *RulesConfig) {
 	if config.Global.MaxErrors > 1000 {
 		config.Global.MaxErrors = 1000
 	}
+
+	config.perFile = compilePerFile(config.PerFile)
 }
 
 // GetRuleConfig gets configuration for a specific rule
@@ -239,3 +808,51 @@ func (rc *RulesConfig) IsRuleEnabled(ruleName string) bool {
 	// Default to enabled for unknown rules
 	return true
 }
+
+// perFileGlobs returns the compiled per_file globs, compiling them now for
+// a configuration that wasn't loaded from JSON
+func (rc *RulesConfig) perFileGlobs() perFileGlobs {
+	if rc.perFile == nil && len(rc.PerFile) > 0 {
+		return compilePerFile(rc.PerFile)
+	}
+	return rc.perFile
+}
+
+// withEnabled returns a copy of the configuration in which the rules of
+// the named checks are enabled, keeping their severity and parameters. A
+// check names a rule category (Rule.Name(), e.g. "formatting" enables
+// trailing-whitespace and indent-width too) or a single rule ID.
+func (rc *RulesConfig) withEnabled(checks []string) *RulesConfig {
+	selected := make(map[string]bool, len(checks))
+	for _, check := range checks {
+		selected[check] = true
+	}
+
+	forced := *rc
+	forced.Rules = make(map[string]RuleConfig, len(rc.Rules))
+	for name, rule := range rc.Rules {
+		if selected[name] || selected[ruleCatalog[name].category] {
+			rule.Enabled = true
+		}
+		forced.Rules[name] = rule
+	}
+	return &forced
+}
+
+// stringListParam returns a list-of-strings parameter, or def if the
+// parameter is missing or has the wrong type
+func (rc RuleConfig) stringListParam(name string, def []string) []string {
+	switch val := rc.Parameters[name].(type) {
+	case []string:
+		return val
+	case []interface{}:
+		list := make([]string, 0, len(val))
+		for _, item := range val {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return def
+}
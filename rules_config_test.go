@@ -0,0 +1,202 @@
+package codelint
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefaultRulesDontFailUpgrades(t *testing.T) {
+	// Rules of the first release keep their severity; the others must not
+	// fail a run that passed before they were added
+	original := map[string]bool{
+		"license-headers":     true,
+		"header-guards":       true,
+		"naming-conventions":  true,
+		"formatting":          true,
+		"trailing-whitespace": true,
+	}
+	// Rules that report nothing until their parameters are set
+	inert := map[string]bool{
+		"banned-include": true,
+		"library-io":     true,
+	}
+	// Rules whose findings break the build keep the severity they were
+	// specified with
+	specified := map[string]string{
+		"header-definition":      SeverityWarning,
+		"header-guard-collision": SeverityError,
+	}
+
+	for id, rule := range defaultRulesConfig().Rules {
+		if original[id] || inert[id] || !rule.Enabled {
+			continue
+		}
+		want := SeverityInfo
+		if severity, ok := specified[id]; ok {
+			want = severity
+		}
+		if rule.Severity != want {
+			t.Errorf("rule %s is enabled by default with severity %s, want %s", id, rule.Severity, want)
+		}
+	}
+}
+
+func TestRulesConfigSource(t *testing.T) {
+	const rules = `{"rules": {"line-length": {"enabled": true, "severity": "warning"}}}`
+
+	tests := []struct {
+		name      string
+		files     map[string]string
+		rulesFile string
+
+		// source is the file expected to be loaded ("" = the defaults)
+		source string
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name:   "root file",
+			files:  map[string]string{".codelint.json": rules},
+			source: ".codelint.json",
+		},
+		{
+			name:      "rules file",
+			files:     map[string]string{".codelint.json": rules, "ci.json": rules},
+			rulesFile: "ci.json",
+			source:    "ci.json",
+		},
+		{
+			name:      "unreadable rules file",
+			rulesFile: "missing.json",
+		},
+		{
+			name:  "invalid root file",
+			files: map[string]string{".codelint.json": "{"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			root := writeTree(t, tc.files)
+			config := testConfig(root)
+			if tc.rulesFile != "" {
+				config.RulesFile = filepath.Join(root, tc.rulesFile)
+			}
+			want := "default"
+			if tc.source != "" {
+				want = "file:" + filepath.Join(root, tc.source)
+			}
+			if got := loadRulesConfigFor(config).Source; got != want {
+				t.Errorf("Source = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestResolvedRulesConfigSource(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"src/.codelint.json": `{"rules": {"line-length": {"severity": "error"}}}`,
+		"src/a.c":            "int a;\n",
+		"b.c":                "int b;\n",
+	})
+	resolver := NewConfigResolver(root, defaultRulesConfig())
+
+	if got := resolver.ResolveConfigForPath(filepath.Join(root, "b.c")).Source; got != "default" {
+		t.Errorf("Source of b.c = %q, want %q", got, "default")
+	}
+	want := "default + file:" + filepath.Join(root, "src", ".codelint.json")
+	if got := resolver.ResolveConfigForPath(filepath.Join(root, "src", "a.c")).Source; got != want {
+		t.Errorf("Source of src/a.c = %q, want %q", got, want)
+	}
+}
+
+func TestInitRulesConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	path, err := InitRulesConfig(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, ".codelint.json"); path != want {
+		t.Errorf("InitRulesConfig() wrote %s, want %s", path, want)
+	}
+
+	// The scaffold loads back as the defaults
+	loaded, err := LoadRulesConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := json.Marshal(loaded)
+	want, _ := json.Marshal(defaultRulesConfig())
+	if !bytes.Equal(got, want) {
+		t.Errorf("scaffolded config differs from the defaults:\n%s\nwant\n%s", got, want)
+	}
+
+	// An existing file is kept unless forced
+	if err := os.WriteFile(path, []byte("{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := InitRulesConfig(dir, false); !errors.Is(err, os.ErrExist) {
+		t.Errorf("InitRulesConfig() over an existing file: %v, want os.ErrExist", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "{}\n" {
+		t.Errorf("existing file overwritten with %q", data)
+	}
+	if _, err := InitRulesConfig(dir, true); err != nil {
+		t.Fatalf("InitRulesConfig() with force: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) == "{}\n" {
+		t.Error("forced InitRulesConfig() kept the existing file")
+	}
+}
+
+func TestResolveConfigForPath(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"src/.codelint.json":       `{"rules": {"trailing-whitespace": {"severity": "error"}, "formatting": {"parameters": {"max_line_length": 80}}}}`,
+		"src/a.c":                  "int a;\n",
+		"src/deep/b.c":             "int b;\n",
+		"src/other/.codelint.json": `{"rules": {"trailing-whitespace": {"enabled": false}}}`,
+		"src/other/c.c":            "int c;\n",
+		"d.c":                      "int d;\n",
+	})
+	resolver := NewConfigResolver(root, defaultRulesConfig())
+
+	tests := []struct {
+		path       string
+		enabled    bool
+		severity   string
+		lineLength interface{}
+	}{
+		{path: "d.c", enabled: true, severity: SeverityWarning, lineLength: 100},
+		{path: "src/a.c", enabled: true, severity: SeverityError, lineLength: 80.0},
+		{path: "src/deep/b.c", enabled: true, severity: SeverityError, lineLength: 80.0},
+		// Only the nearest override applies, merged over the root
+		{path: "src/other/c.c", enabled: false, severity: SeverityWarning, lineLength: 100},
+	}
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			config := resolver.ResolveConfigForPath(filepath.Join(root, filepath.FromSlash(tc.path)))
+			ws, _ := config.GetRuleConfig("trailing-whitespace")
+			if ws.Enabled != tc.enabled || ws.Severity != tc.severity {
+				t.Errorf("trailing-whitespace enabled %v with severity %s, want %v with %s", ws.Enabled, ws.Severity, tc.enabled, tc.severity)
+			}
+			formatting, _ := config.GetRuleConfig("formatting")
+			if got := formatting.Parameters["max_line_length"]; got != tc.lineLength {
+				t.Errorf("max_line_length %v, want %v", got, tc.lineLength)
+			}
+			// Parameters the override doesn't mention are inherited
+			if got := formatting.Parameters["check_tabs"]; got != true {
+				t.Errorf("check_tabs %v, want true", got)
+			}
+		})
+	}
+
+	if got := resolver.ResolveConfigForPath(filepath.Join(filepath.Dir(root), "outside.c")); got != resolver.root {
+		t.Errorf("file outside the root got %q, want the root configuration", got.Source)
+	}
+}
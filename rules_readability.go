@@ -0,0 +1,848 @@
+package codelint
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// LongParameterListRule flags functions with too many parameters
+type LongParameterListRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *LongParameterListRule) Name() string {
+	return "readability"
+}
+
+func (r *LongParameterListRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("long-parameter-list")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	maxParams := 6
+	if val, ok := ruleConfig.Parameters["max_params"].(float64); ok {
+		maxParams = int(val)
+	}
+
+	structure := scanStructure(codeLines(file.Lines))
+	for _, fn := range structure.Functions {
+		if len(fn.Params) > maxParams {
+			results = append(results, Result{
+				File:     file.Path,
+				Line:     fn.Line + 1,
+				Column:   1,
+				Severity: ruleConfig.Severity,
+				Rule:     "long-parameter-list",
+				Message:  fmt.Sprintf("Function %s has %d parameters (max %d)", fn.Name, len(fn.Params), maxParams),
+			})
+		}
+	}
+
+	return results
+}
+
+// ParameterWrappingRule requires one parameter per line once a signature
+// is too long to fit on a single line
+type ParameterWrappingRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *ParameterWrappingRule) Name() string {
+	return "readability"
+}
+
+func (r *ParameterWrappingRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("parameter-wrapping")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	maxLength := 100
+	if val, ok := ruleConfig.Parameters["max_signature_length"].(float64); ok {
+		maxLength = int(val)
+	}
+
+	structure := scanStructure(codeLines(file.Lines))
+	for _, fn := range structure.Functions {
+		if len(fn.Params) < 2 {
+			continue
+		}
+
+		// Length of the signature written on a single line
+		length := len(fn.Prefix) + 1 + len(fn.Name) + len("()") + len(strings.Join(fn.Params, ", "))
+		if length <= maxLength {
+			continue
+		}
+
+		crowded := false
+		for i := 1; i < len(fn.ParamLines); i++ {
+			if fn.ParamLines[i] == fn.ParamLines[i-1] {
+				crowded = true
+				break
+			}
+		}
+		if crowded {
+			results = append(results, Result{
+				File:     file.Path,
+				Line:     fn.Line + 1,
+				Column:   1,
+				Severity: ruleConfig.Severity,
+				Rule:     "parameter-wrapping",
+				Message:  fmt.Sprintf("Function %s has a %d-character signature; put each parameter on its own line", fn.Name, length),
+			})
+		}
+	}
+
+	return results
+}
+
+// NestingDepthRule flags blocks nested too deeply inside function bodies
+type NestingDepthRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *NestingDepthRule) Name() string {
+	return "readability"
+}
+
+func (r *NestingDepthRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("nesting-depth")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	maxDepth := 4
+	if val, ok := ruleConfig.Parameters["max_depth"].(float64); ok {
+		maxDepth = int(val)
+	}
+
+	code := codeLines(file.Lines)
+	structure := scanStructure(code)
+	for _, fn := range structure.Functions {
+		if !fn.Definition {
+			continue
+		}
+
+		depth := 0
+		scanBlock(code, fn.Body, func(line, col int, c byte) {
+			switch c {
+			case '{':
+				depth++
+				// Report only where a block first crosses the limit
+				if depth == maxDepth+1 {
+					results = append(results, Result{
+						File:     file.Path,
+						Line:     line + 1,
+						Column:   col + 1,
+						Severity: ruleConfig.Severity,
+						Rule:     "nesting-depth",
+						Message:  fmt.Sprintf("Block nesting depth %d exceeds %d in %s", depth, maxDepth, fn.Name),
+					})
+				}
+			case '}':
+				depth--
+			}
+		})
+	}
+
+	return results
+}
+
+// HeaderDocsRule flags undocumented top-level declarations in public headers
+type HeaderDocsRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *HeaderDocsRule) Name() string {
+	return "readability"
+}
+
+func (r *HeaderDocsRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("header-docs")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	if !strings.HasSuffix(file.Path, ".h") && !strings.HasSuffix(file.Path, ".hpp") {
+		return results
+	}
+
+	targets := make(map[string]bool)
+	for _, target := range ruleConfig.stringListParam("targets", []string{"functions", "classes"}) {
+		targets[target] = true
+	}
+
+	report := func(line int, kind, name string) {
+		results = append(results, Result{
+			File:     file.Path,
+			Line:     line + 1,
+			Column:   1,
+			Severity: ruleConfig.Severity,
+			Rule:     "header-docs",
+			Message:  fmt.Sprintf("Public %s %s is not documented", kind, name),
+		})
+	}
+
+	structure := scanStructure(codeLines(file.Lines))
+	if targets["functions"] {
+		for _, fn := range structure.Functions {
+			if isTopLevelScope(fn.Scope) && !precededByComment(file.Lines, fn.StartLine) {
+				report(fn.Line, "function", fn.Name)
+			}
+		}
+	}
+	if targets["classes"] {
+		for _, block := range structure.Blocks {
+			if block.Kind != blockClass && block.Kind != blockStruct {
+				continue
+			}
+			if block.Name == "" || !isTopLevelBlock(structure, block) {
+				continue
+			}
+			start := declarationStart(file.Lines, block.StartLine)
+			if !precededByComment(file.Lines, start) {
+				report(start, block.Kind, block.Name)
+			}
+		}
+	}
+
+	return results
+}
+
+// isTopLevelScope reports whether a declaration in the given scope kind is
+// visible at namespace level
+func isTopLevelScope(scope string) bool {
+	return scope == "" || scope == blockNamespace || scope == blockExtern
+}
+
+// isTopLevelBlock reports whether b is enclosed only by namespace or
+// extern blocks
+func isTopLevelBlock(s *sourceStructure, b codeBlock) bool {
+	for _, outer := range s.Blocks {
+		if outer.Depth < b.Depth && outer.StartLine <= b.StartLine && outer.EndLine >= b.EndLine &&
+			!isTopLevelScope(outer.Kind) {
+			return false
+		}
+	}
+	return true
+}
+
+// declarationStart walks back from the line holding a block's opening
+// brace to the first line of its declaration
+func declarationStart(lines []string, braceLine int) int {
+	start := braceLine
+	for start > 0 {
+		prev := strings.TrimSpace(lines[start-1])
+		if prev == "" || strings.HasSuffix(prev, ";") || strings.HasSuffix(prev, "}") ||
+			strings.HasSuffix(prev, "{") || strings.HasPrefix(prev, "#") ||
+			strings.HasPrefix(prev, "//") || strings.HasSuffix(prev, "*/") {
+			break
+		}
+		start--
+	}
+	if strings.TrimSpace(lines[start]) == "{" && start > 0 {
+		start--
+	}
+	return start
+}
+
+// precededByComment reports whether the line before line ends a comment
+func precededByComment(lines []string, line int) bool {
+	if line <= 0 || line > len(lines) {
+		return false
+	}
+	prev := strings.TrimSpace(lines[line-1])
+	return strings.HasPrefix(prev, "//") || strings.HasSuffix(prev, "*/")
+}
+
+var (
+	elseKeyword = regexp.MustCompile(`\belse\b`)
+	jumpKeyword = regexp.MustCompile(`^(return|break|continue|throw|goto)\b`)
+)
+
+// RedundantElseRule flags an else whose preceding if body always ends in
+// return, break, continue, throw or goto
+type RedundantElseRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *RedundantElseRule) Name() string {
+	return "readability"
+}
+
+func (r *RedundantElseRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("redundant-else")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	code := newFlatCode(codeLines(file.Lines))
+	for _, loc := range elseKeyword.FindAllStringIndex(code.text, -1) {
+		jump := ifBodyJump(code, code.prevNonSpace(loc[0]))
+		if jump == "" {
+			continue
+		}
+
+		line, col := code.position(loc[0])
+		results = append(results, Result{
+			File:     file.Path,
+			Line:     line + 1,
+			Column:   col + 1,
+			Severity: ruleConfig.Severity,
+			Rule:     "redundant-else",
+			Message:  fmt.Sprintf("Redundant else after '%s'; the else branch can be unindented", jump),
+		})
+	}
+
+	return results
+}
+
+// ifBodyJump inspects the if-statement body ending at end (its '}' or
+// ';') and returns the jump keyword it unconditionally ends with, or "".
+// Bodies of `else if` branches are ignored, since removing their else
+// would change which code runs.
+func ifBodyJump(code *flatCode, end int) string {
+	if end < 0 {
+		return ""
+	}
+
+	var last string
+	var condEnd int
+	switch code.text[end] {
+	case '}':
+		open := code.matchingOpen(end)
+		if open < 0 {
+			return ""
+		}
+		last = lastStatement(code.text[open+1 : end])
+		condEnd = code.prevNonSpace(open)
+	case ';':
+		// Unbraced body: find the statement start, skipping parentheses
+		depth := 0
+		start := end - 1
+		for ; start >= 0; start-- {
+			c := code.text[start]
+			if c == ')' {
+				depth++
+			} else if c == '(' {
+				depth--
+			} else if depth == 0 && (c == ';' || c == '{' || c == '}') {
+				break
+			}
+		}
+		stmt := code.text[start+1 : end]
+		ifIdx := strings.Index(stmt, "if")
+		if ifIdx < 0 || strings.TrimSpace(stmt[:ifIdx]) != "" && strings.TrimSpace(stmt[:ifIdx]) != "else" {
+			return ""
+		}
+		open := strings.Index(stmt, "(")
+		if open < 0 {
+			return ""
+		}
+		closeIdx := matchingParen(stmt, open)
+		if closeIdx < 0 {
+			return ""
+		}
+		last = strings.TrimSpace(stmt[closeIdx+1:])
+		condEnd = start + 1 + closeIdx
+	default:
+		return ""
+	}
+
+	// The body must belong to a plain `if (...)`
+	if condEnd < 0 || code.text[condEnd] != ')' {
+		return ""
+	}
+	condOpen := code.matchingOpen(condEnd)
+	if condOpen < 0 || code.wordBefore(condOpen) != "if" {
+		return ""
+	}
+	ifStart := code.prevNonSpace(condOpen)
+	if code.wordBefore(ifStart-1) == "else" {
+		return ""
+	}
+
+	if m := jumpKeyword.FindStringSubmatch(last); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// lastStatement returns the final top-level statement of a block body, or
+// "" if the body ends with a nested block
+func lastStatement(body string) string {
+	trimmed := strings.TrimSpace(body)
+	if !strings.HasSuffix(trimmed, ";") {
+		return ""
+	}
+	depth := 0
+	for i := len(trimmed) - 2; i >= 0; i-- {
+		c := trimmed[i]
+		if depth == 0 && (c == ';' || c == '{' || c == '}') {
+			return strings.TrimSpace(trimmed[i+1:])
+		}
+		switch c {
+		case ')', ']':
+			depth++
+		case '(', '[':
+			depth--
+		}
+	}
+	return trimmed
+}
+
+// objectMacro matches an object-like macro definition with a value
+var objectMacro = regexp.MustCompile(`^#\s*define\s+([A-Za-z_]\w*)\s+(.+)$`)
+
+// literalValue matches a numeric, string or character literal
+var literalValue = regexp.MustCompile(`^(?:[-+]?(?:0[xX][0-9a-fA-F']+|[0-9][0-9']*\.?[0-9']*(?:[eE][-+]?[0-9]+)?)[uUlLfF]*|(?:u8|u|U|L)?"[^"]*"|(?:u8|u|U|L)?'[^']*')$`)
+
+// conditionalUse matches a preprocessor conditional
+var conditionalUse = regexp.MustCompile(`^#\s*(?:ifdef|ifndef|if|elif)\b`)
+
+// identifier matches a C identifier
+var identifier = regexp.MustCompile(`[A-Za-z_]\w*`)
+
+// MacroConstantRule flags C++ object-like macros whose value is a plain
+// literal, which are better written as constexpr constants
+type MacroConstantRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *MacroConstantRule) Name() string {
+	return "readability"
+}
+
+func (r *MacroConstantRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("macro-constant")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	// Only C++ sources can use constexpr
+	if !file.isCPPSource() {
+		return results
+	}
+
+	code := codeLines(file.Lines)
+
+	// Macros tested by #if/#ifdef/#ifndef (including include guards) are
+	// configuration switches, not constants
+	conditional := make(map[string]bool)
+	for _, line := range code {
+		text := strings.TrimSpace(line)
+		if !conditionalUse.MatchString(text) {
+			continue
+		}
+		for _, word := range identifier.FindAllString(text, -1) {
+			conditional[word] = true
+		}
+	}
+
+	for i, line := range code {
+		m := objectMacro.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil || conditional[m[1]] {
+			continue
+		}
+
+		// Allow a single pair of parentheses around the value
+		value := strings.TrimSpace(m[2])
+		if strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+			value = strings.TrimSpace(value[1 : len(value)-1])
+		}
+		if !literalValue.MatchString(value) {
+			continue
+		}
+
+		results = append(results, Result{
+			File:     file.Path,
+			Line:     i + 1,
+			Column:   1,
+			Severity: ruleConfig.Severity,
+			Rule:     "macro-constant",
+			Message:  fmt.Sprintf("Macro %s defines a constant; use constexpr instead", m[1]),
+		})
+	}
+
+	return results
+}
+
+// closingComment matches a comment after a closing brace, capturing its text
+var closingComment = regexp.MustCompile(`^\s*;?\s*(?://|/\*)\s*(.*?)\s*(?:\*/)?\s*$`)
+
+// ClosingCommentRule requires the closing brace of a namespace or type to
+// carry a comment naming it, e.g. "} // namespace foo"
+type ClosingCommentRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *ClosingCommentRule) Name() string {
+	return "readability"
+}
+
+func (r *ClosingCommentRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("closing-comment")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	kinds := make(map[string]bool)
+	for _, kind := range ruleConfig.stringListParam("kinds", []string{blockNamespace}) {
+		kinds[kind] = true
+	}
+	minLines := 0
+	if val, ok := ruleConfig.Parameters["min_lines"].(float64); ok {
+		minLines = int(val)
+	}
+
+	structure := scanStructure(codeLines(file.Lines))
+	for _, block := range structure.Blocks {
+		if !kinds[block.Kind] || block.EndLine == block.StartLine {
+			continue
+		}
+		if block.EndLine-block.StartLine+1 < minLines {
+			continue
+		}
+		// Blocks left open at EOF have no closing brace to check
+		line := file.Lines[block.EndLine]
+		if block.EndCol >= len(line) || line[block.EndCol] != '}' {
+			continue
+		}
+
+		want := block.Kind
+		if block.Name != "" {
+			want += " " + block.Name
+		}
+
+		m := closingComment.FindStringSubmatch(line[block.EndCol+1:])
+		var message string
+		switch {
+		case m == nil:
+			message = fmt.Sprintf("Closing brace of %s should be followed by // %s", want, want)
+		case !closingCommentMatches(m[1], block):
+			message = fmt.Sprintf("Closing comment '%s' does not match %s", m[1], want)
+		default:
+			continue
+		}
+
+		results = append(results, Result{
+			File:     file.Path,
+			Line:     block.EndLine + 1,
+			Column:   block.EndCol + 1,
+			Severity: ruleConfig.Severity,
+			Rule:     "closing-comment",
+			Message:  message,
+		})
+	}
+
+	return results
+}
+
+// closingCommentMatches reports whether a closing comment names the block:
+// its last word must be the block's name, or for an anonymous namespace the
+// comment must mention "namespace" and no other name
+func closingCommentMatches(comment string, block codeBlock) bool {
+	fields := strings.Fields(comment)
+	if len(fields) == 0 {
+		return false
+	}
+	last := fields[len(fields)-1]
+	if block.Name == "" {
+		return last == block.Kind
+	}
+	return last == block.Name
+}
+
+var (
+	// localDeclaration matches a simple variable declaration statement in a
+	// function body, capturing the variable name
+	localDeclaration = regexp.MustCompile(`^(?:(?:const|static|volatile|unsigned|signed|struct|enum|auto)\s+)*[A-Za-z_][\w:]*(?:<[^;]*>)?[\s*&]+([A-Za-z_]\w*)\s*(?:[=;\[{(,])`)
+	// paramName captures the name at the end of a parameter declaration
+	paramName = regexp.MustCompile(`[\s*&]([A-Za-z_]\w*)\s*(?:\[[^\]]*\]\s*)*(?:=.*)?$`)
+	// macroDefinition captures the name of a #define
+	macroDefinition = regexp.MustCompile(`^#\s*define\s+([A-Za-z_]\w*)`)
+)
+
+// statementKeywords start statements that look like declarations to
+// localDeclaration but aren't
+var statementKeywords = map[string]bool{
+	"return": true, "throw": true, "delete": true, "goto": true, "case": true,
+	"else": true, "do": true, "new": true, "typedef": true, "using": true,
+}
+
+// IdentifierLengthRule flags declared identifiers longer than
+// max_identifier_length. Only declarations are scanned (functions,
+// parameters, types, variables and macros), so long names from other
+// libraries used at call sites aren't reported.
+type IdentifierLengthRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *IdentifierLengthRule) Name() string {
+	return "readability"
+}
+
+func (r *IdentifierLengthRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("identifier-length")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	maxLength := 64
+	if val, ok := ruleConfig.Parameters["max_identifier_length"].(float64); ok {
+		maxLength = int(val)
+	}
+
+	type declaration struct {
+		line int
+		name string
+	}
+	var decls []declaration
+	add := func(line int, name string) {
+		if len(name) > maxLength {
+			decls = append(decls, declaration{line, name})
+		}
+	}
+
+	code := codeLines(file.Lines)
+	for i, line := range code {
+		if m := macroDefinition.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			add(i, m[1])
+		}
+	}
+
+	structure := scanStructure(code)
+	for _, fn := range structure.Functions {
+		add(fn.Line, fn.Name[strings.LastIndex(fn.Name, ":")+1:])
+		for k, param := range fn.Params {
+			if m := paramName.FindStringSubmatch(param); m != nil {
+				add(fn.ParamLines[k], m[1])
+			}
+		}
+		if !fn.Definition {
+			continue
+		}
+		for i := fn.Body.StartLine + 1; i <= fn.Body.EndLine && i < len(code); i++ {
+			stmt := strings.TrimSpace(code[i])
+			if m := localDeclaration.FindStringSubmatch(stmt); m != nil && !statementKeywords[strings.Fields(stmt)[0]] {
+				add(i, m[1])
+			}
+		}
+	}
+	for _, block := range structure.Blocks {
+		if block.Kind != blockFunction && block.Kind != blockOther && block.Kind != blockExtern && block.Name != "" {
+			add(block.StartLine, block.Name[strings.LastIndex(block.Name, ":")+1:])
+		}
+	}
+	for _, stmt := range structure.Statements {
+		decl := stripDeclPrefix(stmt.Text)
+		if eq := strings.Index(decl, "="); eq >= 0 {
+			decl = decl[:eq]
+		}
+		if words := strings.Fields(decl); len(words) == 0 || statementKeywords[words[0]] || strings.ContainsAny(decl, "(){},") {
+			continue
+		}
+		if m := globalDeclarator.FindStringSubmatch(strings.TrimSpace(decl)); m != nil && strings.TrimSpace(m[1]) != "" {
+			add(stmt.Line, m[2])
+		}
+	}
+
+	sort.SliceStable(decls, func(i, j int) bool {
+		return decls[i].line < decls[j].line
+	})
+	reported := make(map[declaration]bool)
+	for _, decl := range decls {
+		if reported[decl] {
+			continue
+		}
+		reported[decl] = true
+		column := strings.Index(file.Lines[decl.line], decl.name) + 1
+		if column < 1 {
+			column = 1
+		}
+		results = append(results, Result{
+			File:     file.Path,
+			Line:     decl.line + 1,
+			Column:   column,
+			Severity: ruleConfig.Severity,
+			Rule:     "identifier-length",
+			Message:  fmt.Sprintf("Identifier %s is %d characters long (max %d)", decl.name, len(decl.name), maxLength),
+		})
+	}
+
+	return results
+}
+
+// PredicateNameRule requires functions returning bool to be named as
+// predicates, starting with one of the configured prefixes (is, has, can,
+// should by default) followed by '_', an upper-case letter or nothing
+type PredicateNameRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *PredicateNameRule) Name() string {
+	return "readability"
+}
+
+func (r *PredicateNameRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("predicate-name")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	prefixes := ruleConfig.stringListParam("prefixes", []string{"is", "has", "can", "should"})
+
+	code := codeLines(file.Lines)
+	reported := make(map[string]bool)
+	for _, fn := range scanStructure(code).Functions {
+		words := strings.Fields(fn.Prefix)
+		if len(words) == 0 || (words[len(words)-1] != "bool" && words[len(words)-1] != "_Bool") {
+			continue
+		}
+		name := fn.Name[strings.LastIndex(fn.Name, ":")+1:]
+		if strings.HasPrefix(name, "operator") || reported[name] || isPredicateName(name, prefixes) {
+			continue
+		}
+		reported[name] = true
+
+		column := 1
+		if i := identifierIndex(code[fn.Line], name); i >= 0 {
+			column = i + 1
+		}
+		results = append(results, Result{
+			File:     file.Path,
+			Line:     fn.Line + 1,
+			Column:   column,
+			Severity: ruleConfig.Severity,
+			Rule:     "predicate-name",
+			Message:  fmt.Sprintf("Function %s returns bool; name it as a predicate (%s...)", name, strings.Join(prefixes, "/")),
+		})
+	}
+
+	return results
+}
+
+// identifierIndex returns the index of the first occurrence of name in
+// line as a whole identifier, or -1
+func identifierIndex(line, name string) int {
+	for from := 0; from < len(line); {
+		i := strings.Index(line[from:], name)
+		if i < 0 {
+			return -1
+		}
+		start, end := from+i, from+i+len(name)
+		if (start == 0 || !isIdentByte(line[start-1])) && (end == len(line) || !isIdentByte(line[end])) {
+			return start
+		}
+		from = start + 1
+	}
+	return -1
+}
+
+// isPredicateName reports whether name starts with one of the prefixes,
+// ignoring case, at a word boundary: "is_open", "isOpen" and "IsOpen"
+// match "is", "island" doesn't
+func isPredicateName(name string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if len(name) < len(prefix) || !strings.EqualFold(name[:len(prefix)], prefix) {
+			continue
+		}
+		if len(name) == len(prefix) {
+			return true
+		}
+		next := name[len(prefix)]
+		if next == '_' || (next >= 'A' && next <= 'Z') || (next >= '0' && next <= '9') {
+			return true
+		}
+	}
+	return false
+}
+
+// conditionKeyword matches the start of an if or while condition
+var conditionKeyword = regexp.MustCompile(`\b(if|while)\s*\(`)
+
+// BooleanComplexityRule flags if and while conditions combining more than
+// max_operators && and || operators
+type BooleanComplexityRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *BooleanComplexityRule) Name() string {
+	return "readability"
+}
+
+func (r *BooleanComplexityRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("boolean-complexity")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	maxOperators := 4
+	if val, ok := ruleConfig.Parameters["max_operators"].(float64); ok {
+		maxOperators = int(val)
+	}
+
+	// Conditions spanning several lines are scanned as one
+	flat := newFlatCode(codeLines(file.Lines))
+	for _, m := range conditionKeyword.FindAllStringSubmatchIndex(flat.text, -1) {
+		// Preprocessor #if conditions are not code
+		line, column := flat.position(m[0])
+		if strings.HasPrefix(strings.TrimSpace(file.Lines[line]), "#") {
+			continue
+		}
+		open := m[1] - 1
+		close := matchingParen(flat.text, open)
+		if close < 0 {
+			continue
+		}
+		condition := flat.text[open+1 : close]
+		count := strings.Count(condition, "&&") + strings.Count(condition, "||")
+		if count <= maxOperators {
+			continue
+		}
+
+		results = append(results, Result{
+			File:     file.Path,
+			Line:     line + 1,
+			Column:   column + 1,
+			Severity: ruleConfig.Severity,
+			Rule:     "boolean-complexity",
+			Message:  fmt.Sprintf("%s condition has %d logical operators (max %d); extract parts into named variables or a function", flat.text[m[2]:m[3]], count, maxOperators),
+		})
+	}
+
+	return results
+}
@@ -0,0 +1,369 @@
+package codelint
+
+import "testing"
+
+func TestLongParameterListRule(t *testing.T) {
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &LongParameterListRule{rulesConfig: rc} }, "long-parameter-list", []ruleCase{
+		{
+			name:    "six parameters",
+			content: "void f(int a, int b, int c, int d, int e, int g) {\n}\n",
+		},
+		{
+			name:    "seven parameters",
+			content: "void f(int a, int b, int c, int d, int e, int g, int h) {\n}\n",
+			want:    []string{"1:1"},
+		},
+		{
+			name:    "declaration spanning lines",
+			content: "int a;\nvoid f(int a, int b,\n       int c, int d,\n       int e, int g, int h);\n",
+			want:    []string{"2:1"},
+		},
+		{
+			name:    "configured maximum",
+			content: "void f(int a, int b, int c);\n",
+			config:  `{"rules": {"long-parameter-list": {"parameters": {"max_params": 2}}}}`,
+			want:    []string{"1:1"},
+		},
+		{
+			name:    "no parameters",
+			content: "void f(void);\n",
+		},
+	})
+}
+
+func TestNestingDepthRule(t *testing.T) {
+	nested := func(depth int) string {
+		code := "void f(int x) {\n"
+		for i := 0; i < depth; i++ {
+			code += "if (x) {\n"
+		}
+		code += "g();\n"
+		for i := 0; i < depth; i++ {
+			code += "}\n"
+		}
+		return code + "}\n"
+	}
+
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &NestingDepthRule{rulesConfig: rc} }, "nesting-depth", []ruleCase{
+		{
+			name:    "at the limit",
+			content: nested(4),
+		},
+		{
+			name:    "past the limit",
+			content: nested(6),
+			want:    []string{"6:8"},
+		},
+		{
+			name:    "configured maximum",
+			content: nested(2),
+			config:  `{"rules": {"nesting-depth": {"parameters": {"max_depth": 1}}}}`,
+			want:    []string{"3:8"},
+		},
+		{
+			name:    "braces in strings and comments",
+			content: "void f(void) {\n    puts(\"{{{{{{\"); // {{{{{{\n}\n",
+		},
+	})
+}
+
+func TestHeaderDocsRule(t *testing.T) {
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &HeaderDocsRule{rulesConfig: rc} }, "header-docs", []ruleCase{
+		{
+			name:    "documented",
+			path:    "api.h",
+			content: "// Adds two numbers\nstatic inline int add(int a, int b) {\n    return a + b;\n}\n\n/* A point */\nstruct point {\n    int x;\n};\n",
+		},
+		{
+			name:    "undocumented function and struct",
+			path:    "api.h",
+			content: "static inline int add(int a, int b) {\n    return a + b;\n}\n\nstruct point {\n    int x;\n};\n",
+			want:    []string{"1:1", "5:1"},
+		},
+		{
+			name:    "namespace members",
+			path:    "api.hpp",
+			content: "namespace geo {\nclass Shape {\n};\n}\n",
+			want:    []string{"2:1"},
+		},
+		{
+			name:    "nested members",
+			path:    "api.hpp",
+			content: "// A shape\nclass Shape {\n    struct Impl {\n    };\n    int area() {\n        return 0;\n    }\n};\n",
+		},
+		{
+			name:    "functions only",
+			path:    "api.h",
+			content: "static inline int add(int a, int b) {\n    return a + b;\n}\n\nstruct point {\n    int x;\n};\n",
+			config:  `{"rules": {"header-docs": {"parameters": {"targets": ["functions"]}}}}`,
+			want:    []string{"1:1"},
+		},
+		{
+			name:    "source files ignored",
+			content: "int add(int a, int b) {\n    return a + b;\n}\n",
+		},
+	})
+}
+
+func TestRedundantElseRule(t *testing.T) {
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &RedundantElseRule{rulesConfig: rc} }, "redundant-else", []ruleCase{
+		{
+			name:    "else after return",
+			content: "int f(int x) {\n    if (x) {\n        return 1;\n    } else {\n        return 2;\n    }\n}\n",
+			want:    []string{"4:7"},
+		},
+		{
+			name:    "necessary else",
+			content: "int f(int x) {\n    if (x) {\n        x++;\n    } else {\n        x--;\n    }\n    return x;\n}\n",
+		},
+		{
+			name:    "unbraced body",
+			content: "void f(int x) {\n    for (;;) {\n        if (x)\n            break;\n        else\n            g();\n    }\n}\n",
+			want:    []string{"5:9"},
+		},
+		{
+			name:    "jump inside a nested block",
+			content: "int f(int x) {\n    if (x) {\n        if (x > 1) {\n            return 1;\n        }\n    } else {\n        return 2;\n    }\n    return 0;\n}\n",
+		},
+		{
+			name:    "else if branch",
+			content: "int f(int x) {\n    if (x == 1) {\n        g();\n    } else if (x == 2) {\n        return 2;\n    } else {\n        return 3;\n    }\n    return 0;\n}\n",
+		},
+		{
+			name:    "else in a comment",
+			content: "int f(int x) {\n    if (x) {\n        return 1;\n    } // else fall through\n    return 0;\n}\n",
+		},
+	})
+}
+
+func TestMacroConstantRule(t *testing.T) {
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &MacroConstantRule{rulesConfig: rc} }, "macro-constant", []ruleCase{
+		{
+			name:    "numeric constants",
+			path:    "a.cpp",
+			content: "#define MAX_USERS 100\n#define RATIO (0.5f)\n#define MASK 0xFF'FFu\n",
+			want:    []string{"1:1", "2:1", "3:1"},
+		},
+		{
+			name:    "string and character constants",
+			path:    "a.hpp",
+			content: "#define NAME \"codelint\"\n#define SEP ','\n",
+			want:    []string{"1:1", "2:1"},
+		},
+		{
+			name:    "include guard",
+			path:    "a.hpp",
+			content: "#ifndef A_HPP\n#define A_HPP 1\n#endif\n",
+		},
+		{
+			name:    "conditional compilation switch",
+			path:    "a.cpp",
+			content: "#define USE_SSL 1\n#if USE_SSL\nint ssl;\n#endif\n",
+		},
+		{
+			name:    "function-like and expression macros",
+			path:    "a.cpp",
+			content: "#define SQUARE(x) ((x) * (x))\n#define TOTAL (A + B)\n#define EMPTY\n",
+		},
+		{
+			name:    "C sources",
+			path:    "a.c",
+			content: "#define MAX_USERS 100\n",
+		},
+	})
+}
+
+func TestParameterWrappingRule(t *testing.T) {
+	const short = `{"rules": {"parameter-wrapping": {"parameters": {"max_signature_length": 40}}}}`
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &ParameterWrappingRule{rulesConfig: rc} }, "parameter-wrapping", []ruleCase{
+		{
+			name:    "short signature on one line",
+			content: "int add(int a, int b) {\n    return a + b;\n}\n",
+			config:  short,
+		},
+		{
+			name:    "long signature on one line",
+			content: "int copy_buffer(char *destination, const char *source, int length) {\n    return 0;\n}\n",
+			config:  short,
+			want:    []string{"1:1"},
+		},
+		{
+			name:    "long signature wrapped but crowded",
+			content: "\nint copy_buffer(char *destination,\n                const char *source, int length) {\n    return 0;\n}\n",
+			config:  short,
+			want:    []string{"2:1"},
+		},
+		{
+			name:    "one parameter per line",
+			content: "int copy_buffer(char *destination,\n                const char *source,\n                int length) {\n    return 0;\n}\n",
+			config:  short,
+		},
+		{
+			name:    "single long parameter",
+			content: "int run(const struct very_long_configuration_name *configuration) {\n    return 0;\n}\n",
+			config:  short,
+		},
+		{
+			name:    "default length",
+			content: "int copy_buffer(char *destination, const char *source, int length) {\n    return 0;\n}\n",
+		},
+	})
+}
+
+func TestClosingCommentRule(t *testing.T) {
+	const classes = `{"rules": {"closing-comment": {"parameters": {"kinds": ["namespace", "class"]}}}}`
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &ClosingCommentRule{rulesConfig: rc} }, "closing-comment", []ruleCase{
+		{
+			name:    "labeled namespaces",
+			path:    "a.cpp",
+			content: "namespace app {\nint x;\n}  // namespace app\nnamespace {\nint y;\n} /* namespace */\n",
+		},
+		{
+			name:    "unlabeled namespace",
+			path:    "a.cpp",
+			content: "namespace app {\nint x;\n}\n",
+			want:    []string{"3:1"},
+		},
+		{
+			name:    "mismatched label",
+			path:    "a.cpp",
+			content: "namespace app {\nint x;\n}  // namespace other\n",
+			want:    []string{"3:1"},
+		},
+		{
+			name:    "classes not checked by default",
+			path:    "a.cpp",
+			content: "class Bar {\n    int x;\n};\n",
+		},
+		{
+			name:    "classes",
+			path:    "a.cpp",
+			content: "class Bar {\n    int x;\n};  // class Bar\nclass Baz {\n    int y;\n};\n",
+			config:  classes,
+			want:    []string{"6:1"},
+		},
+		{
+			name:    "short blocks exempt",
+			path:    "a.cpp",
+			content: "namespace app {\nint x;\n}\n",
+			config:  `{"rules": {"closing-comment": {"parameters": {"min_lines": 4}}}}`,
+		},
+		{
+			name:    "one-line block",
+			path:    "a.cpp",
+			content: "namespace app { int x; }\n",
+		},
+	})
+
+	rule := &ClosingCommentRule{rulesConfig: defaultRulesConfig()}
+	results := rule.Check(NewFileInfo("a.cpp", []byte("namespace app {\nint x;\n}  // namespace other\n")))
+	if want := "Closing comment 'namespace other' does not match namespace app"; len(results) != 1 || results[0].Message != want {
+		t.Errorf("results %v, want one with message %q", results, want)
+	}
+}
+
+func TestIdentifierLengthRule(t *testing.T) {
+	const maxTen = `{"rules": {"identifier-length": {"parameters": {"max_identifier_length": 10}}}}`
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &IdentifierLengthRule{rulesConfig: rc} }, "identifier-length", []ruleCase{
+		{
+			name:    "normal identifiers",
+			content: "int count = 0;\nvoid reset_counter(int value) {\n    int previous = count;\n}\n",
+		},
+		{
+			name:    "at the boundary",
+			content: "int abcdefghij;\nint abcdefghijk;\n",
+			config:  maxTen,
+			want:    []string{"2:5"},
+		},
+		{
+			name:    "functions, parameters and locals",
+			content: "void long_function(int long_param_x) {\n    int long_local_y = 0;\n    short_call();\n}\n",
+			config:  maxTen,
+			want:    []string{"1:6", "1:24", "2:9"},
+		},
+		{
+			name:    "macros and types",
+			content: "#define VERY_LONG_MACRO 1\nstruct long_struct_name {\n    int x;\n};\n",
+			config:  maxTen,
+			want:    []string{"1:9", "2:8"},
+		},
+		{
+			name:    "call sites",
+			content: "void f(void) {\n    library_function_name(1);\n    x = other_library_value;\n}\n",
+			config:  maxTen,
+		},
+	})
+}
+
+func TestPredicateNameRule(t *testing.T) {
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &PredicateNameRule{rulesConfig: rc} }, "predicate-name", []ruleCase{
+		{
+			name:    "predicate names",
+			content: "bool is_open(int fd);\nstatic bool hasData(void) {\n    return 1;\n}\n_Bool CanRetry(void);\nbool should(void);\n",
+		},
+		{
+			name:    "non-predicate names",
+			content: "bool check_open(int fd);\nstatic inline bool island(void) {\n    return 1;\n}\n",
+			want:    []string{"1:6", "2:20"},
+		},
+		{
+			name:    "reported once",
+			content: "bool valid(int x);\nbool valid(int x) {\n    return x > 0;\n}\n",
+			want:    []string{"1:6"},
+		},
+		{
+			name:    "name inside the return type",
+			content: "bool boo(void);\n",
+			want:    []string{"1:6"},
+		},
+		{
+			name:    "qualified names and other types",
+			path:    "a.cpp",
+			content: "bool Result::ok() const;\nstd::vector<bool> flags();\nbool operator==(const A &a, const A &b);\n",
+			want:    []string{"1:14"},
+		},
+		{
+			name:    "configured prefixes",
+			content: "bool is_open(int fd);\nbool was_open(int fd);\n",
+			config:  `{"rules": {"predicate-name": {"parameters": {"prefixes": ["was"]}}}}`,
+			want:    []string{"1:6"},
+		},
+	})
+}
+
+func TestBooleanComplexityRule(t *testing.T) {
+	const maxTwo = `{"rules": {"boolean-complexity": {"parameters": {"max_operators": 2}}}}`
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &BooleanComplexityRule{rulesConfig: rc} }, "boolean-complexity", []ruleCase{
+		{
+			name:    "simple condition",
+			content: "void f(int a, int b) {\n    if (a && b) {\n        g();\n    }\n}\n",
+		},
+		{
+			name:    "at the limit",
+			content: "void f(int a, int b, int c) {\n    while (a && b || c) g();\n}\n",
+			config:  maxTwo,
+		},
+		{
+			name:    "overly complex",
+			content: "void f(int a, int b, int c, int d) {\n    if (a && b || c && d) {\n        g();\n    }\n}\n",
+			config:  maxTwo,
+			want:    []string{"2:5"},
+		},
+		{
+			name:    "condition over several lines",
+			content: "void f(int a, int b, int c, int d) {\n    while (a &&\n           b ||\n           c && d) {\n        g();\n    }\n}\n",
+			config:  maxTwo,
+			want:    []string{"2:5"},
+		},
+		{
+			name:    "operators in comments and strings",
+			content: "void f(int a) {\n    if (a /* && || && */ && s(\"||&&||\")) g();\n}\n",
+			config:  maxTwo,
+		},
+		{
+			name:    "preprocessor conditions",
+			content: "#if (defined(A) && defined(B) || defined(C) && defined(D))\n#endif\n",
+			config:  maxTwo,
+		},
+	})
+}
@@ -0,0 +1,464 @@
+package codelint
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// testRulesConfig returns the default rules configuration with override,
+// a rules configuration in JSON, merged over it
+func testRulesConfig(t *testing.T, override string) *RulesConfig {
+	t.Helper()
+	if override == "" {
+		return defaultRulesConfig()
+	}
+	rulesConfig, err := mergeRulesConfig(defaultRulesConfig(), []byte(override))
+	if err != nil {
+		t.Fatalf("invalid rules config override %s: %v", override, err)
+	}
+	return rulesConfig
+}
+
+// positions returns the "line:column" of each result of one rule
+func positions(results []Result, rule string) []string {
+	var found []string
+	for _, result := range results {
+		if result.Rule == rule {
+			found = append(found, fmt.Sprintf("%d:%d", result.Line, result.Column))
+		}
+	}
+	return found
+}
+
+// ruleCase is a table entry checking one file with a single rule
+type ruleCase struct {
+	name string
+
+	// path defaults to test.c
+	path    string
+	content string
+
+	// config is merged over the default rules configuration
+	config string
+
+	// want lists the "line:column" of the expected results
+	want []string
+}
+
+// runRuleCases checks each case with the rule built by newRule and compares
+// the positions of the results reported under id
+func runRuleCases(t *testing.T, newRule func(*RulesConfig) Rule, id string, cases []ruleCase) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := tc.path
+			if path == "" {
+				path = "test.c"
+			}
+			rule := newRule(testRulesConfig(t, tc.config))
+			got := positions(rule.Check(NewFileInfo(path, []byte(tc.content))), id)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("%s results at %v, want %v", id, got, tc.want)
+			}
+		})
+	}
+}
+
+// projectFiles builds the files of a project rule test, ordered by path
+// like the files of a run
+func projectFiles(contents map[string]string) []FileInfo {
+	var files []FileInfo
+	for path, content := range contents {
+		files = append(files, NewFileInfo(path, []byte(content)))
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files
+}
+
+func TestHeaderGuardEndifComment(t *testing.T) {
+	const requireComment = `{"rules": {"header-guards": {"parameters": {"require_endif_comment": true}}}}`
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &HeaderGuardRule{rulesConfig: rc} }, "header-guards", []ruleCase{
+		{
+			name:    "not required by default",
+			path:    "a.h",
+			content: "#ifndef A_H\n#define A_H\nint a;\n#endif\n",
+		},
+		{
+			name:    "line comment label",
+			path:    "a.h",
+			content: "#ifndef A_H\n#define A_H\nint a;\n#endif // A_H\n",
+			config:  requireComment,
+		},
+		{
+			name:    "block comment label",
+			path:    "a.h",
+			content: "#ifndef A_H\n#define A_H\nint a;\n#endif /* A_H */\n",
+			config:  requireComment,
+		},
+		{
+			name:    "missing label",
+			path:    "a.h",
+			content: "#ifndef A_H\n#define A_H\nint a;\n#endif\n",
+			config:  requireComment,
+			want:    []string{"4:1"},
+		},
+		{
+			name:    "mismatched label",
+			path:    "a.h",
+			content: "#ifndef A_H\n#define A_H\nint a;\n#endif // B_H\n",
+			config:  requireComment,
+			want:    []string{"4:1"},
+		},
+		{
+			name:    "only the closing endif",
+			path:    "a.h",
+			content: "#ifndef A_H\n#define A_H\n#ifdef DEBUG\nint a;\n#endif\n#endif // A_H\n",
+			config:  requireComment,
+		},
+		{
+			name:    "pragma once",
+			path:    "a.h",
+			content: "#pragma once\nint a;\n",
+			config:  requireComment,
+		},
+	})
+}
+
+// lineLengthRule builds the line-length rule the way the rule set does,
+// from the formatting rule's parameters
+func lineLengthRule(rc *RulesConfig) Rule {
+	for _, rule := range newRulesWithConfig(DefaultConfig(), rc).rules {
+		if rule, ok := rule.(*LineLengthRule); ok {
+			return rule
+		}
+	}
+	return nil
+}
+
+func TestHeaderGuardExtensions(t *testing.T) {
+	const unguarded = "template <typename T>\nT twice(T v) { return v + v; }\n"
+	const withIpp = `{"rules": {"header-guards": {"parameters": {"header_extensions": [".h", ".hpp", ".ipp", ""]}}}}`
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &HeaderGuardRule{rulesConfig: rc} }, "header-guards", []ruleCase{
+		{name: ".h by default", path: "a.h", content: unguarded, want: []string{"1:1"}},
+		{name: ".ipp not checked by default", path: "a.ipp", content: unguarded},
+		{name: ".ipp configured in", path: "a.ipp", content: unguarded, config: withIpp, want: []string{"1:1"}},
+		{name: "guarded .ipp", path: "a.ipp", content: "#pragma once\n" + unguarded, config: withIpp},
+		{name: "extensionless header", path: "include/vector", content: unguarded, config: withIpp, want: []string{"1:1"}},
+		{name: ".h configured out", path: "a.h", content: unguarded, config: `{"rules": {"header-guards": {"parameters": {"header_extensions": [".ipp"]}}}}`},
+		{name: "other extensions", path: "a.c", content: unguarded, config: withIpp},
+	})
+}
+
+func TestHeaderGuardOrder(t *testing.T) {
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &HeaderGuardRule{rulesConfig: rc} }, "header-guards", []ruleCase{
+		{
+			name:    "correct guard",
+			path:    "a.h",
+			content: "// a.h\n#ifndef A_H\n\n#define A_H\n#include <stdio.h>\nint a;\n#endif\n",
+		},
+		{
+			name:    "guard defined after content",
+			path:    "a.h",
+			content: "#ifndef A_H\n#include <stdio.h>\n/* a */\nint a;\n#define A_H\n#endif\n",
+			want:    []string{"5:1"},
+		},
+		{
+			name:    "comments before the define",
+			path:    "a.h",
+			content: "#ifndef A_H\n// guard\n/* for a.h */\n#define A_H\nint a;\n#endif\n",
+		},
+		{
+			name:    "other macro first",
+			path:    "a.h",
+			content: "#ifndef A_H\n#define A_VERSION 2\n#define A_H\n#endif\n",
+			want:    []string{"3:1"},
+		},
+	})
+
+	rule := &HeaderGuardRule{rulesConfig: defaultRulesConfig()}
+	results := rule.Check(NewFileInfo("a.h", []byte("#ifndef A_H\nint a;\n#define A_H\n#endif\n")))
+	if want := "Header guard A_H is defined after content at line 2; move the #define directly below the #ifndef"; len(results) != 1 || results[0].Message != want {
+		t.Errorf("results %v, want one with message %q", results, want)
+	}
+}
+
+func TestLineLengthCommentLimit(t *testing.T) {
+	limits := func(code, comment int) string {
+		return fmt.Sprintf(`{"rules": {"formatting": {"parameters": {"max_line_length": %d, "max_comment_length": %d}}}}`, code, comment)
+	}
+	comment := "// " + strings.Repeat("c", 27) + "\n" // 30 characters
+	code := "int " + strings.Repeat("x", 25) + ";\n"  // 30 characters
+
+	runRuleCases(t, lineLengthRule, "line-length", []ruleCase{
+		{
+			name:    "comments share the code limit by default",
+			content: comment + code,
+			config:  limits(20, 0),
+			want:    []string{"1:21", "2:21"},
+		},
+		{
+			name:    "longer comment budget",
+			content: comment + code,
+			config:  limits(20, 40),
+			want:    []string{"2:21"},
+		},
+		{
+			name:    "stricter comment budget",
+			content: comment + code,
+			config:  limits(40, 20),
+			want:    []string{"1:21"},
+		},
+		{
+			name:    "trailing comments count as code",
+			content: "int x; // " + strings.Repeat("c", 20) + "\n",
+			config:  limits(20, 40),
+			want:    []string{"1:21"},
+		},
+		{
+			name:    "block comment body",
+			content: "/*\n * " + strings.Repeat("c", 27) + "\n */\n",
+			config:  limits(20, 40),
+		},
+	})
+}
+
+func TestNamingConventionSkipsComments(t *testing.T) {
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &NamingConventionRule{rulesConfig: rc} }, "naming-conventions", []ruleCase{
+		{
+			name:    "camelCase function",
+			content: "void doThing(void);\n",
+			want:    []string{"1:1"},
+		},
+		{
+			name:    "block comment body",
+			content: "/*\n * doThing(x);\n * if (x) { callMe(); }\n */\n",
+		},
+		{
+			name:    "code after a block comment",
+			content: "/* doThing(x); */ void runTask(void);\n",
+			want:    []string{"1:1"},
+		},
+		{
+			name:    "string literal",
+			content: "const char *s = \"doThing(x)\";\n",
+		},
+	})
+}
+
+func TestLineLengthRawStrings(t *testing.T) {
+	const exempt = `{"rules": {"formatting": {"parameters": {"max_line_length": 20, "exempt_raw_strings": true}}}}`
+	sql := "SELECT name, email FROM users WHERE id = ?"
+	content := "auto q = R\"sql(\n" + sql + "\n)sql\";\nconst char *s = \"" + sql + "\";\n"
+
+	runRuleCases(t, lineLengthRule, "line-length", []ruleCase{
+		{
+			name:    "checked by default",
+			path:    "q.cpp",
+			content: content,
+			config:  `{"rules": {"formatting": {"parameters": {"max_line_length": 20}}}}`,
+			want:    []string{"2:21", "4:21"},
+		},
+		{
+			name:    "raw strings exempt",
+			path:    "q.cpp",
+			content: content,
+			config:  exempt,
+			want:    []string{"4:21"},
+		},
+		{
+			name:    "overflow after the raw string",
+			path:    "q.cpp",
+			content: "auto q = R\"(a)\"; int " + strings.Repeat("x", 20) + ";\n",
+			config:  exempt,
+			want:    []string{"1:21"},
+		},
+	})
+}
+
+func TestTrailingWhitespaceBlockComments(t *testing.T) {
+	const exempt = `{"rules": {"trailing-whitespace": {"parameters": {"exempt_block_comments": true}}}}`
+	content := "/*  \n *  +--+  \n *  |  |\t\n */\nint x; /* c */ \n// note \nint y; \n"
+
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &TrailingWhitespaceRule{rulesConfig: rc} }, "trailing-whitespace", []ruleCase{
+		{
+			name:    "strict by default",
+			content: content,
+			want:    []string{"1:4", "2:10", "3:9", "5:15", "6:8", "7:7"},
+		},
+		{
+			name:    "block comments exempt",
+			content: content,
+			config:  exempt,
+			want:    []string{"5:15", "6:8", "7:7"},
+		},
+	})
+}
+
+func TestTrailingWhitespaceBlankLines(t *testing.T) {
+	content := "int x; \n   \n\t\nint y;\n\n"
+	tests := []struct {
+		name       string
+		blankLines string
+		want       []string
+	}{
+		{"default", "", []string{"trailing-whitespace 1:7", "trailing-whitespace 2:3", "trailing-whitespace 3:1"}},
+		{"same", "same", []string{"trailing-whitespace 1:7", "trailing-whitespace 2:3", "trailing-whitespace 3:1"}},
+		{"separate", "separate", []string{"trailing-whitespace 1:7", "blank-line-whitespace 2:3", "blank-line-whitespace 3:1"}},
+		{"ignore", "ignore", []string{"trailing-whitespace 1:7"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := ""
+			if tc.blankLines != "" {
+				config = fmt.Sprintf(`{"rules": {"trailing-whitespace": {"parameters": {"blank_lines": %q}}}}`, tc.blankLines)
+			}
+			rule := &TrailingWhitespaceRule{rulesConfig: testRulesConfig(t, config)}
+			var got []string
+			for _, r := range rule.Check(NewFileInfo("test.c", []byte(content))) {
+				got = append(got, fmt.Sprintf("%s %d:%d", r.Rule, r.Line, r.Column))
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("results %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNamingConventionAllow(t *testing.T) {
+	content := "void onClick(int x);\nvoid xmlParseFile(const char *p);\nvoid doThing(void);\n"
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &NamingConventionRule{rulesConfig: rc} }, "naming-conventions", []ruleCase{
+		{
+			name:    "no allow list",
+			content: content,
+			want:    []string{"1:1", "2:1", "3:1"},
+		},
+		{
+			name:    "names and regular expressions",
+			content: content,
+			config:  `{"rules": {"naming-conventions": {"parameters": {"allow": ["onClick", "xml[A-Z]\\w*"]}}}}`,
+			want:    []string{"3:1"},
+		},
+		{
+			name:    "whole names only",
+			content: content,
+			config:  `{"rules": {"naming-conventions": {"parameters": {"allow": ["on", "doThing"]}}}}`,
+			want:    []string{"1:1", "2:1"},
+		},
+		{
+			name:    "invalid expression",
+			content: content,
+			config:  `{"rules": {"naming-conventions": {"parameters": {"allow": ["doThing[", "onClick"]}}}}`,
+			want:    []string{"2:1", "3:1"},
+		},
+		{
+			name:    "C++ files not checked",
+			path:    "a.cpp",
+			content: content,
+		},
+	})
+}
+
+func TestHeaderGuardCollisionRule(t *testing.T) {
+	guard := func(name string) string {
+		return "// header\n#ifndef " + name + "\n#define " + name + "\nint x;\n#endif\n"
+	}
+	tests := []struct {
+		name   string
+		files  map[string]string
+		config string
+		want   []string
+	}{
+		{
+			name:  "distinct guards",
+			files: map[string]string{"a.h": guard("A_H"), "b.h": guard("B_H")},
+		},
+		{
+			name:  "shared guard",
+			files: map[string]string{"a.h": guard("UTIL_H"), "lib/util.h": guard("UTIL_H"), "b.h": guard("B_H")},
+			want:  []string{"a.h:2:1", "lib/util.h:2:1"},
+		},
+		{
+			name:  "guard not directly defined",
+			files: map[string]string{"a.h": guard("A_H"), "b.h": "#ifndef A_H\nint y;\n#endif\n"},
+		},
+		{
+			name:  "sources ignored",
+			files: map[string]string{"a.h": guard("A_H"), "a.c": guard("A_H")},
+		},
+		{
+			name:   "configured header extensions",
+			files:  map[string]string{"a.h": guard("A_H"), "a.hh": guard("A_H")},
+			config: `{"rules": {"header-guards": {"parameters": {"header_extensions": [".h", ".hh"]}}}}`,
+			want:   []string{"a.h:2:1", "a.hh:2:1"},
+		},
+		{
+			name:   "disabled",
+			files:  map[string]string{"a.h": guard("A_H"), "b.h": guard("A_H")},
+			config: `{"rules": {"header-guard-collision": {"enabled": false}}}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rule := &HeaderGuardCollisionRule{rulesConfig: testRulesConfig(t, tc.config)}
+			got := ruleLines(rule.CheckProject(projectFiles(tc.files)), "header-guard-collision")
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("collisions at %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMaxSeverityCap(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{name: "no cap", want: SeverityInfo},
+		{
+			name:   "error capped at warning",
+			config: `{"rules": {"mid-line-tab": {"severity": "error", "parameters": {"max_severity": "warning"}}}}`,
+			want:   SeverityWarning,
+		},
+		{
+			name:   "error capped at info",
+			config: `{"rules": {"mid-line-tab": {"severity": "error", "parameters": {"max_severity": "info"}}}}`,
+			want:   SeverityInfo,
+		},
+		{
+			name:   "cap above the severity",
+			config: `{"rules": {"mid-line-tab": {"severity": "warning", "parameters": {"max_severity": "error"}}}}`,
+			want:   SeverityWarning,
+		},
+		{
+			name:   "unknown cap",
+			config: `{"rules": {"mid-line-tab": {"severity": "error", "parameters": {"max_severity": "fatal"}}}}`,
+			want:   SeverityError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rules := newRulesWithConfig(DefaultConfig(), testRulesConfig(t, tc.config))
+			results := rules.CheckFile(NewFileInfo("test.c", []byte("/* Copyright */\nint x;\t// x\n")))
+			found := false
+			for _, r := range results {
+				if r.Rule != "mid-line-tab" {
+					continue
+				}
+				found = true
+				if r.Severity != tc.want {
+					t.Errorf("severity %s, want %s", r.Severity, tc.want)
+				}
+			}
+			if !found {
+				t.Errorf("no mid-line-tab result in %v", results)
+			}
+		})
+	}
+}
@@ -0,0 +1,1168 @@
+package codelint
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// NonASCIIRule flags non-ASCII characters outside string/char literals
+// and (optionally) comments
+type NonASCIIRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *NonASCIIRule) Name() string {
+	return "formatting"
+}
+
+func (r *NonASCIIRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("non-ascii")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	exemptComments := true
+	if val, ok := ruleConfig.Parameters["exempt_comments"].(bool); ok {
+		exemptComments = val
+	}
+
+	comments, strs := file.commentMask(), file.stringMask()
+	for i, line := range file.Lines {
+		for j := 0; j < len(line); {
+			if line[j] < utf8.RuneSelf {
+				j++
+				continue
+			}
+
+			ch, size := utf8.DecodeRuneInString(line[j:])
+			exempt := strs[i][j] || (comments[i][j] && exemptComments)
+			if !exempt {
+				results = append(results, Result{
+					File:     file.Path,
+					Line:     i + 1,
+					Column:   utf8.RuneCountInString(line[:j]) + 1,
+					Severity: ruleConfig.Severity,
+					Rule:     "non-ascii",
+					Message:  fmt.Sprintf("Non-ASCII character %q (%U)", ch, ch),
+				})
+			}
+			j += size
+		}
+	}
+
+	return results
+}
+
+// EncodingRule flags files whose content is not valid in any of the
+// allowed encodings
+type EncodingRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *EncodingRule) Name() string {
+	return "formatting"
+}
+
+func (r *EncodingRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("encoding")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	allowed := ruleConfig.stringListParam("encodings", []string{"utf-8"})
+	if validEncoding(file.Content, allowed) {
+		return results
+	}
+
+	// Point at the first offending byte in the message; the result itself
+	// is reported at the top of the file
+	at := firstInvalidByte(file.Content, allowed)
+	before, _ := splitLines(string(file.Content[:at]))
+	line := len(before)
+	column := len(before[line-1]) + 1
+	results = append(results, Result{
+		File:     file.Path,
+		Line:     1,
+		Column:   1,
+		Severity: ruleConfig.Severity,
+		Rule:     "encoding",
+		Message: fmt.Sprintf("File is not valid %s (byte 0x%02x at line %d, column %d)",
+			strings.Join(allowed, " or "), file.Content[at], line, column),
+	})
+
+	return results
+}
+
+// hasInvalidEncoding reports whether the encoding rule is enabled and the
+// file fails it
+func (r *EncodingRule) hasInvalidEncoding(file FileInfo) bool {
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("encoding")
+	if !ruleConfig.Enabled {
+		return false
+	}
+	return !validEncoding(file.Content, ruleConfig.stringListParam("encodings", []string{"utf-8"}))
+}
+
+// validEncoding reports whether content is valid in any of the encodings:
+// "utf-8", "ascii", or "latin-1" (which accepts any byte)
+func validEncoding(content []byte, encodings []string) bool {
+	return firstInvalidByte(content, encodings) < 0
+}
+
+// firstInvalidByte returns the offset of an invalid byte if content is
+// invalid in every one of the known encodings, or -1. Unknown encoding
+// names are ignored.
+func firstInvalidByte(content []byte, encodings []string) int {
+	first := -1
+	for _, encoding := range encodings {
+		at := -1
+		switch strings.ToLower(encoding) {
+		case "utf-8", "utf8":
+			for i := 0; i < len(content); {
+				ch, size := utf8.DecodeRune(content[i:])
+				if ch == utf8.RuneError && size <= 1 {
+					at = i
+					break
+				}
+				i += size
+			}
+		case "ascii":
+			for i, c := range content {
+				if c >= utf8.RuneSelf {
+					at = i
+					break
+				}
+			}
+		case "latin-1", "latin1", "iso-8859-1":
+			// Every byte is a Latin-1 character
+		default:
+			continue
+		}
+		if at < 0 {
+			return -1
+		}
+		if at > first {
+			first = at
+		}
+	}
+	return first
+}
+
+// EmptyFileRule flags files with no content other than whitespace, which
+// are usually accidents or leftovers
+type EmptyFileRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *EmptyFileRule) Name() string {
+	return "formatting"
+}
+
+func (r *EmptyFileRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("empty-file")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	// Some projects keep intentionally empty umbrella headers
+	if val, ok := ruleConfig.Parameters["exempt_headers"].(bool); ok && val {
+		if strings.HasSuffix(file.Path, ".h") || strings.HasSuffix(file.Path, ".hpp") {
+			return results
+		}
+	}
+
+	if len(bytes.TrimSpace(file.Content)) > 0 {
+		return results
+	}
+
+	message := "File is empty"
+	if len(file.Content) > 0 {
+		message = "File contains only whitespace"
+	}
+	results = append(results, Result{
+		File:     file.Path,
+		Line:     1,
+		Column:   1,
+		Severity: ruleConfig.Severity,
+		Rule:     "empty-file",
+		Message:  message,
+	})
+
+	return results
+}
+
+// LeadingBlankLinesRule flags blank lines at the top of a file, before the
+// license header or include guard, and removes them as a fix
+type LeadingBlankLinesRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *LeadingBlankLinesRule) Name() string {
+	return "formatting"
+}
+
+func (r *LeadingBlankLinesRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("leading-blank-lines")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	blank := 0
+	for blank < len(file.Lines) && strings.TrimSpace(file.Lines[blank]) == "" {
+		blank++
+	}
+	// Files with nothing but whitespace are left to the empty-file rule
+	if blank == 0 || blank == len(file.Lines) {
+		return results
+	}
+
+	results = append(results, Result{
+		File:     file.Path,
+		Line:     1,
+		Column:   1,
+		Severity: ruleConfig.Severity,
+		Rule:     "leading-blank-lines",
+		Message:  fmt.Sprintf("File starts with %d blank line(s)", blank),
+		Fix:      &Fix{StartLine: 1, EndLine: blank, Replacement: []string{}},
+	})
+
+	return results
+}
+
+// includeDirective matches an #include line, capturing the opening
+// delimiter and the path
+var includeDirective = regexp.MustCompile(`^#\s*include\s*([<"])([^>"]+)[>"]`)
+
+// systemHeaders are the C standard and common POSIX headers, which are
+// included with angle brackets
+var systemHeaders = map[string]bool{
+	"assert.h": true, "complex.h": true, "ctype.h": true, "errno.h": true, "fenv.h": true,
+	"float.h": true, "inttypes.h": true, "iso646.h": true, "limits.h": true, "locale.h": true,
+	"math.h": true, "setjmp.h": true, "signal.h": true, "stdalign.h": true, "stdarg.h": true,
+	"stdatomic.h": true, "stdbool.h": true, "stddef.h": true, "stdint.h": true, "stdio.h": true,
+	"stdlib.h": true, "stdnoreturn.h": true, "string.h": true, "tgmath.h": true, "threads.h": true,
+	"time.h": true, "uchar.h": true, "wchar.h": true, "wctype.h": true,
+	"dirent.h": true, "dlfcn.h": true, "fcntl.h": true, "netdb.h": true, "poll.h": true,
+	"pthread.h": true, "sched.h": true, "semaphore.h": true, "strings.h": true, "syslog.h": true,
+	"termios.h": true, "unistd.h": true,
+}
+
+// IncludeStyleRule requires quotes for project includes and angle brackets
+// for system includes
+type IncludeStyleRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *IncludeStyleRule) Name() string {
+	return "formatting"
+}
+
+func (r *IncludeStyleRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("include-style")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	prefixes := ruleConfig.stringListParam("project_prefixes", nil)
+	extraSystem := make(map[string]bool)
+	for _, header := range ruleConfig.stringListParam("system_headers", nil) {
+		extraSystem[header] = true
+	}
+
+	mask := file.commentMask()
+	for i, line := range file.Lines {
+		m := includeDirective.FindStringSubmatch(strings.TrimSpace(blankMasked(line, mask[i])))
+		if m == nil {
+			continue
+		}
+		quoted, path := m[1] == `"`, m[2]
+
+		// Extensionless headers without a directory are the C++ standard
+		// library (<vector>, <string>)
+		system := systemHeaders[path] || extraSystem[path] ||
+			(!strings.Contains(path, "/") && !strings.Contains(path, "."))
+		project := !system && (!strings.Contains(path, "/") || hasAnyPrefix(path, prefixes))
+
+		var message string
+		if quoted && system {
+			message = fmt.Sprintf("System header should be included as <%s>", path)
+		} else if !quoted && project {
+			message = fmt.Sprintf("Project header should be included as \"%s\"", path)
+		}
+		if message != "" {
+			results = append(results, Result{
+				File:     file.Path,
+				Line:     i + 1,
+				Column:   1,
+				Severity: ruleConfig.Severity,
+				Rule:     "include-style",
+				Message:  message,
+			})
+		}
+	}
+
+	return results
+}
+
+// hasAnyPrefix reports whether s starts with any of the prefixes
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// IncludeCountRule flags files with more #include directives than
+// max_includes, a sign that the file does too much
+type IncludeCountRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *IncludeCountRule) Name() string {
+	return "readability"
+}
+
+func (r *IncludeCountRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	// Off unless the rules configuration enables it explicitly
+	ruleConfig, exists := r.rulesConfig.GetRuleConfig("max-includes")
+	if !exists || !ruleConfig.Enabled {
+		return results
+	}
+
+	maxIncludes := 40
+	if val, ok := ruleConfig.Parameters["max_includes"].(float64); ok {
+		maxIncludes = int(val)
+	}
+
+	count := 0
+	mask := file.commentMask()
+	for i, line := range file.Lines {
+		if includeDirective.MatchString(strings.TrimSpace(blankMasked(line, mask[i]))) {
+			count++
+		}
+	}
+
+	if count > maxIncludes {
+		results = append(results, Result{
+			File:     file.Path,
+			Line:     1,
+			Column:   1,
+			Severity: ruleConfig.Severity,
+			Rule:     "max-includes",
+			Message:  fmt.Sprintf("File has %d includes (max %d)", count, maxIncludes),
+		})
+	}
+
+	return results
+}
+
+// BannedIncludeRule enforces layering by flagging #include directives that
+// match one of the banned_includes globs, unless the including file is
+// under one of the paths listed for that glob in except
+type BannedIncludeRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *BannedIncludeRule) Name() string {
+	return "readability"
+}
+
+func (r *BannedIncludeRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("banned-include")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	banned := ruleConfig.stringListParam("banned_includes", nil)
+	if len(banned) == 0 {
+		return results
+	}
+	except, _ := ruleConfig.Parameters["except"].(map[string]interface{})
+
+	// Patterns this file is allowed to include anyway
+	path := filepath.ToSlash(file.Path)
+	var active []*regexp.Regexp
+	var patterns []string
+	for _, pattern := range banned {
+		allowed := RuleConfig{Parameters: except}.stringListParam(pattern, nil)
+		if matchesAnyGlob(path, allowed, "(?:/|$)") {
+			continue
+		}
+		re, err := regexp.Compile(globPrefixExpr(pattern) + "$")
+		if err != nil {
+			continue
+		}
+		active = append(active, re)
+		patterns = append(patterns, pattern)
+	}
+
+	mask := file.commentMask()
+	for i, line := range file.Lines {
+		m := includeDirective.FindStringSubmatch(strings.TrimSpace(blankMasked(line, mask[i])))
+		if m == nil {
+			continue
+		}
+		for k, re := range active {
+			if !re.MatchString(m[2]) {
+				continue
+			}
+			results = append(results, Result{
+				File:     file.Path,
+				Line:     i + 1,
+				Column:   1,
+				Severity: ruleConfig.Severity,
+				Rule:     "banned-include",
+				Message:  fmt.Sprintf("Include of %s is not allowed here (banned by %s)", m[2], patterns[k]),
+			})
+			break
+		}
+	}
+
+	return results
+}
+
+// OwnHeaderFirstRule requires a source file's first #include to be its own
+// header (foo.cc includes "foo.h" first), so that a header missing an
+// include of its own fails to compile in at least one place
+type OwnHeaderFirstRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *OwnHeaderFirstRule) Name() string {
+	return "readability"
+}
+
+func (r *OwnHeaderFirstRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	// Off unless the rules configuration enables it explicitly
+	ruleConfig, exists := r.rulesConfig.GetRuleConfig("own-header-first")
+	if !exists || !ruleConfig.Enabled {
+		return results
+	}
+
+	path := filepath.ToSlash(file.Path)
+	sources := ruleConfig.stringListParam("source_extensions", []string{".c", ".cc", ".cpp"})
+	if !hasHeaderExtension(path, sources) {
+		return results
+	}
+	exempt := ruleConfig.stringListParam("exempt_files", []string{"main.c", "main.cc", "main.cpp"})
+	if matchesAnyGlob(path, exempt, "$") {
+		return results
+	}
+
+	// foo_test.cc tests foo.h
+	name := strings.TrimSuffix(filepath.Base(file.Path), filepath.Ext(file.Path))
+	for _, suffix := range ruleConfig.stringListParam("strip_suffixes", []string{"_test", "_unittest"}) {
+		if suffix != "" && strings.HasSuffix(name, suffix) && len(name) > len(suffix) {
+			name = strings.TrimSuffix(name, suffix)
+			break
+		}
+	}
+	var headers []string
+	for _, pattern := range ruleConfig.stringListParam("header_names", []string{"{name}.h", "{name}.hpp"}) {
+		headers = append(headers, strings.ReplaceAll(pattern, "{name}", name))
+	}
+	if len(headers) == 0 {
+		return results
+	}
+
+	mask := file.commentMask()
+	for i, line := range file.Lines {
+		m := includeDirective.FindStringSubmatch(strings.TrimSpace(blankMasked(line, mask[i])))
+		if m == nil {
+			continue
+		}
+		for _, header := range headers {
+			if m[2] == header || strings.HasSuffix(m[2], "/"+header) {
+				return results
+			}
+		}
+
+		results = append(results, Result{
+			File:     file.Path,
+			Line:     i + 1,
+			Column:   1,
+			Severity: ruleConfig.Severity,
+			Rule:     "own-header-first",
+			Message:  fmt.Sprintf("First include is %s; include the file's own header (%s) first", m[2], strings.Join(headers, " or ")),
+		})
+		break
+	}
+
+	return results
+}
+
+// IncludeDepthRule builds the graph of quoted #include directives between
+// the files of a run and flags headers whose transitive include chain is
+// deeper than max_depth, since each level adds to the build time of every
+// file including them
+type IncludeDepthRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *IncludeDepthRule) Name() string {
+	return "readability"
+}
+
+// Check does nothing; the include graph is built by CheckProject
+func (r *IncludeDepthRule) Check(file FileInfo) []Result {
+	return nil
+}
+
+func (r *IncludeDepthRule) CheckProject(files []FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	// Off unless the rules configuration enables it explicitly
+	ruleConfig, exists := r.rulesConfig.GetRuleConfig("include-depth")
+	if !exists || !ruleConfig.Enabled {
+		return results
+	}
+
+	maxDepth := 5
+	if val, ok := ruleConfig.Parameters["max_depth"].(float64); ok {
+		maxDepth = int(val)
+	}
+
+	graph := buildIncludeGraph(files)
+	included := make(map[string]bool)
+	for _, edges := range graph {
+		for _, edge := range edges {
+			included[edge.target] = true
+		}
+	}
+
+	// Depth is the longest chain of includes below a file; a cycle, which
+	// the guards cut short, doesn't add to it
+	depth := make(map[string]int)
+	deepest := make(map[string]includeEdge)
+	state := make(map[string]int)
+	var visit func(path string) int
+	visit = func(path string) int {
+		switch state[path] {
+		case 1:
+			return 0
+		case 2:
+			return depth[path]
+		}
+		state[path] = 1
+		for _, edge := range graph[path] {
+			if d := visit(edge.target) + 1; d > depth[path] {
+				depth[path] = d
+				deepest[path] = edge
+			}
+		}
+		state[path] = 2
+		return depth[path]
+	}
+
+	for _, file := range files {
+		path := filepath.ToSlash(file.Path)
+		if !included[path] || visit(path) <= maxDepth {
+			continue
+		}
+
+		chain := []string{path}
+		for p := path; len(chain) <= depth[path]; {
+			p = deepest[p].target
+			chain = append(chain, p)
+		}
+		results = append(results, Result{
+			File:     file.Path,
+			Line:     deepest[path].line + 1,
+			Column:   1,
+			Severity: ruleConfig.Severity,
+			Rule:     "include-depth",
+			Message:  fmt.Sprintf("Header includes other headers %d levels deep (max %d): %s", depth[path], maxDepth, strings.Join(chain, " -> ")),
+		})
+	}
+
+	return results
+}
+
+// includeEdge is a quoted #include resolved to a file of the run
+type includeEdge struct {
+	target string
+	// line is the 0-based line of the directive
+	line int
+}
+
+// buildIncludeGraph maps each file's slash-separated path to the files its
+// quoted includes resolve to: relative to the including file's directory
+// first, then any file whose path ends with the include path. System
+// includes and includes of files outside the run are left out.
+func buildIncludeGraph(files []FileInfo) map[string][]includeEdge {
+	known := make(map[string]bool)
+	for _, file := range files {
+		known[filepath.ToSlash(file.Path)] = true
+	}
+	resolve := func(from, include string) string {
+		if candidate := path.Join(path.Dir(from), include); known[candidate] {
+			return candidate
+		}
+		for _, file := range files {
+			candidate := filepath.ToSlash(file.Path)
+			if candidate == include || strings.HasSuffix(candidate, "/"+include) {
+				return candidate
+			}
+		}
+		return ""
+	}
+
+	graph := make(map[string][]includeEdge)
+	for _, file := range files {
+		from := filepath.ToSlash(file.Path)
+		mask := file.commentMask()
+		for i, line := range file.Lines {
+			m := includeDirective.FindStringSubmatch(strings.TrimSpace(blankMasked(line, mask[i])))
+			if m == nil || m[1] != `"` {
+				continue
+			}
+			if target := resolve(from, m[2]); target != "" && target != from {
+				graph[from] = append(graph[from], includeEdge{target: target, line: i})
+			}
+		}
+	}
+	return graph
+}
+
+// matchesAnyGlob reports whether path matches any of the gitignore-style
+// globs, with suffix appended to each glob's expression
+func matchesAnyGlob(path string, globs []string, suffix string) bool {
+	for _, glob := range globs {
+		glob = strings.TrimRight(glob, "/")
+		if re, err := regexp.Compile(globPrefixExpr(glob) + suffix); err == nil && re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// IndentWidthRule checks that space indentation is a multiple of the
+// configured indent width
+type IndentWidthRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *IndentWidthRule) Name() string {
+	return "formatting"
+}
+
+func (r *IndentWidthRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("indent-width")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	indentWidth := 4
+	if val, ok := ruleConfig.Parameters["indent_width"].(float64); ok && val > 0 {
+		indentWidth = int(val)
+	}
+
+	mask := file.commentMask()
+	continuation := false
+	parenDepth := 0
+	for i, line := range file.Lines {
+		// Skip blank lines and comments, whose alignment is free-form
+		if strings.TrimSpace(line) == "" || isCommentOnly(line, mask[i]) {
+			continue
+		}
+
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		isContinuation := continuation || parenDepth > 0
+
+		// Remember whether the next line continues this one
+		code := strings.TrimSpace(blankMasked(line, mask[i]))
+		parenDepth += strings.Count(code, "(") - strings.Count(code, ")")
+		if parenDepth < 0 {
+			parenDepth = 0
+		}
+		continuation = hasContinuationSuffix(code)
+
+		// Tab-indented lines are left to the tabs check
+		if isContinuation || strings.HasPrefix(line[indent:], "\t") {
+			continue
+		}
+
+		if indent%indentWidth != 0 {
+			results = append(results, Result{
+				File:     file.Path,
+				Line:     i + 1,
+				Column:   1,
+				Severity: ruleConfig.Severity,
+				Rule:     "indent-width",
+				Message:  fmt.Sprintf("Indentation of %d spaces is not a multiple of %d", indent, indentWidth),
+			})
+		}
+	}
+
+	return results
+}
+
+// IndentColumnRule flags lines indented past max_indent_column once tabs
+// are expanded to tab_width columns, a cheap sign of deep nesting
+type IndentColumnRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *IndentColumnRule) Name() string {
+	return "formatting"
+}
+
+func (r *IndentColumnRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("max-indent")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	maxColumn, tabWidth := 40, 4
+	if val, ok := ruleConfig.Parameters["max_indent_column"].(float64); ok {
+		maxColumn = int(val)
+	}
+	if val, ok := ruleConfig.Parameters["tab_width"].(float64); ok && val > 0 {
+		tabWidth = int(val)
+	}
+
+	mask := file.commentMask()
+	for i, line := range file.Lines {
+		// Comments may be indented to line up with anything
+		if strings.TrimSpace(line) == "" || isCommentOnly(line, mask[i]) {
+			continue
+		}
+
+		column := indentColumn(line, tabWidth)
+		if column <= maxColumn {
+			continue
+		}
+
+		results = append(results, Result{
+			File:     file.Path,
+			Line:     i + 1,
+			Column:   1,
+			Severity: ruleConfig.Severity,
+			Rule:     "max-indent",
+			Message:  fmt.Sprintf("Line is indented %d columns (max %d); consider extracting the nested code", column, maxColumn),
+		})
+	}
+
+	return results
+}
+
+// hasContinuationSuffix reports whether a line of code ends in a way that
+// implies the statement continues on the next line
+func hasContinuationSuffix(code string) bool {
+	for _, suffix := range []string{",", "\\", "&&", "||", "+", "-", "*", "/", "=", "?", ":", "<<", ">>"} {
+		if strings.HasSuffix(code, suffix) {
+			// A trailing ':' on a label or access specifier is not a continuation
+			if suffix == ":" && !strings.HasSuffix(code, "::") {
+				continue
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// MidLineTabRule flags tabs after the first non-whitespace character of a
+// line, which only line up at one particular tab width
+type MidLineTabRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *MidLineTabRule) Name() string {
+	return "formatting"
+}
+
+func (r *MidLineTabRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("mid-line-tab")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	fixSpaces := 1
+	if val, ok := ruleConfig.Parameters["fix_spaces"].(float64); ok && val >= 1 {
+		fixSpaces = int(val)
+	}
+
+	strs := file.stringMask()
+	for i, line := range file.Lines {
+		start := len(leadingWhitespace(line))
+
+		// Tabs inside string literals are part of the value
+		var tabs []int
+		for j := start; j < len(line); j++ {
+			if line[j] == '\t' && !strs[i][j] {
+				tabs = append(tabs, j)
+			}
+		}
+		if len(tabs) == 0 {
+			continue
+		}
+
+		// The fix replaces every flagged tab on the line
+		var fixed strings.Builder
+		last := 0
+		for _, j := range tabs {
+			fixed.WriteString(line[last:j])
+			fixed.WriteString(strings.Repeat(" ", fixSpaces))
+			last = j + 1
+		}
+		fixed.WriteString(line[last:])
+
+		for _, j := range tabs {
+			results = append(results, Result{
+				File:     file.Path,
+				Line:     i + 1,
+				Column:   j + 1,
+				Severity: ruleConfig.Severity,
+				Rule:     "mid-line-tab",
+				Message:  "Tab after code assumes a fixed tab width",
+				Fix:      lineFix(i, fixed.String()),
+			})
+		}
+	}
+
+	return results
+}
+
+// NamespaceIndentRule checks whether namespace contents are indented,
+// according to the indent_namespace parameter
+type NamespaceIndentRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *NamespaceIndentRule) Name() string {
+	return "formatting"
+}
+
+func (r *NamespaceIndentRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("namespace-indentation")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	indentNamespace := false
+	if val, ok := ruleConfig.Parameters["indent_namespace"].(bool); ok {
+		indentNamespace = val
+	}
+
+	structure := scanStructure(codeLines(file.Lines))
+
+	// Collect the first line of every declaration directly inside a namespace
+	var candidates []int
+	for _, fn := range structure.Functions {
+		if fn.Scope == blockNamespace {
+			candidates = append(candidates, fn.StartLine)
+		}
+	}
+	for _, stmt := range structure.Statements {
+		if stmt.Scope == blockNamespace {
+			candidates = append(candidates, stmt.Line)
+		}
+	}
+	for _, block := range structure.Blocks {
+		if block.Kind != blockFunction && block.Depth > 0 {
+			candidates = append(candidates, declarationStart(file.Lines, block.StartLine))
+		}
+	}
+	sort.Ints(candidates)
+
+	reported := make(map[int]bool)
+	for _, line := range candidates {
+		// Find the innermost namespace holding this line
+		var ns *codeBlock
+		for i := range structure.Blocks {
+			b := &structure.Blocks[i]
+			if b.Kind == blockNamespace && b.StartLine < line && b.EndLine > line &&
+				(ns == nil || b.Depth > ns.Depth) {
+				ns = b
+			}
+		}
+		if ns == nil || reported[ns.StartLine] {
+			continue
+		}
+
+		// Only declarations directly inside the namespace count
+		direct := true
+		for _, b := range structure.Blocks {
+			if b.Kind != blockNamespace && b.Depth > ns.Depth && b.StartLine < line && b.EndLine > line &&
+				b.StartLine > ns.StartLine {
+				direct = false
+				break
+			}
+		}
+		if !direct {
+			continue
+		}
+
+		nsIndent := leadingWhitespace(file.Lines[declarationStart(file.Lines, ns.StartLine)])
+		indent := leadingWhitespace(file.Lines[line])
+		var message string
+		if indentNamespace && len(indent) <= len(nsIndent) {
+			message = "Namespace contents should be indented"
+		} else if !indentNamespace && len(indent) != len(nsIndent) {
+			message = "Namespace contents should not be indented"
+		}
+		if message != "" {
+			reported[ns.StartLine] = true
+			results = append(results, Result{
+				File:     file.Path,
+				Line:     line + 1,
+				Column:   1,
+				Severity: ruleConfig.Severity,
+				Rule:     "namespace-indentation",
+				Message:  message,
+			})
+		}
+	}
+
+	return results
+}
+
+// leadingWhitespace returns the indentation prefix of a line
+func leadingWhitespace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// indentColumn returns the column at which a line's indentation ends, with
+// tabs advancing to the next multiple of tabWidth
+func indentColumn(line string, tabWidth int) int {
+	column := 0
+	for _, c := range leadingWhitespace(line) {
+		if c == '\t' {
+			column += tabWidth - column%tabWidth
+		} else {
+			column++
+		}
+	}
+	return column
+}
+
+// TabAlignmentRule flags lines indented with tabs followed by spaces, which
+// only line up at one particular tab width
+type TabAlignmentRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *TabAlignmentRule) Name() string {
+	return "formatting"
+}
+
+func (r *TabAlignmentRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("tab-alignment")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	mask := file.commentMask()
+	for i, line := range file.Lines {
+		// Comment-only lines (e.g. " * " block comment bodies) are exempt
+		if strings.TrimSpace(line) == "" || isCommentOnly(line, mask[i]) {
+			continue
+		}
+
+		indent := leadingWhitespace(line)
+		tabs := len(indent) - len(strings.TrimLeft(indent, "\t"))
+		if tabs == 0 || tabs == len(indent) || strings.Contains(indent[tabs:], "\t") {
+			continue
+		}
+
+		results = append(results, Result{
+			File:     file.Path,
+			Line:     i + 1,
+			Column:   tabs + 1,
+			Severity: ruleConfig.Severity,
+			Rule:     "tab-alignment",
+			Message:  "Spaces after tab indentation assume a fixed tab width",
+		})
+	}
+
+	return results
+}
+
+// KeywordSpaceRule requires a space between control keywords and '('
+type KeywordSpaceRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *KeywordSpaceRule) Name() string {
+	return "formatting"
+}
+
+func (r *KeywordSpaceRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("keyword-space")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	keywords := ruleConfig.stringListParam("keywords", []string{"if", "for", "while", "switch"})
+	if len(keywords) == 0 {
+		return results
+	}
+	quoted := make([]string, len(keywords))
+	for i, kw := range keywords {
+		quoted[i] = regexp.QuoteMeta(kw)
+	}
+	pattern := regexp.MustCompile(`\b(` + strings.Join(quoted, "|") + `)\(`)
+
+	code := codeLines(file.Lines)
+	for i, line := range code {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		matches := pattern.FindAllStringSubmatchIndex(line, -1)
+		if len(matches) == 0 {
+			continue
+		}
+
+		// The fix inserts the space at every occurrence on the line
+		fixed := file.Lines[i]
+		for j := len(matches) - 1; j >= 0; j-- {
+			at := matches[j][3]
+			fixed = fixed[:at] + " " + fixed[at:]
+		}
+
+		for _, m := range matches {
+			results = append(results, Result{
+				File:     file.Path,
+				Line:     i + 1,
+				Column:   m[2] + 1,
+				Severity: ruleConfig.Severity,
+				Rule:     "keyword-space",
+				Message:  fmt.Sprintf("Missing space between '%s' and '('", line[m[2]:m[3]]),
+				Fix:      lineFix(i, fixed),
+			})
+		}
+	}
+
+	return results
+}
+
+// FunctionSpacingRule checks the number of blank lines between
+// consecutive top-level function definitions against min_blank_lines and
+// max_blank_lines. A comment before the next definition belongs to it.
+type FunctionSpacingRule struct {
+	rulesConfig *RulesConfig
+}
+
+func (r *FunctionSpacingRule) Name() string {
+	return "formatting"
+}
+
+func (r *FunctionSpacingRule) Check(file FileInfo) []Result {
+	var results []Result
+
+	// Get rule configuration
+	ruleConfig, _ := r.rulesConfig.GetRuleConfig("function-spacing")
+	if !ruleConfig.Enabled {
+		return results
+	}
+
+	minBlank, maxBlank := 1, 2
+	if val, ok := ruleConfig.Parameters["min_blank_lines"].(float64); ok {
+		minBlank = int(val)
+	}
+	if val, ok := ruleConfig.Parameters["max_blank_lines"].(float64); ok {
+		maxBlank = int(val)
+	}
+
+	var defs []functionSignature
+	for _, fn := range scanStructure(codeLines(file.Lines)).Functions {
+		if fn.Definition && isTopLevelScope(fn.Scope) {
+			defs = append(defs, fn)
+		}
+	}
+	sort.Slice(defs, func(i, j int) bool {
+		return defs[i].StartLine < defs[j].StartLine
+	})
+
+	mask := file.commentMask()
+	for k := 1; k < len(defs); k++ {
+		prevEnd, next := defs[k-1].Body.EndLine, defs[k].StartLine
+		if next <= prevEnd {
+			continue
+		}
+
+		// Count the blank lines after the closing brace; anything other
+		// than blank lines and the next function's comment in between
+		// (declarations, preprocessor lines) means these aren't neighbours
+		blank := 0
+		for blank < next-prevEnd-1 && strings.TrimSpace(file.Lines[prevEnd+1+blank]) == "" {
+			blank++
+		}
+		neighbours := true
+		for i := prevEnd + 1 + blank; i < next; i++ {
+			if strings.TrimSpace(file.Lines[i]) != "" && !isCommentOnly(file.Lines[i], mask[i]) {
+				neighbours = false
+				break
+			}
+		}
+		if !neighbours || (blank >= minBlank && blank <= maxBlank) {
+			continue
+		}
+
+		expected := fmt.Sprintf("%d to %d", minBlank, maxBlank)
+		if minBlank == maxBlank {
+			expected = fmt.Sprintf("%d", minBlank)
+		}
+		results = append(results, Result{
+			File:     file.Path,
+			Line:     prevEnd + 2,
+			Column:   1,
+			Severity: ruleConfig.Severity,
+			Rule:     "function-spacing",
+			Message:  fmt.Sprintf("%d blank lines between %s and %s (expected %s)", blank, defs[k-1].Name, defs[k].Name, expected),
+		})
+	}
+
+	return results
+}
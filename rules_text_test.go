@@ -0,0 +1,728 @@
+package codelint
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNonASCIIRule(t *testing.T) {
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &NonASCIIRule{rulesConfig: rc} }, "non-ascii", []ruleCase{
+		{
+			name:    "ascii only",
+			content: "int x = 1;\n",
+		},
+		{
+			name:    "identifier",
+			content: "int café = 1;\n",
+			want:    []string{"1:8"},
+		},
+		{
+			name:    "column counts characters",
+			content: "int éé = 1;\n",
+			want:    []string{"1:5", "1:6"},
+		},
+		{
+			name:    "string literal",
+			content: "const char *s = \"café\";\n",
+		},
+		{
+			name:    "comment exempt by default",
+			content: "// café\nint x;\n",
+		},
+		{
+			name:    "comment checked",
+			content: "// café\nint x;\n",
+			config:  `{"rules": {"non-ascii": {"parameters": {"exempt_comments": false}}}}`,
+			want:    []string{"1:7"},
+		},
+		{
+			name:    "disabled",
+			content: "int café = 1;\n",
+			config:  `{"rules": {"non-ascii": {"enabled": false}}}`,
+		},
+	})
+}
+
+func TestIndentWidthRule(t *testing.T) {
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &IndentWidthRule{rulesConfig: rc} }, "indent-width", []ruleCase{
+		{
+			name:    "multiples of four",
+			content: "void f(void) {\n    if (x) {\n        g();\n    }\n}\n",
+		},
+		{
+			name:    "three spaces",
+			content: "void f(void) {\n   g();\n}\n",
+			want:    []string{"2:1"},
+		},
+		{
+			name:    "configured width",
+			content: "void f(void) {\n  g();\n   h();\n}\n",
+			config:  `{"rules": {"indent-width": {"parameters": {"indent_width": 2}}}}`,
+			want:    []string{"3:1"},
+		},
+		{
+			name:    "continuation lines",
+			content: "void f(void) {\n    g(a,\n      b);\n    int x = 1 +\n      2;\n}\n",
+		},
+		{
+			name:    "block comment body",
+			content: "/*\n * comment\n */\nint x;\n",
+		},
+		{
+			name:    "tab indentation left to the tabs check",
+			content: "void f(void) {\n\t g();\n}\n",
+		},
+	})
+}
+
+func TestNamespaceIndentRule(t *testing.T) {
+	const indented = `{"rules": {"namespace-indentation": {"parameters": {"indent_namespace": true}}}}`
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &NamespaceIndentRule{rulesConfig: rc} }, "namespace-indentation", []ruleCase{
+		{
+			name:    "flush contents",
+			path:    "a.cpp",
+			content: "namespace app {\nint x;\nvoid f() {\n    g();\n}\n}\n",
+		},
+		{
+			name:    "indented contents",
+			path:    "a.cpp",
+			content: "namespace app {\n    int x;\n}\n",
+			want:    []string{"2:1"},
+		},
+		{
+			name:    "one result per namespace",
+			path:    "a.cpp",
+			content: "namespace app {\n    int x;\n    int y;\n}\n",
+			want:    []string{"2:1"},
+		},
+		{
+			name:    "indentation required",
+			path:    "a.cpp",
+			content: "namespace app {\n    int x;\n    void f() {\n        g();\n    }\n}\n",
+			config:  indented,
+		},
+		{
+			name:    "indentation missing",
+			path:    "a.cpp",
+			content: "namespace app {\nint x;\n}\n",
+			config:  indented,
+			want:    []string{"2:1"},
+		},
+		{
+			name:    "class members not checked",
+			path:    "a.cpp",
+			content: "namespace app {\nclass Widget {\n    int size;\n};\n}\n",
+		},
+	})
+}
+
+func TestTabAlignmentRule(t *testing.T) {
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &TabAlignmentRule{rulesConfig: rc} }, "tab-alignment", []ruleCase{
+		{
+			name:    "tab indentation",
+			content: "void f(void) {\n\tif (x) {\n\t\tg();\n\t}\n}\n",
+		},
+		{
+			name:    "space indentation",
+			content: "void f(void) {\n    g();\n}\n",
+		},
+		{
+			name:    "tab then spaces",
+			content: "void f(void) {\n\tg(a,\n\t  b);\n\t\t   h();\n}\n",
+			want:    []string{"3:2", "4:3"},
+		},
+		{
+			name:    "spaces then tab",
+			content: "void f(void) {\n  \tg();\n}\n",
+		},
+		{
+			name:    "block comment body",
+			content: "\t/*\n\t * comment\n\t */\n\tint x;\n",
+		},
+		{
+			name:    "disabled",
+			content: "void f(void) {\n\t  g();\n}\n",
+			config:  `{"rules": {"tab-alignment": {"enabled": false}}}`,
+		},
+	})
+}
+
+func TestKeywordSpaceRule(t *testing.T) {
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &KeywordSpaceRule{rulesConfig: rc} }, "keyword-space", []ruleCase{
+		{
+			name:    "space after keyword",
+			content: "void f(int x) {\n    if (x) {\n        while (x--) g();\n    }\n}\n",
+		},
+		{
+			name:    "missing space",
+			content: "void f(int x) {\n    if(x) {\n        for(;;) g();\n    }\n}\n",
+			want:    []string{"2:5", "3:9"},
+		},
+		{
+			name:    "several on one line",
+			content: "void f(int x) {\n    if(x) while(x--) g();\n}\n",
+			want:    []string{"2:5", "2:11"},
+		},
+		{
+			name:    "identifiers containing keywords",
+			content: "void f(int x) {\n    ifdef(x);\n    my_if(x);\n    forEach(x);\n}\n",
+		},
+		{
+			name:    "strings, comments and directives",
+			content: "#if(DEBUG)\n// if(x)\nconst char *s = \"if(x)\";\n",
+		},
+		{
+			name:    "configured keywords",
+			content: "void f(int x) {\n    if(x) return(x);\n}\n",
+			config:  `{"rules": {"keyword-space": {"parameters": {"keywords": ["return"]}}}}`,
+			want:    []string{"2:11"},
+		},
+	})
+}
+
+func TestKeywordSpaceFix(t *testing.T) {
+	rule := &KeywordSpaceRule{rulesConfig: defaultRulesConfig()}
+	results := rule.Check(NewFileInfo("test.c", []byte("int f(int x) {\n    if(x) while(x--) g();\n}\n")))
+	if len(results) == 0 || results[0].Fix == nil {
+		t.Fatalf("no fix in %v", results)
+	}
+	want := Fix{StartLine: 2, EndLine: 2, Replacement: []string{"    if (x) while (x--) g();"}}
+	if got := *results[0].Fix; !reflect.DeepEqual(got, want) {
+		t.Errorf("fix %+v, want %+v", got, want)
+	}
+}
+
+func TestEncodingRule(t *testing.T) {
+	const latin1 = "/* caf\xe9 */\nint x;\n"
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &EncodingRule{rulesConfig: rc} }, "encoding", []ruleCase{
+		{
+			name:    "valid UTF-8",
+			content: "/* café */\nint x;\n",
+		},
+		{
+			name:    "Latin-1 bytes",
+			content: latin1,
+			want:    []string{"1:1"},
+		},
+		{
+			name:    "Latin-1 allowed",
+			content: latin1,
+			config:  `{"rules": {"encoding": {"parameters": {"encodings": ["utf-8", "latin-1"]}}}}`,
+		},
+		{
+			name:    "ASCII only",
+			content: "/* café */\n",
+			config:  `{"rules": {"encoding": {"parameters": {"encodings": ["ascii"]}}}}`,
+			want:    []string{"1:1"},
+		},
+	})
+
+	rule := &EncodingRule{rulesConfig: defaultRulesConfig()}
+	results := rule.Check(NewFileInfo("test.c", []byte("int x;\nint y; /* \xe9t\xe9 */\n")))
+	if want := "File is not valid utf-8 (byte 0xe9 at line 2, column 11)"; len(results) != 1 || results[0].Message != want {
+		t.Errorf("results %v, want one with message %q", results, want)
+	}
+}
+
+func TestEncodingSkipOtherChecks(t *testing.T) {
+	content := []byte("int x; /* caf\xe9 */ \n")
+	tests := []struct {
+		name   string
+		config string
+		want   []string
+	}{
+		{"other checks run by default", "", []string{"encoding", "trailing-whitespace"}},
+		{"other checks skipped", `{"rules": {"encoding": {"parameters": {"skip_other_checks": true}}}}`, []string{"encoding"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rules := newRulesWithConfig(DefaultConfig(), testRulesConfig(t, tc.config))
+			found := make(map[string]bool)
+			for _, result := range rules.CheckFile(NewFileInfo("test.c", content)) {
+				found[result.Rule] = true
+			}
+			var got []string
+			for _, rule := range []string{"encoding", "trailing-whitespace"} {
+				if found[rule] {
+					got = append(got, rule)
+				}
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("rules reported %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIncludeStyleRule(t *testing.T) {
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &IncludeStyleRule{rulesConfig: rc} }, "include-style", []ruleCase{
+		{
+			name:    "correct styles",
+			content: "#include <stdio.h>\n#include <vector>\n#include <openssl/ssl.h>\n#include \"util.h\"\n",
+		},
+		{
+			name:    "bracketed local include",
+			content: "#include <stdio.h>\n#include <util.h>\n",
+			want:    []string{"2:1"},
+		},
+		{
+			name:    "quoted system include",
+			content: "#include \"stdio.h\"\n#  include \"string\"\n",
+			want:    []string{"1:1", "2:1"},
+		},
+		{
+			name:    "project prefixes",
+			content: "#include <app/core.h>\n#include <openssl/ssl.h>\n#include \"app/core.h\"\n",
+			config:  `{"rules": {"include-style": {"parameters": {"project_prefixes": ["app/"]}}}}`,
+			want:    []string{"1:1"},
+		},
+		{
+			name:    "configured system headers",
+			content: "#include <zlib.h>\n#include \"zlib.h\"\n",
+			config:  `{"rules": {"include-style": {"parameters": {"system_headers": ["zlib.h"]}}}}`,
+			want:    []string{"2:1"},
+		},
+		{
+			name:    "commented out",
+			content: "// #include <util.h>\n/* #include \"stdio.h\" */\n",
+		},
+	})
+}
+
+func TestMidLineTabRule(t *testing.T) {
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &MidLineTabRule{rulesConfig: rc} }, "mid-line-tab", []ruleCase{
+		{
+			name:    "mid-line tabs",
+			content: "int x;\t// x\n#define A\t1\t/* a */\n",
+			want:    []string{"1:7", "2:10", "2:12"},
+		},
+		{
+			name:    "leading tabs only",
+			content: "void f(void) {\n\tint x;\n\t\tg();\n}\n",
+		},
+		{
+			name:    "tab inside a string",
+			content: "const char *s = \"a\tb\";\n",
+		},
+	})
+}
+
+func TestMidLineTabFix(t *testing.T) {
+	const content = "\tint x;\t\t// x\n"
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{name: "single space", want: "\tint x;  // x"},
+		{
+			name:   "configured width",
+			config: `{"rules": {"mid-line-tab": {"parameters": {"fix_spaces": 4}}}}`,
+			want:   "\tint x;        // x",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := &MidLineTabRule{rulesConfig: testRulesConfig(t, tt.config)}
+			results := rule.Check(NewFileInfo("test.c", []byte(content)))
+			if len(results) != 2 || results[0].Fix == nil {
+				t.Fatalf("results %v, want two with a fix", results)
+			}
+			want := Fix{StartLine: 1, EndLine: 1, Replacement: []string{tt.want}}
+			if got := *results[0].Fix; !reflect.DeepEqual(got, want) {
+				t.Errorf("fix %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestIncludeCountRule(t *testing.T) {
+	const maxTwo = `{"rules": {"max-includes": {"enabled": true, "parameters": {"max_includes": 2}}}}`
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &IncludeCountRule{rulesConfig: rc} }, "max-includes", []ruleCase{
+		{
+			name:    "off by default",
+			content: "#include <a.h>\n#include <b.h>\n#include <c.h>\n",
+		},
+		{
+			name:    "clean",
+			content: "#include <a.h>\nint x;\n",
+			config:  maxTwo,
+		},
+		{
+			name:    "at the limit",
+			content: "#include <a.h>\n#include \"b.h\"\n",
+			config:  maxTwo,
+		},
+		{
+			name:    "over the limit",
+			content: "#include <a.h>\n#include \"b.h\"\n#  include <c.h>\n",
+			config:  maxTwo,
+			want:    []string{"1:1"},
+		},
+		{
+			name:    "commented out includes",
+			content: "#include <a.h>\n#include <b.h>\n// #include <c.h>\n/*\n#include <d.h>\n*/\n",
+			config:  maxTwo,
+		},
+	})
+}
+
+func TestEmptyFileRule(t *testing.T) {
+	const exemptHeaders = `{"rules": {"empty-file": {"parameters": {"exempt_headers": true}}}}`
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &EmptyFileRule{rulesConfig: rc} }, "empty-file", []ruleCase{
+		{name: "empty", content: "", want: []string{"1:1"}},
+		{name: "whitespace only", content: "\n  \t\r\n\n", want: []string{"1:1"}},
+		{name: "not empty", content: "int x;\n"},
+		{name: "comment only", content: "/* reserved */\n"},
+		{name: "empty header", path: "a.h", content: "", want: []string{"1:1"}},
+		{name: "exempt header", path: "a.h", content: "", config: exemptHeaders},
+		{name: "exempt C++ header", path: "a.hpp", content: "\n", config: exemptHeaders},
+		{name: "source with headers exempt", content: "", config: exemptHeaders, want: []string{"1:1"}},
+	})
+
+	rule := &EmptyFileRule{rulesConfig: defaultRulesConfig()}
+	for content, want := range map[string]string{"": "File is empty", "\n\n": "File contains only whitespace"} {
+		if results := rule.Check(NewFileInfo("test.c", []byte(content))); len(results) != 1 || results[0].Message != want {
+			t.Errorf("%q: results %v, want one with message %q", content, results, want)
+		}
+	}
+}
+
+func TestBannedIncludeRule(t *testing.T) {
+	const layering = `{"rules": {"banned-include": {"parameters": {
+		"banned_includes": ["internal/*.h", "windows.h"],
+		"except": {"internal/*.h": ["core", "tests/core_*.c"]}
+	}}}}`
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &BannedIncludeRule{rulesConfig: rc} }, "banned-include", []ruleCase{
+		{
+			name:    "nothing banned by default",
+			content: "#include \"internal/impl.h\"\n#include <windows.h>\n",
+		},
+		{
+			name:    "banned includes",
+			path:    "app/main.c",
+			content: "#include <stdio.h>\n#include \"internal/impl.h\"\n#include <windows.h>\n#include \"internal/sub/deep.h\"\n",
+			config:  layering,
+			want:    []string{"2:1", "3:1"},
+		},
+		{
+			name:    "unanchored pattern matches at any level",
+			path:    "app/main.c",
+			content: "#include <sys/windows.h>\n",
+			config:  layering,
+			want:    []string{"1:1"},
+		},
+		{
+			name:    "allowed exception",
+			path:    "core/engine.c",
+			content: "#include \"internal/impl.h\"\n#include <windows.h>\n",
+			config:  layering,
+			want:    []string{"2:1"},
+		},
+		{
+			name:    "exception by file glob",
+			path:    "tests/core_test.c",
+			content: "#include \"internal/impl.h\"\n",
+			config:  layering,
+		},
+		{
+			name:    "commented out",
+			path:    "app/main.c",
+			content: "// #include \"internal/impl.h\"\n/* #include <windows.h> */\n",
+			config:  layering,
+		},
+	})
+}
+
+func TestLeadingBlankLinesRule(t *testing.T) {
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &LeadingBlankLinesRule{rulesConfig: rc} }, "leading-blank-lines", []ruleCase{
+		{name: "starts with content", content: "/* Copyright */\n\nint x;\n"},
+		{name: "one blank line", content: "\n/* Copyright */\n", want: []string{"1:1"}},
+		{name: "whitespace-only lines", content: "  \n\t\n\r\nint x;\n", want: []string{"1:1"}},
+		{name: "whitespace-only file", content: "\n\n\n"},
+	})
+}
+
+func TestLeadingBlankLinesFix(t *testing.T) {
+	content := "\n  \n/* Copyright */\nint x;\n"
+	rule := &LeadingBlankLinesRule{rulesConfig: defaultRulesConfig()}
+	results := rule.Check(NewFileInfo("test.c", []byte(content)))
+	if len(results) != 1 || results[0].Fix == nil {
+		t.Fatalf("results %v, want one with a fix", results)
+	}
+	if want := "File starts with 2 blank line(s)"; results[0].Message != want {
+		t.Errorf("message %q, want %q", results[0].Message, want)
+	}
+
+	lines, _ := splitLines(content)
+	fixed, applied := applyFixes(lines, []Fix{*results[0].Fix})
+	if want := []string{"/* Copyright */", "int x;", ""}; applied != 1 || !reflect.DeepEqual(fixed, want) {
+		t.Errorf("fixed to %q (%d applied), want %q", fixed, applied, want)
+	}
+}
+
+func TestFunctionSpacingRule(t *testing.T) {
+	fn := func(name string) string {
+		return "void " + name + "(void) {\n    work();\n}\n"
+	}
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &FunctionSpacingRule{rulesConfig: rc} }, "function-spacing", []ruleCase{
+		{
+			name:    "compliant",
+			content: fn("a") + "\n" + fn("b") + "\n\n// c does things\n" + fn("c"),
+		},
+		{
+			name:    "no blank line",
+			content: fn("a") + fn("b"),
+			want:    []string{"4:1"},
+		},
+		{
+			name:    "too many blank lines",
+			content: fn("a") + "\n\n\n" + fn("b"),
+			want:    []string{"4:1"},
+		},
+		{
+			name:    "declarations in between",
+			content: fn("a") + "static int count;\n" + fn("b"),
+		},
+		{
+			name:    "exactly two",
+			content: fn("a") + "\n" + fn("b") + "\n\n" + fn("c"),
+			config:  `{"rules": {"function-spacing": {"parameters": {"min_blank_lines": 2, "max_blank_lines": 2}}}}`,
+			want:    []string{"4:1"},
+		},
+	})
+
+	rule := &FunctionSpacingRule{rulesConfig: defaultRulesConfig()}
+	results := rule.Check(NewFileInfo("test.c", []byte(fn("a")+fn("b"))))
+	if want := "0 blank lines between a and b (expected 1 to 2)"; len(results) != 1 || results[0].Message != want {
+		t.Errorf("results %v, want one with message %q", results, want)
+	}
+}
+
+func TestIncludeDepthRule(t *testing.T) {
+	const enabled = `{"rules": {"include-depth": {"enabled": true, "parameters": {"max_depth": 2}}}}`
+	// main.c -> a.h -> lib/b.h -> lib/c.h -> lib/d.h
+	chain := map[string]string{
+		"main.c":  "#include \"a.h\"\nint main(void) { return 0; }\n",
+		"a.h":     "#pragma once\n#include \"lib/b.h\"\n",
+		"lib/b.h": "#pragma once\n#include <stdio.h>\n#include \"c.h\"\n",
+		"lib/c.h": "#pragma once\n#include \"d.h\"\n",
+		"lib/d.h": "#pragma once\nint d;\n",
+	}
+	tests := []struct {
+		name   string
+		files  map[string]string
+		config string
+		want   []string
+	}{
+		{name: "off by default", files: chain},
+		{name: "deep header", files: chain, config: enabled, want: []string{"a.h:2:1"}},
+		{
+			name:   "within the limit",
+			files:  chain,
+			config: `{"rules": {"include-depth": {"enabled": true, "parameters": {"max_depth": 3}}}}`,
+		},
+		{
+			name:   "sources not reported",
+			files:  map[string]string{"main.c": chain["main.c"], "a.h": "#include \"b.h\"\n", "b.h": "#include \"c.h\"\n", "c.h": "int c;\n"},
+			config: enabled,
+		},
+		{
+			name:   "cycle",
+			files:  map[string]string{"main.c": chain["main.c"], "a.h": "#include \"b.h\"\n", "b.h": "#include \"a.h\"\n"},
+			config: enabled,
+		},
+		{
+			name: "commented-out include",
+			files: map[string]string{
+				"main.c": chain["main.c"], "a.h": "// #include \"b.h\"\n", "b.h": "#include \"c.h\"\n",
+				"c.h": "#include \"d.h\"\n", "d.h": "int d;\n",
+			},
+			config: enabled,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rule := &IncludeDepthRule{rulesConfig: testRulesConfig(t, tc.config)}
+			got := ruleLines(rule.CheckProject(projectFiles(tc.files)), "include-depth")
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("include-depth at %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	rule := &IncludeDepthRule{rulesConfig: testRulesConfig(t, enabled)}
+	results := rule.CheckProject(projectFiles(chain))
+	want := "Header includes other headers 3 levels deep (max 2): a.h -> lib/b.h -> lib/c.h -> lib/d.h"
+	if len(results) != 1 || results[0].Message != want {
+		t.Errorf("results %v, want one with message %q", results, want)
+	}
+}
+
+func TestIndentColumn(t *testing.T) {
+	tests := []struct {
+		line     string
+		tabWidth int
+		want     int
+	}{
+		{"x;", 4, 0},
+		{"    x;", 4, 4},
+		{"\tx;", 4, 4},
+		{"\tx;", 8, 8},
+		{"  \tx;", 4, 4},
+		{"\t  x;", 4, 6},
+		{"  \t  \tx;", 4, 8},
+		{"     \tx;", 4, 8},
+		{"\t\t", 4, 8},
+	}
+	for _, tc := range tests {
+		if got := indentColumn(tc.line, tc.tabWidth); got != tc.want {
+			t.Errorf("indentColumn(%q, %d) = %d, want %d", tc.line, tc.tabWidth, got, tc.want)
+		}
+	}
+}
+
+func TestIndentColumnRule(t *testing.T) {
+	indent := func(prefix string, n int) string {
+		return strings.Repeat(prefix, n)
+	}
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &IndentColumnRule{rulesConfig: rc} }, "max-indent", []ruleCase{
+		{
+			name:    "shallow",
+			content: "int f(void) {\n    return 0;\n}\n",
+		},
+		{
+			name:    "spaces",
+			content: indent(" ", 40) + "a();\n" + indent(" ", 41) + "b();\n",
+			want:    []string{"2:1"},
+		},
+		{
+			name:    "tabs",
+			content: indent("\t", 10) + "a();\n" + indent("\t", 11) + "b();\n" + indent(" ", 39) + "\tc();\n",
+			want:    []string{"2:1"},
+		},
+		{
+			name:    "tab width",
+			content: indent("\t", 5) + "a();\n" + indent("\t", 6) + "b();\n",
+			config:  `{"rules": {"max-indent": {"parameters": {"tab_width": 8}}}}`,
+			want:    []string{"2:1"},
+		},
+		{
+			name:    "max column",
+			content: indent(" ", 12) + "a();\n" + indent(" ", 13) + "b();\n",
+			config:  `{"rules": {"max-indent": {"parameters": {"max_indent_column": 12}}}}`,
+			want:    []string{"2:1"},
+		},
+		{
+			name:    "comments and blank lines",
+			content: indent(" ", 60) + "// aligned note\n" + indent(" ", 60) + "/* block */\n" + indent("\t", 20) + "\n",
+		},
+		{
+			name:    "disabled",
+			content: indent(" ", 60) + "a();\n",
+			config:  `{"rules": {"max-indent": {"enabled": false}}}`,
+		},
+	})
+
+	rule := &IndentColumnRule{rulesConfig: defaultRulesConfig()}
+	results := rule.Check(NewFileInfo("test.c", []byte(indent("\t", 11)+"b();\n")))
+	if want := "Line is indented 44 columns (max 40); consider extracting the nested code"; len(results) != 1 || results[0].Message != want {
+		t.Errorf("results %v, want one with message %q", results, want)
+	}
+}
+
+func TestOwnHeaderFirstRule(t *testing.T) {
+	const enabled = `{"rules": {"own-header-first": {"enabled": true}}}`
+	runRuleCases(t, func(rc *RulesConfig) Rule { return &OwnHeaderFirstRule{rulesConfig: rc} }, "own-header-first", []ruleCase{
+		{
+			name:    "off by default",
+			path:    "src/foo.cc",
+			content: "#include <vector>\n#include \"foo.h\"\n",
+		},
+		{
+			name:    "conforming",
+			path:    "src/foo.cc",
+			content: "// foo\n#include \"foo.h\"\n\n#include <vector>\n",
+			config:  enabled,
+		},
+		{
+			name:    "own header by path",
+			path:    "src/net/socket.cpp",
+			content: "#include \"net/socket.hpp\"\n#include <vector>\n",
+			config:  enabled,
+		},
+		{
+			name:    "other header first",
+			path:    "src/foo.cc",
+			content: "// foo\n#include <vector>\n#include \"foo.h\"\n",
+			config:  enabled,
+			want:    []string{"2:1"},
+		},
+		{
+			name:    "similar name",
+			path:    "src/foo.c",
+			content: "#include \"myfoo.h\"\n",
+			config:  enabled,
+			want:    []string{"1:1"},
+		},
+		{
+			name:    "commented-out include",
+			path:    "src/foo.c",
+			content: "/* #include \"foo.h\" */\n#include <stdio.h>\n",
+			config:  enabled,
+			want:    []string{"2:1"},
+		},
+		{
+			name:    "test file",
+			path:    "src/foo_test.cc",
+			content: "#include \"foo.h\"\n#include \"gtest/gtest.h\"\n",
+			config:  enabled,
+		},
+		{
+			name:    "no includes",
+			path:    "src/foo.c",
+			content: "int x;\n",
+			config:  enabled,
+		},
+		{
+			name:    "headers skipped",
+			path:    "src/foo.h",
+			content: "#include <vector>\n",
+			config:  enabled,
+		},
+		{
+			name:    "exempt main",
+			path:    "src/main.cc",
+			content: "#include <vector>\n",
+			config:  enabled,
+		},
+		{
+			name:    "configured convention",
+			path:    "src/foo.cxx",
+			content: "#include \"foo.hxx\"\n",
+			config:  `{"rules": {"own-header-first": {"enabled": true, "parameters": {"source_extensions": [".cxx"], "header_names": ["{name}.hxx"]}}}}`,
+		},
+		{
+			name:    "configured convention not followed",
+			path:    "src/foo.cxx",
+			content: "#include \"foo.h\"\n",
+			config:  `{"rules": {"own-header-first": {"enabled": true, "parameters": {"source_extensions": [".cxx"], "header_names": ["{name}.hxx", "include/{name}.hxx"]}}}}`,
+			want:    []string{"1:1"},
+		},
+		{
+			name:    "configured suffixes",
+			path:    "src/foo_spec.cc",
+			content: "#include \"foo.h\"\n",
+			config:  `{"rules": {"own-header-first": {"enabled": true, "parameters": {"strip_suffixes": ["_spec"]}}}}`,
+		},
+	})
+
+	rule := &OwnHeaderFirstRule{rulesConfig: testRulesConfig(t, enabled)}
+	results := rule.Check(NewFileInfo("src/foo.cc", []byte("#include <vector>\n")))
+	if want := "First include is vector; include the file's own header (foo.h or foo.hpp) first"; len(results) != 1 || results[0].Message != want {
+		t.Errorf("results %v, want one with message %q", results, want)
+	}
+}
@@ -0,0 +1,610 @@
+package codelint
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Lexical classes assigned to each byte by classifyLines
+const (
+	classCode byte = iota
+	classComment
+	classString
+	classRawString
+)
+
+// classifyLines assigns a lexical class to every byte of every line:
+// comment text (including the delimiters), the contents of string and
+// character literals (not the quotes), the contents of raw string literals
+// (including the delimiters and parentheses), or code.
+func classifyLines(lines []string) [][]byte {
+	classes := make([][]byte, len(lines))
+	inBlockComment := false
+	rawDelim := ""
+	inRaw := false
+
+	for n, line := range lines {
+		class := make([]byte, len(line))
+		var quote byte
+
+		mark := func(from, to int, c byte) {
+			for k := from; k < to && k < len(class); k++ {
+				class[k] = c
+			}
+		}
+
+		for i := 0; i < len(line); i++ {
+			c := line[i]
+			switch {
+			case inBlockComment:
+				if c == '*' && i+1 < len(line) && line[i+1] == '/' {
+					inBlockComment = false
+					mark(i, i+2, classComment)
+					i++
+					continue
+				}
+				class[i] = classComment
+			case inRaw:
+				end := ")" + rawDelim + "\""
+				if strings.HasPrefix(line[i:], end) {
+					inRaw = false
+					mark(i, i+len(end)-1, classRawString)
+					i += len(end) - 1
+					continue
+				}
+				class[i] = classRawString
+			case quote != 0:
+				if c == '\\' {
+					mark(i, i+2, classString)
+					i++
+					continue
+				}
+				if c == quote {
+					quote = 0
+					continue
+				}
+				class[i] = classString
+			case c == '/' && i+1 < len(line) && line[i+1] == '/':
+				mark(i, len(line), classComment)
+				i = len(line)
+			case c == '/' && i+1 < len(line) && line[i+1] == '*':
+				inBlockComment = true
+				mark(i, i+2, classComment)
+				i++
+			case c == '"':
+				if i > 0 && line[i-1] == 'R' {
+					if open := strings.IndexByte(line[i+1:], '('); open >= 0 {
+						rawDelim = line[i+1 : i+1+open]
+						inRaw = true
+						mark(i+1, i+2+open, classRawString)
+						i += open + 1
+						continue
+					}
+				}
+				quote = c
+			case c == '\'':
+				// C++14 digit separators (1'000'000) are not literals
+				if !isNumberSeparator(line, i) {
+					quote = c
+				}
+			}
+		}
+
+		classes[n] = class
+	}
+
+	return classes
+}
+
+// codeLines returns a copy of lines with comments and the contents of
+// string and character literals replaced by spaces, so structural scans
+// only see code. Byte offsets (and therefore columns) are preserved.
+func codeLines(lines []string) []string {
+	classes := classifyLines(lines)
+	out := make([]string, len(lines))
+	for n, line := range lines {
+		buf := []byte(line)
+		for i, c := range classes[n] {
+			if c != classCode {
+				buf[i] = ' '
+			}
+		}
+		out[n] = string(buf)
+	}
+	return out
+}
+
+// maskOf extracts a per-byte mask of the given lexical classes
+func maskOf(classes [][]byte, wanted ...byte) [][]bool {
+	mask := make([][]bool, len(classes))
+	for n, line := range classes {
+		mask[n] = make([]bool, len(line))
+		for i, c := range line {
+			for _, w := range wanted {
+				if c == w {
+					mask[n][i] = true
+					break
+				}
+			}
+		}
+	}
+	return mask
+}
+
+// isNumberSeparator reports whether the quote at i sits inside a numeric
+// literal such as 1'000
+func isNumberSeparator(line string, i int) bool {
+	start := i
+	for start > 0 && isIdentByte(line[start-1]) {
+		start--
+	}
+	return start < i && line[start] >= '0' && line[start] <= '9'
+}
+
+// isIdentByte reports whether c can appear in a C identifier
+func isIdentByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// Block kinds recorded by scanStructure
+const (
+	blockNamespace = "namespace"
+	blockClass     = "class"
+	blockStruct    = "struct"
+	blockUnion     = "union"
+	blockEnum      = "enum"
+	blockExtern    = "extern"
+	blockFunction  = "function"
+	blockOther     = "other"
+)
+
+// codeBlock is a brace-delimited block found outside function bodies
+type codeBlock struct {
+	Kind string
+	// Name of the namespace, type or function ("" if anonymous)
+	Name string
+	// Head is the code preceding the opening brace
+	Head string
+	// StartLine and EndLine are the 0-based lines holding '{' and '}';
+	// StartCol and EndCol are their byte offsets within those lines
+	StartLine int
+	StartCol  int
+	EndLine   int
+	EndCol    int
+	// Depth is the number of enclosing scope blocks
+	Depth int
+}
+
+// functionSignature is a function declaration or definition
+type functionSignature struct {
+	Name string
+	// Prefix is the code before the name: return type, storage class, etc.
+	Prefix string
+	Params []string
+	// ParamLines holds the 0-based line on which each parameter starts
+	ParamLines []int
+	// Line is the 0-based line holding the function name; StartLine is
+	// where the declaration starts (including template headers)
+	Line      int
+	StartLine int
+	// EndLine is the 0-based line of the closing parenthesis
+	EndLine int
+	// Template is set when the declaration has a template header
+	Template bool
+	// Definition is set when the signature is followed by a body
+	Definition bool
+	Body       codeBlock
+	// Scope is the kind of the innermost enclosing block ("" at file scope)
+	Scope string
+}
+
+// sourceStructure is the result of scanStructure
+type sourceStructure struct {
+	Blocks    []codeBlock
+	Functions []functionSignature
+	// Statements are the ';'-terminated declarations found outside
+	// function bodies
+	Statements []scopeStatement
+}
+
+// scopeStatement is a declaration statement outside any function body
+type scopeStatement struct {
+	Text  string
+	Line  int
+	Scope string
+}
+
+var (
+	scopeHead       = regexp.MustCompile(`^(?:template\s*<.*>\s*)?(namespace|class|struct|union|enum)\b(?:\s+(?:class|struct)\b)?\s*(?:\[\[[^\]]*\]\]\s*)?([A-Za-z_][\w:]*)?`)
+	externHead      = regexp.MustCompile(`^extern\s*"`)
+	accessLabel     = regexp.MustCompile(`^(?:public|private|protected)\s*:\s*`)
+	templateHead    = regexp.MustCompile(`^template\s*<`)
+	templateKeyword = regexp.MustCompile(`\btemplate\s*<`)
+	funcNameAtEnd   = regexp.MustCompile(`((?:[A-Za-z_]\w*\s*::\s*)*(?:~?[A-Za-z_]\w*|operator\s*(?:\(\)|[^\s\w(]+|\w+)))\s*$`)
+	signatureTail   = regexp.MustCompile(`^(?:\s*(?:const|volatile|noexcept(?:\s*\([^)]*\))?|override|final|&&|&|throw\s*\([^)]*\)|->\s*[^=;{]+|=\s*(?:0|default|delete)|__attribute__\s*\(\(.*\)\)))*\s*(?::.*)?$`)
+)
+
+// nonFunctionNames are identifiers that can precede '(' without naming a
+// function
+var nonFunctionNames = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "return": true,
+	"sizeof": true, "alignof": true, "decltype": true, "typeof": true,
+	"static_assert": true, "alignas": true, "__attribute__": true, "__declspec": true,
+	"void": true, "int": true, "char": true, "short": true, "long": true,
+	"float": true, "double": true, "bool": true, "unsigned": true, "signed": true,
+	"auto": true, "const": true, "case": true, "do": true, "else": true, "new": true,
+	"delete": true, "throw": true, "catch": true, "typedef": true, "using": true,
+}
+
+// scanStructure walks comment- and string-free code lines (as produced by
+// codeLines) and records namespace/type/function blocks and function
+// signatures outside function bodies. It is a heuristic scanner, not a
+// parser: preprocessor lines are skipped and macros are not expanded.
+func scanStructure(code []string) *sourceStructure {
+	s := &sourceStructure{}
+
+	type openBlock struct {
+		block codeBlock
+		fn    int // index into s.Functions, or -1
+	}
+	var stack []openBlock
+	bodyDepth := 0 // brace depth inside a function or other non-scope block
+
+	var head strings.Builder
+	headLine := -1
+	var lineStarts []linePos
+
+	resetHead := func() {
+		head.Reset()
+		headLine = -1
+		lineStarts = lineStarts[:0]
+	}
+	scopeKind := func() string {
+		if len(stack) == 0 {
+			return ""
+		}
+		return stack[len(stack)-1].block.Kind
+	}
+
+	inDirective := false
+	for n, line := range code {
+		trimmed := strings.TrimSpace(line)
+		if inDirective || strings.HasPrefix(trimmed, "#") {
+			inDirective = strings.HasSuffix(trimmed, "\\")
+			continue
+		}
+
+		for i := 0; i < len(line); i++ {
+			c := line[i]
+
+			if bodyDepth > 0 {
+				switch c {
+				case '{':
+					bodyDepth++
+				case '}':
+					bodyDepth--
+					if bodyDepth == 0 {
+						top := stack[len(stack)-1]
+						stack = stack[:len(stack)-1]
+						top.block.EndLine = n
+						top.block.EndCol = i
+						s.Blocks = append(s.Blocks, top.block)
+						if top.fn >= 0 {
+							s.Functions[top.fn].Body = top.block
+						}
+					}
+				}
+				continue
+			}
+
+			switch c {
+			case '{':
+				text := strings.TrimSpace(head.String())
+				block := codeBlock{Kind: blockOther, Head: text, StartLine: n, StartCol: i, Depth: len(stack)}
+				fn := -1
+				decl := stripDeclPrefix(text)
+				if sig, ok := parseSignature(text, headLine, lineStarts); ok {
+					sig.Definition = true
+					sig.Scope = scopeKind()
+					block.Kind = blockFunction
+					block.Name = sig.Name
+					s.Functions = append(s.Functions, sig)
+					fn = len(s.Functions) - 1
+				} else if m := scopeHead.FindStringSubmatch(decl); m != nil && !strings.Contains(decl, "=") {
+					block.Kind = m[1]
+					block.Name = m[2]
+				} else if externHead.MatchString(text) {
+					block.Kind = blockExtern
+				}
+				stack = append(stack, openBlock{block: block, fn: fn})
+				if block.Kind == blockFunction || block.Kind == blockOther || block.Kind == blockEnum {
+					bodyDepth = 1
+				}
+				resetHead()
+			case '}':
+				if len(stack) > 0 {
+					top := stack[len(stack)-1]
+					stack = stack[:len(stack)-1]
+					top.block.EndLine = n
+					top.block.EndCol = i
+					s.Blocks = append(s.Blocks, top.block)
+				}
+				resetHead()
+			case ';':
+				text := strings.TrimSpace(head.String())
+				if sig, ok := parseSignature(text, headLine, lineStarts); ok {
+					sig.Scope = scopeKind()
+					s.Functions = append(s.Functions, sig)
+				} else if text != "" {
+					s.Statements = append(s.Statements, scopeStatement{Text: text, Line: headLine, Scope: scopeKind()})
+				}
+				resetHead()
+			default:
+				if headLine < 0 {
+					if c == ' ' || c == '\t' {
+						continue
+					}
+					headLine = n
+				}
+				if len(lineStarts) == 0 || lineStarts[len(lineStarts)-1].line != n {
+					if len(lineStarts) > 0 {
+						head.WriteByte(' ')
+					}
+					lineStarts = append(lineStarts, linePos{line: n, offset: head.Len()})
+				}
+				head.WriteByte(c)
+			}
+		}
+	}
+
+	// Blocks still open at EOF are recorded as ending on the last line
+	for i := len(stack) - 1; i >= 0; i-- {
+		stack[i].block.EndLine = len(code) - 1
+		stack[i].block.EndCol = len(code[len(code)-1])
+		s.Blocks = append(s.Blocks, stack[i].block)
+		if stack[i].fn >= 0 {
+			s.Functions[stack[i].fn].Body = stack[i].block
+		}
+	}
+
+	return s
+}
+
+// stripDeclPrefix removes access labels, template headers and attributes
+// from the start of a declaration
+func stripDeclPrefix(text string) string {
+	for {
+		before := text
+		text = accessLabel.ReplaceAllString(text, "")
+		if templateHead.MatchString(text) {
+			if end := matchingAngle(text, strings.Index(text, "<")); end >= 0 {
+				text = strings.TrimSpace(text[end+1:])
+			}
+		}
+		if strings.HasPrefix(text, "[[") {
+			if end := strings.Index(text, "]]"); end >= 0 {
+				text = strings.TrimSpace(text[end+2:])
+			}
+		}
+		if text == before {
+			return text
+		}
+	}
+}
+
+// matchingAngle returns the index of the '>' closing the '<' at open
+func matchingAngle(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '<':
+			depth++
+		case '>':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// linePos maps an offset in an accumulated declaration to a source line
+type linePos struct {
+	line   int
+	offset int
+}
+
+// parseSignature recognizes a function declaration head such as
+// `static int foo(int a, char *b) const`. headLine and lineStarts map
+// offsets in text back to source lines.
+func parseSignature(text string, headLine int, lineStarts []linePos) (functionSignature, bool) {
+	var sig functionSignature
+
+	// Offset of the declaration after access labels, templates, attributes
+	decl := stripDeclPrefix(text)
+	base := len(text) - len(decl)
+
+	open := strings.Index(decl, "(")
+	if open <= 0 {
+		return sig, false
+	}
+	// operator() declares its parameters in the second parenthesis
+	if strings.HasSuffix(strings.TrimSpace(decl[:open]), "operator") && strings.HasPrefix(decl[open:], "()") {
+		if next := strings.Index(decl[open+2:], "("); next >= 0 {
+			open += 2 + next
+		}
+	}
+
+	m := funcNameAtEnd.FindStringSubmatchIndex(decl[:open])
+	if m == nil {
+		return sig, false
+	}
+	name := strings.Join(strings.Fields(decl[m[2]:m[3]]), "")
+	short := name[strings.LastIndex(name, ":")+1:]
+	if nonFunctionNames[short] || strings.Contains(decl[:m[2]], "=") {
+		return sig, false
+	}
+
+	closeIdx := matchingParen(decl, open)
+	if closeIdx < 0 || !signatureTail.MatchString(decl[closeIdx+1:]) {
+		return sig, false
+	}
+
+	lineOf := func(offset int) int {
+		line := headLine
+		for _, ls := range lineStarts {
+			if ls.offset <= offset {
+				line = ls.line
+			}
+		}
+		return line
+	}
+
+	params, offsets := splitParams(decl[open+1 : closeIdx])
+	for _, p := range params {
+		if p != "" && p[0] >= '0' && p[0] <= '9' {
+			// A literal argument means this is an object initialization
+			return sig, false
+		}
+	}
+	sig.Name = name
+	sig.Prefix = strings.TrimSpace(decl[:m[2]])
+	sig.Template = templateKeyword.MatchString(text[:base])
+	sig.Params = params
+	for _, off := range offsets {
+		sig.ParamLines = append(sig.ParamLines, lineOf(base+open+1+off))
+	}
+	sig.StartLine = headLine
+	sig.Line = lineOf(base + m[2])
+	sig.EndLine = lineOf(base + closeIdx)
+	return sig, true
+}
+
+// splitParams splits a parameter list on top-level commas, returning the
+// trimmed parameters and their offsets in list. An empty or `void` list
+// yields no parameters.
+func splitParams(list string) ([]string, []int) {
+	if t := strings.TrimSpace(list); t == "" || t == "void" {
+		return nil, nil
+	}
+
+	var params []string
+	var offsets []int
+	depth := 0
+	start := 0
+	add := func(end int) {
+		raw := list[start:end]
+		params = append(params, strings.TrimSpace(raw))
+		offsets = append(offsets, start+len(raw)-len(strings.TrimLeft(raw, " \t")))
+	}
+	for i := 0; i < len(list); i++ {
+		switch list[i] {
+		case '(', '<', '[', '{':
+			depth++
+		case ')', '>', ']', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				add(i)
+				start = i + 1
+			}
+		}
+	}
+	add(len(list))
+	return params, offsets
+}
+
+// scanBlock calls visit for each byte of code strictly between the
+// opening and closing braces of b
+func scanBlock(code []string, b codeBlock, visit func(line, col int, c byte)) {
+	for n := b.StartLine; n <= b.EndLine && n < len(code); n++ {
+		line := code[n]
+		start, end := 0, len(line)
+		if n == b.StartLine {
+			start = b.StartCol + 1
+		}
+		if n == b.EndLine && b.EndCol < end {
+			end = b.EndCol
+		}
+		for i := start; i < end; i++ {
+			visit(n, i, line[i])
+		}
+	}
+}
+
+// flatCode joins code lines into one buffer so scans can cross line
+// boundaries, remembering where each line starts
+type flatCode struct {
+	text       string
+	lineStarts []int
+}
+
+// newFlatCode builds a flatCode from comment- and string-free lines
+func newFlatCode(code []string) *flatCode {
+	f := &flatCode{}
+	var b strings.Builder
+	for _, line := range code {
+		f.lineStarts = append(f.lineStarts, b.Len())
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	f.text = b.String()
+	return f
+}
+
+// position converts an offset into 0-based line and column
+func (f *flatCode) position(offset int) (int, int) {
+	line := sort.Search(len(f.lineStarts), func(i int) bool {
+		return f.lineStarts[i] > offset
+	}) - 1
+	if line < 0 {
+		line = 0
+	}
+	return line, offset - f.lineStarts[line]
+}
+
+// prevNonSpace returns the index of the last non-whitespace byte before i,
+// or -1
+func (f *flatCode) prevNonSpace(i int) int {
+	for i--; i >= 0; i-- {
+		switch f.text[i] {
+		case ' ', '\t', '\n', '\r':
+		default:
+			return i
+		}
+	}
+	return -1
+}
+
+// matchingOpen returns the index of the opening bracket matching the
+// closing bracket at close, or -1
+func (f *flatCode) matchingOpen(close int) int {
+	closing := f.text[close]
+	opening := map[byte]byte{')': '(', '}': '{', ']': '['}[closing]
+	depth := 0
+	for i := close; i >= 0; i-- {
+		switch f.text[i] {
+		case closing:
+			depth++
+		case opening:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// wordBefore returns the identifier ending at the last non-space byte
+// before i
+func (f *flatCode) wordBefore(i int) string {
+	end := f.prevNonSpace(i)
+	start := end
+	for start >= 0 && isIdentByte(f.text[start]) {
+		start--
+	}
+	return f.text[start+1 : end+1]
+}
@@ -0,0 +1,124 @@
+package codelint
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Session keeps the files and results of a full run in memory so single
+// files can be re-checked after an edit without walking the tree again.
+// It is the building block for watch and editor integrations.
+type Session struct {
+	linter *Linter
+	checks *fileChecks
+
+	mu      sync.Mutex
+	files   map[string]FileInfo
+	results map[string][]Result
+}
+
+// NewSession walks the tree once, checks every file and caches the results
+func (l *Linter) NewSession() (*Session, error) {
+	if err := l.rules.CheckRequired(l.config.RequiredRules); err != nil {
+		return nil, err
+	}
+
+	checks, err := l.prepareFileChecks()
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := l.walker.Walk()
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk directory: %w", err)
+	}
+
+	s := &Session{
+		linter:  l,
+		checks:  checks,
+		files:   make(map[string]FileInfo),
+		results: make(map[string][]Result),
+	}
+	for _, file := range files {
+		relPath := l.walker.ReportPath(file.Path)
+		l.paths[relPath] = file.Path
+		file.Path = relPath
+		s.store(file)
+	}
+
+	return s, nil
+}
+
+// Results returns the cached results of all files, sorted by file, line
+// and column
+func (s *Session) Results() []Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var all []Result
+	for _, results := range s.results {
+		all = append(all, results...)
+	}
+	sortResults(all)
+	return all
+}
+
+// File returns the cached FileInfo for a reported path
+func (s *Session) File(path string) (FileInfo, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, ok := s.files[path]
+	return file, ok
+}
+
+// Relint re-reads a single file from disk and re-checks it, replacing only
+// that file's cached results. path may be the reported (relative) path or
+// the path on disk. A file that can no longer be read is dropped from the
+// session.
+func (s *Session) Relint(path string) []Result {
+	relPath, diskPath := s.resolve(path)
+
+	content, err := os.ReadFile(diskPath)
+	if err != nil {
+		s.mu.Lock()
+		delete(s.files, relPath)
+		delete(s.results, relPath)
+		s.mu.Unlock()
+		return nil
+	}
+
+	return s.RelintContent(path, content)
+}
+
+// RelintContent re-checks a single file using the given content instead of
+// reading it from disk, e.g. an unsaved editor buffer
+func (s *Session) RelintContent(path string, content []byte) []Result {
+	relPath, _ := s.resolve(path)
+	return s.store(NewFileInfo(relPath, content))
+}
+
+// resolve maps a reported or on-disk path to both forms
+func (s *Session) resolve(path string) (string, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if diskPath, ok := s.linter.paths[path]; ok {
+		return path, diskPath
+	}
+	relPath := s.linter.walker.ReportPath(path)
+	s.linter.paths[relPath] = path
+	return relPath, path
+}
+
+// store checks a file and caches it with its results
+func (s *Session) store(file FileInfo) []Result {
+	results, _ := s.linter.checkFile(file, s.checks)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[file.Path] = file
+	s.results[file.Path] = results
+	return results
+}
@@ -0,0 +1,80 @@
+package codelint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// ruleLines returns "file:line:column" for each result of one rule
+func ruleLines(results []Result, rule string) []string {
+	var found []string
+	for _, result := range results {
+		if result.Rule == rule {
+			found = append(found, fmt.Sprintf("%s:%d:%d", result.File, result.Line, result.Column))
+		}
+	}
+	return found
+}
+
+func TestSessionRelint(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"a.c": "/* Copyright */\nint a; \n",
+		"b.c": "/* Copyright */\nint b; \n",
+	})
+	config := testConfig(root)
+	config.SlashPaths = true
+
+	session, err := New(config).NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a.c:2:7", "b.c:2:7"}
+	if got := ruleLines(session.Results(), "trailing-whitespace"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("initial results %v, want %v", got, want)
+	}
+
+	// Fixing a.c on disk only changes a.c's results
+	if err := os.WriteFile(filepath.Join(root, "a.c"), []byte("/* Copyright */\nint a;\n\nint c; \n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := ruleLines(session.Relint("a.c"), "trailing-whitespace"); !reflect.DeepEqual(got, []string{"a.c:4:7"}) {
+		t.Errorf("Relint(a.c) = %v, want [a.c:4:7]", got)
+	}
+	want = []string{"a.c:4:7", "b.c:2:7"}
+	if got := ruleLines(session.Results(), "trailing-whitespace"); !reflect.DeepEqual(got, want) {
+		t.Errorf("results after Relint %v, want %v", got, want)
+	}
+
+	// The on-disk path names the same file
+	if got := session.Relint(filepath.Join(root, "a.c")); len(ruleLines(got, "trailing-whitespace")) != 1 {
+		t.Errorf("Relint by disk path = %v", got)
+	}
+
+	// Unsaved content is checked without touching the disk
+	session.RelintContent("b.c", []byte("/* Copyright */\nint b;\n"))
+	if got := ruleLines(session.Results(), "trailing-whitespace"); !reflect.DeepEqual(got, []string{"a.c:4:7"}) {
+		t.Errorf("results after RelintContent %v, want [a.c:4:7]", got)
+	}
+	if file, ok := session.File("b.c"); !ok || file.Lines[1] != "int b;" {
+		t.Errorf("File(b.c) = %q, %v; want the unsaved content", file.Lines, ok)
+	}
+
+	// A deleted file leaves the session
+	if err := os.Remove(filepath.Join(root, "a.c")); err != nil {
+		t.Fatal(err)
+	}
+	if got := session.Relint("a.c"); got != nil {
+		t.Errorf("Relint of a deleted file = %v, want nil", got)
+	}
+	if _, ok := session.File("a.c"); ok {
+		t.Error("deleted file still in the session")
+	}
+	for _, result := range session.Results() {
+		if result.File == "a.c" {
+			t.Errorf("deleted file still has result %+v", result)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package codelint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Summary aggregates the results of a run
+type Summary struct {
+	// FilesScanned is the number of files checked
+	FilesScanned int `json:"files_scanned"`
+
+	// FilesWithIssues is the number of files with at least one result
+	FilesWithIssues int `json:"files_with_issues"`
+
+	// Severities counts results by severity
+	Severities map[string]int `json:"severities"`
+
+	// Rules counts results by rule
+	Rules map[string]int `json:"rules"`
+}
+
+// Summarize computes the Summary of a result set
+func Summarize(results []Result, filesScanned int) Summary {
+	summary := Summary{
+		FilesScanned: filesScanned,
+		Severities: map[string]int{
+			SeverityError:   0,
+			SeverityWarning: 0,
+			SeverityInfo:    0,
+		},
+		Rules: make(map[string]int),
+	}
+
+	files := make(map[string]bool)
+	for _, r := range results {
+		if r.File != "" {
+			files[r.File] = true
+		}
+		summary.Severities[r.Severity]++
+		summary.Rules[r.Rule]++
+	}
+	summary.FilesWithIssues = len(files)
+
+	return summary
+}
+
+// WriteMachineSummary writes a single-line summary of a run in the given
+// format ("text" or "json"), meant for scripts wrapping the linter
+func WriteMachineSummary(w io.Writer, format string, results []Result, filesScanned int) error {
+	summary := Summarize(results, filesScanned)
+
+	switch format {
+	case "json":
+		data, err := json.Marshal(summary)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case "text":
+		rules := make([]string, 0, len(summary.Rules))
+		for rule, count := range summary.Rules {
+			rules = append(rules, fmt.Sprintf("%s:%d", rule, count))
+		}
+		sort.Strings(rules)
+		_, err := fmt.Fprintf(w, "files=%d files_with_issues=%d errors=%d warnings=%d info=%d rules=%s\n",
+			summary.FilesScanned, summary.FilesWithIssues,
+			summary.Severities[SeverityError], summary.Severities[SeverityWarning], summary.Severities[SeverityInfo],
+			strings.Join(rules, ","))
+		return err
+	default:
+		return fmt.Errorf("unknown summary format %q", format)
+	}
+}
+
+// runStatus is the document written by WriteStatusFile
+type runStatus struct {
+	Passed     bool           `json:"passed"`
+	ExitCode   int            `json:"exit_code"`
+	Severities map[string]int `json:"severities"`
+}
+
+// WriteStatusFile writes a small JSON status document for CI orchestration:
+// whether the run passed, the exit code the process will use, and result
+// counts by severity. A run passes when its exit code is ExitSuccess.
+func WriteStatusFile(path string, results []Result, exitCode int) error {
+	status := runStatus{
+		Passed:   exitCode == ExitSuccess,
+		ExitCode: exitCode,
+		Severities: map[string]int{
+			SeverityError:   0,
+			SeverityWarning: 0,
+			SeverityInfo:    0,
+		},
+	}
+	for _, r := range results {
+		status.Severities[r.Severity]++
+	}
+
+	data, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write status file: %w", err)
+	}
+	return nil
+}
+
+// RunReport is a self-describing record of a run, written by
+// WriteRunReport: the codelint version, when the run happened, the
+// configuration it used and everything it found
+type RunReport struct {
+	Version      string       `json:"version"`
+	Timestamp    time.Time    `json:"timestamp"`
+	Config       Config       `json:"config"`
+	RulesSource  string       `json:"rules_source"`
+	RulesConfig  *RulesConfig `json:"rules_config"`
+	FilesScanned int          `json:"files_scanned"`
+	Summary      Summary      `json:"summary"`
+	Results      []Result     `json:"results"`
+}
+
+// NewRunReport builds the report of the linter's last Run, which produced
+// results
+func (l *Linter) NewRunReport(results []Result) RunReport {
+	if results == nil {
+		results = []Result{}
+	}
+	return RunReport{
+		Version:      Version,
+		Timestamp:    time.Now().UTC(),
+		Config:       l.config,
+		RulesSource:  l.rules.rulesConfig.Source,
+		RulesConfig:  l.rules.rulesConfig,
+		FilesScanned: l.filesScanned,
+		Summary:      Summarize(results, l.filesScanned),
+		Results:      results,
+	}
+}
+
+// WriteRunReport writes a run report as JSON to path, for audit trails and
+// reproducing a run later
+func WriteRunReport(path string, report RunReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write run report: %w", err)
+	}
+	return nil
+}
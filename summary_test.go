@@ -0,0 +1,187 @@
+package codelint
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// summaryResults is a small result set shared by the summary tests
+var summaryResults = []Result{
+	{File: "a.c", Line: 1, Severity: SeverityError, Rule: "license-headers"},
+	{File: "a.c", Line: 2, Severity: SeverityInfo, Rule: "line-length"},
+	{File: "b.h", Line: 1, Severity: SeverityWarning, Rule: "header-guards"},
+	{File: "b.h", Line: 9, Severity: SeverityInfo, Rule: "line-length"},
+	{Severity: SeverityInfo, Rule: "max-errors"},
+}
+
+func TestSummarize(t *testing.T) {
+	want := Summary{
+		FilesScanned:    3,
+		FilesWithIssues: 2,
+		Severities:      map[string]int{SeverityError: 1, SeverityWarning: 1, SeverityInfo: 3},
+		Rules:           map[string]int{"license-headers": 1, "line-length": 2, "header-guards": 1, "max-errors": 1},
+	}
+	if got := Summarize(summaryResults, 3); !reflect.DeepEqual(got, want) {
+		t.Errorf("Summarize() = %+v, want %+v", got, want)
+	}
+
+	empty := Summarize(nil, 0)
+	if empty.Severities[SeverityError] != 0 || len(empty.Severities) != 3 {
+		t.Errorf("Summarize(nil) severities %v, want all three at 0", empty.Severities)
+	}
+}
+
+func TestWriteMachineSummary(t *testing.T) {
+	t.Run("text", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteMachineSummary(&buf, "text", summaryResults, 3); err != nil {
+			t.Fatal(err)
+		}
+		want := "files=3 files_with_issues=2 errors=1 warnings=1 info=3 " +
+			"rules=header-guards:1,license-headers:1,line-length:2,max-errors:1\n"
+		if got := buf.String(); got != want {
+			t.Errorf("text summary %q, want %q", got, want)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteMachineSummary(&buf, "json", summaryResults, 3); err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Count(buf.Bytes(), []byte("\n")) != 1 {
+			t.Errorf("json summary %q is not a single line", buf.String())
+		}
+		var got Summary
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if want := Summarize(summaryResults, 3); !reflect.DeepEqual(got, want) {
+			t.Errorf("json summary %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteMachineSummary(&buf, "xml", summaryResults, 3); err == nil {
+			t.Error("WriteMachineSummary(xml) succeeded, want an error")
+		}
+	})
+}
+
+func TestWriteStatusFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		results  []Result
+		exitCode int
+		want     runStatus
+	}{
+		{
+			name:     "empty run",
+			exitCode: ExitSuccess,
+			want: runStatus{
+				Passed:     true,
+				ExitCode:   ExitSuccess,
+				Severities: map[string]int{SeverityError: 0, SeverityWarning: 0, SeverityInfo: 0},
+			},
+		},
+		{
+			name:     "failed run",
+			results:  summaryResults,
+			exitCode: ExitLintErrors,
+			want: runStatus{
+				Passed:     false,
+				ExitCode:   ExitLintErrors,
+				Severities: map[string]int{SeverityError: 1, SeverityWarning: 1, SeverityInfo: 3},
+			},
+		},
+		{
+			name:     "max errors",
+			results:  summaryResults,
+			exitCode: ExitMaxErrors,
+			want: runStatus{
+				Passed:     false,
+				ExitCode:   ExitMaxErrors,
+				Severities: map[string]int{SeverityError: 1, SeverityWarning: 1, SeverityInfo: 3},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "status.json")
+			if err := WriteStatusFile(path, tc.results, tc.exitCode); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got runStatus
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("status %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWriteRunReport(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{name: "clean run", files: map[string]string{"a.c": "/* Copyright */\nint a;\n"}},
+		{name: "findings", files: map[string]string{"a.c": "/* Copyright */\nint a; \n", "b.c": "int b;\t// b\n"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := testConfig(writeTree(t, tc.files))
+			linter := New(config)
+			results, err := linter.Run()
+			if err != nil {
+				t.Fatal(err)
+			}
+			report := linter.NewRunReport(results)
+			path := filepath.Join(t.TempDir(), "report.json")
+			if err := WriteRunReport(path, report); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var envelope map[string]json.RawMessage
+			if err := json.Unmarshal(data, &envelope); err != nil {
+				t.Fatal(err)
+			}
+			for _, key := range []string{"version", "timestamp", "config", "rules_source", "rules_config", "files_scanned", "summary", "results"} {
+				if _, ok := envelope[key]; !ok {
+					t.Errorf("report has no %q", key)
+				}
+			}
+
+			var got RunReport
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Version != Version || got.RulesSource != "default" || got.FilesScanned != len(tc.files) {
+				t.Errorf("version %q, rules source %q, %d files scanned", got.Version, got.RulesSource, got.FilesScanned)
+			}
+			if !got.Timestamp.Equal(report.Timestamp) || got.Config.RootDir != config.RootDir {
+				t.Errorf("timestamp %v with root %q, want %v with %q", got.Timestamp, got.Config.RootDir, report.Timestamp, config.RootDir)
+			}
+			if !reflect.DeepEqual(got.Results, report.Results) || !reflect.DeepEqual(got.Summary, report.Summary) {
+				t.Errorf("results %+v with summary %+v, want %+v with %+v", got.Results, got.Summary, report.Results, report.Summary)
+			}
+		})
+	}
+}
@@ -0,0 +1,5 @@
+package codelint
+
+// Version is the codelint release, recorded in run reports. Release builds
+// set it with -ldflags "-X github.com/nirohfeld/code_linter.Version=v1.2.3".
+var Version = "dev"
@@ -1,8 +1,10 @@
 package codelint
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -11,11 +13,216 @@ type FileInfo struct {
 	Path    string
 	Content []byte
 	Lines   []string
+
+	// CommentMask marks, per line and byte, whether the byte is part of a
+	// comment (including multi-line block comments)
+	CommentMask [][]bool
+
+	// StringMask marks, per line and byte, whether the byte is inside a
+	// string or character literal (including raw strings); the quotes
+	// themselves are not marked
+	StringMask [][]bool
+
+	// LineEnding is the file's line terminator style: LineEndingLF,
+	// LineEndingCRLF, LineEndingCR, LineEndingMixed, or "" for content
+	// without line breaks
+	LineEnding string
+
+	// Language forces C (LanguageC) or C++ (LanguageCPP) rule behavior;
+	// "" infers it from the file extension
+	Language string
+
+	// RelPath is the slash-separated path relative to the file's root
+	// directory, whatever form of Path is reported; per_file globs match
+	// against it. "" means Path.
+	RelPath string
+}
+
+// Languages that can be forced with FileInfo.Language and Config.Language
+const (
+	LanguageC   = "c"
+	LanguageCPP = "cpp"
+)
+
+// Line terminator styles recorded in FileInfo.LineEnding
+const (
+	LineEndingLF    = "lf"
+	LineEndingCRLF  = "crlf"
+	LineEndingCR    = "cr"
+	LineEndingMixed = "mixed"
+)
+
+// NewFileInfo builds a FileInfo from a file's content, splitting it into
+// lines and computing the comment and string masks
+func NewFileInfo(path string, content []byte) FileInfo {
+	lines, ending := splitLines(string(content))
+	classes := classifyLines(lines)
+	return FileInfo{
+		Path:        path,
+		Content:     content,
+		Lines:       lines,
+		CommentMask: maskOf(classes, classComment),
+		StringMask:  maskOf(classes, classString, classRawString),
+		LineEnding:  ending,
+	}
+}
+
+// splitLines splits content into lines on "\n", "\r\n" and lone "\r"
+// (classic Mac) terminators, returning the lines without terminators and
+// the terminator style. Like strings.Split, content ending in a terminator
+// yields a final empty line.
+func splitLines(content string) ([]string, string) {
+	var lines []string
+	ending := ""
+	start := 0
+	for i := 0; i < len(content); i++ {
+		var style string
+		switch content[i] {
+		case '\n':
+			style = LineEndingLF
+		case '\r':
+			style = LineEndingCR
+			if i+1 < len(content) && content[i+1] == '\n' {
+				style = LineEndingCRLF
+			}
+		default:
+			continue
+		}
+
+		lines = append(lines, content[start:i])
+		if style == LineEndingCRLF {
+			i++
+		}
+		start = i + 1
+
+		if ending == "" {
+			ending = style
+		} else if ending != style {
+			ending = LineEndingMixed
+		}
+	}
+	lines = append(lines, content[start:])
+	return lines, ending
+}
+
+// lineTerminator returns the terminator to write for a line ending style;
+// mixed files are written with "\n"
+func lineTerminator(ending string) string {
+	switch ending {
+	case LineEndingCRLF:
+		return "\r\n"
+	case LineEndingCR:
+		return "\r"
+	default:
+		return "\n"
+	}
+}
+
+// isCSource reports whether C-only rules apply to the file: a .c file,
+// unless another language is forced
+func (f FileInfo) isCSource() bool {
+	switch f.Language {
+	case LanguageC:
+		return true
+	case LanguageCPP:
+		return false
+	}
+	return strings.HasSuffix(f.Path, ".c")
+}
+
+// isCPPSource reports whether C++-only rules apply to the file: a .cc,
+// .cpp or .hpp file, unless another language is forced
+func (f FileInfo) isCPPSource() bool {
+	switch f.Language {
+	case LanguageCPP:
+		return true
+	case LanguageC:
+		return false
+	}
+	return strings.HasSuffix(f.Path, ".cc") || strings.HasSuffix(f.Path, ".cpp") || strings.HasSuffix(f.Path, ".hpp")
+}
+
+// matchPath returns the slash-separated path that path patterns of the
+// configuration are matched against
+func (f FileInfo) matchPath() string {
+	if f.RelPath != "" {
+		return f.RelPath
+	}
+	return filepath.ToSlash(f.Path)
+}
+
+// commentMask returns the comment mask, computing it if the FileInfo was
+// built without NewFileInfo
+func (f FileInfo) commentMask() [][]bool {
+	if f.CommentMask != nil {
+		return f.CommentMask
+	}
+	return maskOf(classifyLines(f.Lines), classComment)
+}
+
+// stringMask returns the string-literal mask, computing it if the
+// FileInfo was built without NewFileInfo
+func (f FileInfo) stringMask() [][]bool {
+	if f.StringMask != nil {
+		return f.StringMask
+	}
+	return maskOf(classifyLines(f.Lines), classString, classRawString)
+}
+
+// rawStringMask returns a mask of the bytes inside raw string literals only.
+// It is computed on demand since few rules need to tell raw strings apart.
+func (f FileInfo) rawStringMask() [][]bool {
+	return maskOf(classifyLines(f.Lines), classRawString)
+}
+
+// isCommentOnly reports whether a line holds a comment and nothing else
+// but whitespace
+func isCommentOnly(line string, mask []bool) bool {
+	hasComment := false
+	for i := 0; i < len(line); i++ {
+		if mask[i] {
+			hasComment = true
+		} else if line[i] != ' ' && line[i] != '\t' && line[i] != '\r' {
+			return false
+		}
+	}
+	return hasComment
+}
+
+// commentText returns the bytes of line that belong to comments
+func commentText(line string, mask []bool) string {
+	var b strings.Builder
+	for i := 0; i < len(line) && i < len(mask); i++ {
+		if mask[i] {
+			b.WriteByte(line[i])
+		}
+	}
+	return b.String()
+}
+
+// blankMasked returns line with the bytes marked in any of the masks
+// replaced by spaces
+func blankMasked(line string, masks ...[]bool) string {
+	buf := []byte(line)
+	for i := range buf {
+		for _, mask := range masks {
+			if mask[i] {
+				buf[i] = ' '
+				break
+			}
+		}
+	}
+	return string(buf)
 }
 
 // Walker handles file system traversal
 type Walker struct {
 	config Config
+
+	// ambiguous holds the slash-separated root-relative paths that files
+	// under different roots share in the last Walk or ReadFiles; those
+	// files are reported qualified by their root
+	ambiguous map[string]bool
 }
 
 // NewWalker creates a new file walker
@@ -27,56 +234,96 @@ func NewWalker(config Config) *Walker {
 func (w *Walker) Walk() ([]FileInfo, error) {
 	var files []FileInfo
 
-	for _, includeDir := range w.config.IncludeDirs {
-		rootPath := filepath.Join(w.config.RootDir, includeDir)
-		
-		err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
+	for _, root := range w.roots() {
+		ignore, err := loadIgnoreFile(filepath.Join(root, ignoreFileName))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", ignoreFileName, err)
+		}
+
+		for _, includeDir := range w.config.IncludeDirs {
+			if err := w.walkDir(root, filepath.Join(root, includeDir), ignore, &files); err != nil {
+				return nil, err
 			}
+		}
+	}
+
+	// Sort by slash-separated path so the checking order (and therefore
+	// MaxErrors early-stop and verbose progress) is the same on every OS
+	sort.SliceStable(files, func(i, j int) bool {
+		return filepath.ToSlash(files[i].Path) < filepath.ToSlash(files[j].Path)
+	})
+	w.markAmbiguous(files)
 
-			// Skip directories
+	return files, nil
+}
+
+// roots returns the root directories to scan
+func (w *Walker) roots() []string {
+	if len(w.config.RootDirs) > 0 {
+		return w.config.RootDirs
+	}
+	return []string{w.config.RootDir}
+}
+
+// walkDir collects the files to lint below rootPath, honoring the
+// ignore list of the root they belong to
+func (w *Walker) walkDir(root, rootPath string, ignore *ignoreList, files *[]FileInfo) error {
+	return filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Check the root's ignore file
+		if relPath, err := filepath.Rel(root, path); err == nil && relPath != "." &&
+			ignore.Match(filepath.ToSlash(relPath), info.IsDir()) {
 			if info.IsDir() {
-				// Check if this directory should be excluded
-				if w.shouldExcludeDir(path) {
-					return filepath.SkipDir
-				}
-				return nil
+				return filepath.SkipDir
 			}
+			return nil
+		}
 
-			// Check if file should be processed
-			if !w.shouldProcessFile(path) {
-				return nil
+		// Skip hidden files and directories below the walked directory
+		if w.config.SkipHidden && isHidden(rootPath, path) {
+			if info.IsDir() {
+				return filepath.SkipDir
 			}
+			return nil
+		}
 
-			// Read file content
-			content, err := os.ReadFile(path)
-			if err != nil {
-				// Skip files we can't read
-				if w.config.Verbose {
-					// Log the error but continue
-				}
-				return nil
+		// Skip directories
+		if info.IsDir() {
+			// Check if this directory should be excluded
+			if w.shouldExcludeDir(path) {
+				return filepath.SkipDir
 			}
+			return nil
+		}
 
-			// Split into lines for line-based analysis
-			lines := strings.Split(string(content), "\n")
-
-			files = append(files, FileInfo{
-				Path:    path,
-				Content: content,
-				Lines:   lines,
-			})
+		// Check if file should be processed
+		if !w.shouldProcessFile(path) {
+			return nil
+		}
 
+		// Skip files not modified recently enough
+		if !w.config.ChangedSince.IsZero() && info.ModTime().Before(w.config.ChangedSince) {
 			return nil
-		})
+		}
 
+		// Read file content
+		content, err := os.ReadFile(path)
 		if err != nil {
-			return nil, err
+			// Skip files we can't read
+			if w.config.Verbose {
+				// Log the error but continue
+			}
+			return nil
 		}
-	}
 
-	return files, nil
+		// Split into lines for line-based analysis
+		*files = append(*files, NewFileInfo(path, content))
+
+		return nil
+	})
 }
 
 // shouldExcludeDir checks if a directory should be excluded
@@ -113,11 +360,150 @@ func (w *Walker) shouldProcessFile(path string) bool {
 	return false
 }
 
-// GetRelativePath returns the path relative to the root directory
+// GetRelativePath returns the path relative to the root directory that
+// contains it; with several roots the most specific one wins. Files under
+// different roots that share a relative path are qualified by their root
+// (as given) so that they stay distinct.
 func (w *Walker) GetRelativePath(path string) string {
-	relPath, err := filepath.Rel(w.config.RootDir, path)
+	root, rel, ok := w.rootOf(path)
+	if !ok {
+		return path
+	}
+	if w.ambiguous[filepath.ToSlash(rel)] {
+		return filepath.Join(root, rel)
+	}
+	return rel
+}
+
+// rootOf returns the most specific root directory containing path and the
+// path relative to it; ok is false if no root contains path
+func (w *Walker) rootOf(path string) (root, rel string, ok bool) {
+	for _, candidate := range w.roots() {
+		relPath, err := filepath.Rel(candidate, path)
+		if err != nil {
+			// Mixed relative and absolute paths compare once both are absolute
+			absRoot, rootErr := filepath.Abs(candidate)
+			absPath, pathErr := filepath.Abs(path)
+			if rootErr != nil || pathErr != nil {
+				continue
+			}
+			if relPath, err = filepath.Rel(absRoot, absPath); err != nil {
+				continue
+			}
+		}
+		if relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if !ok || len(relPath) < len(rel) {
+			root, rel, ok = candidate, relPath, true
+		}
+	}
+	return root, rel, ok
+}
+
+// rootRelativePath returns path relative to the root directory containing
+// it with forward slashes, regardless of how paths are reported
+func (w *Walker) rootRelativePath(path string) string {
+	_, rel, ok := w.rootOf(path)
+	if !ok {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// markAmbiguous records the root-relative paths that files under
+// different roots have in common
+func (w *Walker) markAmbiguous(files []FileInfo) {
+	w.ambiguous = nil
+	if len(w.roots()) < 2 {
+		return
+	}
+	roots := make(map[string]string)
+	for _, file := range files {
+		root, rel, ok := w.rootOf(file.Path)
+		if !ok {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		if other, seen := roots[rel]; seen && other != root {
+			if w.ambiguous == nil {
+				w.ambiguous = make(map[string]bool)
+			}
+			w.ambiguous[rel] = true
+		}
+		roots[rel] = root
+	}
+}
+
+// ReadFiles loads an explicit list of files instead of walking the roots.
+// Files whose extension isn't in FileTypes are skipped; exclusions and
+// ignore files don't apply.
+func (w *Walker) ReadFiles(paths []string) ([]FileInfo, error) {
+	var files []FileInfo
+	for _, path := range paths {
+		if !w.shouldProcessFile(path) {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		files = append(files, NewFileInfo(path, content))
+	}
+	w.markAmbiguous(files)
+	return files, nil
+}
+
+// ReportPath returns the path used in results for a file on disk: relative
+// to its root unless AbsolutePaths is set, and with forward slashes when
+// SlashPaths or Canonical is set
+func (w *Walker) ReportPath(path string) string {
+	reported := w.GetRelativePath(path)
+	if w.config.PathsRelativeTo == PathsRelativeToCWD {
+		reported = relativeToCWD(path)
+	}
+	if w.config.AbsolutePaths {
+		if abs, err := filepath.Abs(path); err == nil {
+			reported = abs
+		}
+	}
+	if w.config.SlashPaths || w.config.Canonical {
+		reported = filepath.ToSlash(reported)
+	}
+	if w.config.Canonical {
+		reported = strings.ReplaceAll(reported, `\`, "/")
+	}
+	return reported
+}
+
+// relativeToCWD returns path relative to the working directory, or
+// unchanged if that can't be determined
+func relativeToCWD(path string) string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(cwd, abs)
 	if err != nil {
 		return path
 	}
-	return relPath
-}
\ No newline at end of file
+	return rel
+}
+
+// isHidden reports whether any segment of path below base starts with '.'
+func isHidden(base, path string) bool {
+	rel, err := filepath.Rel(base, path)
+	if err != nil || rel == "." {
+		return false
+	}
+	for _, segment := range strings.Split(filepath.ToSlash(rel), "/") {
+		if strings.HasPrefix(segment, ".") && segment != "." && segment != ".." {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,462 @@
+package codelint
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// walkPaths walks the tree described by config and returns the reported
+// path of each file found, in walk order
+func walkPaths(t *testing.T, config Config) []string {
+	t.Helper()
+	walker := NewWalker(config)
+	files, err := walker.Walk()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, file := range files {
+		paths = append(paths, walker.ReportPath(file.Path))
+	}
+	return paths
+}
+
+func TestWalkOrder(t *testing.T) {
+	// filepath.Walk visits a/ before a-b/, but '-' sorts before '/'
+	root := writeTree(t, map[string]string{
+		"b.c":     "int b;\n",
+		"a/z.c":   "int z;\n",
+		"a-b/x.c": "int x;\n",
+		"A.c":     "int a;\n",
+		"a/y.h":   "int y;\n",
+	})
+	config := testConfig(root)
+	config.SlashPaths = true
+
+	want := []string{"A.c", "a-b/x.c", "a/y.h", "a/z.c", "b.c"}
+	for run := 0; run < 3; run++ {
+		if got := walkPaths(t, config); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Walk() order %v, want %v", got, want)
+		}
+	}
+}
+
+func TestGetRelativePath(t *testing.T) {
+	tests := []struct {
+		name  string
+		roots []string
+		path  string
+		want  string
+	}{
+		{
+			name:  "single root",
+			roots: []string{"/src"},
+			path:  "/src/lib/a.c",
+			want:  "lib/a.c",
+		},
+		{
+			name:  "outside the root",
+			roots: []string{"/src"},
+			path:  "/other/a.c",
+			want:  "/other/a.c",
+		},
+		{
+			name:  "several roots",
+			roots: []string{"/repo/a", "/repo/b"},
+			path:  "/repo/b/lib/x.c",
+			want:  "lib/x.c",
+		},
+		{
+			name:  "relative roots",
+			roots: []string{"a", "b"},
+			path:  "a/x.c",
+			want:  "x.c",
+		},
+		{
+			name:  "most specific root",
+			roots: []string{"/repo", "/repo/sub"},
+			path:  "/repo/sub/x.c",
+			want:  "x.c",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := DefaultConfig()
+			if len(tc.roots) == 1 {
+				config.RootDir = filepath.FromSlash(tc.roots[0])
+			} else {
+				for _, root := range tc.roots {
+					config.RootDirs = append(config.RootDirs, filepath.FromSlash(root))
+				}
+			}
+			got := NewWalker(config).GetRelativePath(filepath.FromSlash(tc.path))
+			if want := filepath.FromSlash(tc.want); got != want {
+				t.Errorf("GetRelativePath(%s) = %s, want %s", tc.path, got, want)
+			}
+		})
+	}
+}
+
+func TestWalkChangedSince(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"old.c":    "int old;\n",
+		"recent.c": "int recent;\n",
+		"new.h":    "int new;\n",
+	})
+	now := time.Now()
+	for name, age := range map[string]time.Duration{"old.c": 48 * time.Hour, "recent.c": time.Hour, "new.h": 0} {
+		mtime := now.Add(-age)
+		if err := os.Chtimes(filepath.Join(root, name), mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		since time.Time
+		want  []string
+	}{
+		{"all files", time.Time{}, []string{"new.h", "old.c", "recent.c"}},
+		{"last day", now.Add(-24 * time.Hour), []string{"new.h", "recent.c"}},
+		{"last minute", now.Add(-time.Minute), []string{"new.h"}},
+		{"future", now.Add(time.Hour), nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := testConfig(root)
+			config.ChangedSince = tc.since
+			if got := walkPaths(t, config); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Walk() found %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// maskPicture renders a mask as one string per line, '#' marking masked
+// bytes and '.' the others
+func maskPicture(mask [][]bool) []string {
+	var picture []string
+	for _, line := range mask {
+		b := make([]byte, len(line))
+		for i, masked := range line {
+			b[i] = '.'
+			if masked {
+				b[i] = '#'
+			}
+		}
+		picture = append(picture, string(b))
+	}
+	return picture
+}
+
+func TestCommentMask(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "line comment",
+			content: "int x; // note",
+			want:    []string{".......#######"},
+		},
+		{
+			name:    "block comment spanning lines",
+			content: "/* a\nif (x) {\n*/ int y;",
+			want:    []string{"####", "########", "##......."},
+		},
+		{
+			name:    "code between comments",
+			content: "/* a */ int f(); /* b */",
+			want:    []string{"#######..........#######"},
+		},
+		{
+			name:    "comment markers in strings",
+			content: "char *s = \"/* x */\"; // y",
+			want:    []string{".....................####"},
+		},
+		{
+			name:    "line comment inside a block comment",
+			content: "/* // */ int z;",
+			want:    []string{"########......."},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			file := NewFileInfo("test.c", []byte(tc.content))
+			if got := maskPicture(file.CommentMask); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("CommentMask\n%v, want\n%v", got, tc.want)
+			}
+			// FileInfo values built by hand compute the same mask
+			bare := FileInfo{Path: file.Path, Lines: file.Lines}
+			if got := maskPicture(bare.commentMask()); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("commentMask() without NewFileInfo\n%v, want\n%v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCommentOnlyLines(t *testing.T) {
+	file := NewFileInfo("test.c", []byte("/*\n * body\n */ int x;\n   // note\nint y;\n"))
+	want := []bool{true, true, false, true, false, false}
+	for i, line := range file.Lines {
+		if got := isCommentOnly(line, file.CommentMask[i]); got != want[i] {
+			t.Errorf("isCommentOnly(%q) = %v, want %v", line, got, want[i])
+		}
+	}
+}
+
+func TestStringMask(t *testing.T) {
+	// The quotes around a literal are not marked
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "string literal",
+			content: `f("a+b", x);`,
+			want:    []string{"...###......"},
+		},
+		{
+			name:    "escaped quote",
+			content: `s = "a\"b"; t;`,
+			want:    []string{".....####....."},
+		},
+		{
+			name:    "character literals",
+			content: `c = '"'; d = '\'';`,
+			want:    []string{".....#........##.."},
+		},
+		{
+			name:    "raw string spanning lines",
+			content: "s = R\"x(a \"quoted\"\n)\" still)x\"; y;",
+			want:    []string{"......############", "##########....."},
+		},
+		{
+			name:    "quotes in comments",
+			content: `x; // "not a string"`,
+			want:    []string{"...................."},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			file := NewFileInfo("test.cpp", []byte(tc.content))
+			if got := maskPicture(file.StringMask); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("StringMask\n%v, want\n%v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWalkSkipHidden(t *testing.T) {
+	parent := writeTree(t, map[string]string{
+		".proj/main.c":        "int m;\n",
+		".proj/.hidden/a.c":   "int a;\n",
+		".proj/.dot.h":        "int d;\n",
+		".proj/src/.local.c":  "int l;\n",
+		".proj/src/visible.c": "int v;\n",
+		".proj/.git/hook.c":   "int h;\n",
+	})
+	// The root itself may be hidden
+	root := filepath.Join(parent, ".proj")
+
+	tests := []struct {
+		name       string
+		skipHidden bool
+		want       []string
+	}{
+		{"default", false, []string{".dot.h", ".hidden/a.c", "main.c", "src/.local.c", "src/visible.c"}},
+		{"skip hidden", true, []string{"main.c", "src/visible.c"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := testConfig(root)
+			config.SlashPaths = true
+			config.SkipHidden = tc.skipHidden
+			if got := walkPaths(t, config); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Walk() found %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestReportPath(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "lib", "util.c")
+
+	tests := []struct {
+		name   string
+		config func(*Config)
+		want   string
+	}{
+		{
+			name: "relative to the root",
+			want: filepath.Join("lib", "util.c"),
+		},
+		{
+			name:   "forward slashes",
+			config: func(c *Config) { c.SlashPaths = true },
+			want:   "lib/util.c",
+		},
+		{
+			name:   "absolute",
+			config: func(c *Config) { c.AbsolutePaths = true },
+			want:   path,
+		},
+		{
+			name:   "absolute with forward slashes",
+			config: func(c *Config) { c.AbsolutePaths, c.SlashPaths = true, true },
+			want:   filepath.ToSlash(path),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := testConfig(root)
+			if tc.config != nil {
+				tc.config(&config)
+			}
+			if got := NewWalker(config).ReportPath(path); got != tc.want {
+				t.Errorf("ReportPath() = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPathsRelativeToCWD(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(root, "lib", "util.c")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	tests := []struct {
+		name       string
+		cwd        string
+		relativeTo string
+		want       string
+	}{
+		{
+			name:       "root base",
+			cwd:        filepath.Join(root, "lib"),
+			relativeTo: PathsRelativeToRoot,
+			want:       filepath.Join("lib", "util.c"),
+		},
+		{
+			name: "root base by default",
+			cwd:  filepath.Join(root, "lib"),
+			want: filepath.Join("lib", "util.c"),
+		},
+		{
+			name:       "cwd below the root",
+			cwd:        filepath.Join(root, "lib"),
+			relativeTo: PathsRelativeToCWD,
+			want:       "util.c",
+		},
+		{
+			name:       "cwd above the root",
+			cwd:        filepath.Dir(root),
+			relativeTo: PathsRelativeToCWD,
+			want:       filepath.Join(filepath.Base(root), "lib", "util.c"),
+		},
+		{
+			name:       "cwd at the root",
+			cwd:        root,
+			relativeTo: PathsRelativeToCWD,
+			want:       filepath.Join("lib", "util.c"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := os.Chdir(tc.cwd); err != nil {
+				t.Fatal(err)
+			}
+			config := testConfig(root)
+			config.PathsRelativeTo = tc.relativeTo
+			if got := NewWalker(config).ReportPath(path); got != tc.want {
+				t.Errorf("ReportPath() = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRunReportsRelativePaths(t *testing.T) {
+	root := writeTree(t, map[string]string{"lib/util.c": "int x; \n"})
+	config := testConfig(root)
+	config.SlashPaths = true
+
+	// A root given with a trailing separator still yields relative paths
+	config.RootDir = root + string(filepath.Separator)
+	results, err := New(config).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		if result.File != "" && result.File != "lib/util.c" {
+			t.Errorf("result file %s, want lib/util.c", result.File)
+		}
+	}
+	if len(results) == 0 {
+		t.Error("no results")
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		lines   []string
+		ending  string
+	}{
+		{name: "empty", content: "", lines: []string{""}},
+		{name: "no terminator", content: "int x;", lines: []string{"int x;"}},
+		{name: "LF", content: "a\nb\n", lines: []string{"a", "b", ""}, ending: LineEndingLF},
+		{name: "CRLF", content: "a\r\nb\r\n", lines: []string{"a", "b", ""}, ending: LineEndingCRLF},
+		{name: "CR only", content: "a\rb\r", lines: []string{"a", "b", ""}, ending: LineEndingCR},
+		{name: "blank CR lines", content: "a\r\r\rb", lines: []string{"a", "", "", "b"}, ending: LineEndingCR},
+		{name: "mixed", content: "a\nb\r\nc\rd", lines: []string{"a", "b", "c", "d"}, ending: LineEndingMixed},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			lines, ending := splitLines(tc.content)
+			if !reflect.DeepEqual(lines, tc.lines) || ending != tc.ending {
+				t.Errorf("splitLines(%q) = %q, %q; want %q, %q", tc.content, lines, ending, tc.lines, tc.ending)
+			}
+		})
+	}
+}
+
+func TestCROnlyFile(t *testing.T) {
+	file := NewFileInfo("test.c", []byte("/* Copyright */\rint x; \r/* a\r   b */ int y;\r"))
+	if file.LineEnding != LineEndingCR || len(file.Lines) != 5 {
+		t.Fatalf("LineEnding %q with %d lines, want %q with 5", file.LineEnding, len(file.Lines), LineEndingCR)
+	}
+	if got := maskPicture(file.CommentMask); !reflect.DeepEqual(got, []string{"###############", ".......", "####", "#######.......", ""}) {
+		t.Errorf("comment mask %q", got)
+	}
+
+	rule := &TrailingWhitespaceRule{rulesConfig: defaultRulesConfig()}
+	if got, want := positions(rule.Check(file), "trailing-whitespace"), []string{"2:7"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("trailing-whitespace at %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,104 @@
+package codelint
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultWebhookTimeout bounds each attempt to deliver results to a webhook
+const DefaultWebhookTimeout = 10 * time.Second
+
+// Webhook posts the results of a run to a URL as the JSON document written
+// by JSONReporter, e.g. to feed a dashboard. Only results are sent; nothing
+// is read back from the server.
+type Webhook struct {
+	// URL is the http or https endpoint the results are POSTed to
+	URL string
+
+	// Client sends the requests (nil = a client with DefaultWebhookTimeout)
+	Client *http.Client
+
+	// Attempts is the number of tries before giving up (0 = 3)
+	Attempts int
+
+	// Backoff is the wait before the first retry, doubled after each
+	// failed attempt (0 = 1s)
+	Backoff time.Duration
+}
+
+// NewWebhook returns a Webhook for rawURL, rejecting anything that isn't an
+// absolute http or https URL
+func NewWebhook(rawURL string) (*Webhook, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid webhook URL %q: want an http or https URL", rawURL)
+	}
+	return &Webhook{URL: rawURL}, nil
+}
+
+// Post sends results and summary to the webhook. Connection errors and 5xx
+// responses are retried; any other non-2xx response fails immediately.
+func (w *Webhook) Post(results []Result, summary Summary) error {
+	var body bytes.Buffer
+	if err := (JSONReporter{}).Report(&body, results, summary); err != nil {
+		return err
+	}
+
+	client := w.Client
+	if client == nil {
+		client = &http.Client{Timeout: DefaultWebhookTimeout}
+	}
+	attempts := w.Attempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+	backoff := w.Backoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+
+		retry, err := w.send(client, body.Bytes())
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+	return fmt.Errorf("posting results to webhook: %w", lastErr)
+}
+
+// send makes a single delivery attempt, reporting whether a failure is
+// worth retrying
+func (w *Webhook) send(client *http.Client, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "codelint/"+Version)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	return resp.StatusCode >= 500, fmt.Errorf("server responded %s", resp.Status)
+}
@@ -0,0 +1,124 @@
+package codelint
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewWebhook(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{url: "http://localhost:8080/hook"},
+		{url: "https://dashboard.example.com/api/lint"},
+		{url: "ftp://example.com/hook", wantErr: true},
+		{url: "/relative/path", wantErr: true},
+		{url: "http://", wantErr: true},
+		{url: "::not a url", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		_, err := NewWebhook(tc.url)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("NewWebhook(%q) error = %v, want error %v", tc.url, err, tc.wantErr)
+		}
+	}
+}
+
+func TestWebhookPost(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		requests int
+		wantErr  bool
+	}{
+		{name: "delivered", statuses: []int{http.StatusOK}, requests: 1},
+		{name: "retried after server errors", statuses: []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusNoContent}, requests: 3},
+		{name: "gives up after the attempts", statuses: []int{http.StatusInternalServerError}, requests: 3, wantErr: true},
+		{name: "client errors not retried", statuses: []int{http.StatusForbidden}, requests: 1, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var payloads []json.RawMessage
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+					t.Errorf("%s request with content type %q", r.Method, r.Header.Get("Content-Type"))
+				}
+				var payload json.RawMessage
+				if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+					t.Errorf("invalid payload: %v", err)
+				}
+				payloads = append(payloads, payload)
+
+				status := tc.statuses[len(tc.statuses)-1]
+				if len(payloads) <= len(tc.statuses) {
+					status = tc.statuses[len(payloads)-1]
+				}
+				w.WriteHeader(status)
+			}))
+			defer server.Close()
+
+			webhook, err := NewWebhook(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			webhook.Client = server.Client()
+			webhook.Backoff = time.Millisecond
+
+			summary := Summarize(reportResults, 2)
+			err = webhook.Post(reportResults, summary)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("Post() error = %v, want error %v", err, tc.wantErr)
+			}
+			if len(payloads) != tc.requests {
+				t.Fatalf("%d requests, want %d", len(payloads), tc.requests)
+			}
+
+			var doc struct {
+				Results []Result `json:"results"`
+				Summary Summary  `json:"summary"`
+			}
+			if err := json.Unmarshal(payloads[0], &doc); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(doc.Results, reportResults) || !reflect.DeepEqual(doc.Summary, summary) {
+				t.Errorf("posted %+v with summary %+v, want %+v with %+v", doc.Results, doc.Summary, reportResults, summary)
+			}
+		})
+	}
+}
+
+func TestWebhookTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	webhook, err := NewWebhook(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	webhook.Client = &http.Client{Timeout: 20 * time.Millisecond}
+	webhook.Attempts = 2
+	webhook.Backoff = time.Millisecond
+
+	start := time.Now()
+	if err := webhook.Post(nil, Summary{}); err == nil {
+		t.Error("Post() succeeded against a server that never responds")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Post() took %s", elapsed)
+	}
+}